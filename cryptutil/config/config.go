@@ -3,3 +3,22 @@ package config
 type CryptUtilConfig struct {
 	DefaultCost int `mapstructure:"default_cost"`
 }
+
+// KeyConfig 是密钥环中的一个 AES 密钥, 密钥为 base64 编码的 16/24/32 字节, 来源为 Key, KeyEnv, KeyFile 之一
+type KeyConfig struct {
+	ID      string `mapstructure:"id"`
+	Key     string `mapstructure:"key"`
+	KeyEnv  string `mapstructure:"key_env"`
+	KeyFile string `mapstructure:"key_file"`
+}
+
+/*
+KeyRingConfig 配置字段加密使用的密钥环:
+  - PrimaryKeyID 用于加密新数据, Keys 中的其他密钥只用于解密旧数据, 轮换密钥时追加新密钥并修改 PrimaryKeyID
+  - BlindIndexKey 是计算盲索引(HMAC-SHA256)的密钥, 修改后已有的盲索引全部失效, 需要重新计算
+*/
+type KeyRingConfig struct {
+	PrimaryKeyID  string      `mapstructure:"primary_key_id"`
+	Keys          []KeyConfig `mapstructure:"keys"`
+	BlindIndexKey KeyConfig   `mapstructure:"blind_index_key"`
+}
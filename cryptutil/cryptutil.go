@@ -14,3 +14,32 @@ type CryptUtil interface {
 func NewCryptUtil(config config.CryptUtilConfig) CryptUtil {
 	return internal.NewCryptUtil(config)
 }
+
+var (
+	// ErrUnknownKey 表示密文使用的密钥不在密钥环中
+	ErrUnknownKey = internal.ErrUnknownKey
+	// ErrInvalidCiphertext 表示密文格式错误或认证失败(被篡改或密钥错误)
+	ErrInvalidCiphertext = internal.ErrInvalidCiphertext
+)
+
+/*
+KeyRing 使用 AES-GCM 加密字段, 密文中包含密钥ID, 因此轮换主密钥后旧数据仍可解密.
+
+KeyRing encrypts with the primary key and decrypts with whichever key the ciphertext names.
+*/
+type KeyRing interface {
+	// PrimaryKeyID 返回加密新数据使用的密钥ID
+	PrimaryKeyID() string
+	// Seal 使用主密钥加密, 每次加密使用随机 nonce, 相同明文得到不同密文
+	Seal(plaintext []byte) ([]byte, error)
+	// Open 按密文中的密钥ID解密, 密钥不存在返回 ErrUnknownKey, 密文无效返回 ErrInvalidCiphertext
+	Open(ciphertext []byte) ([]byte, error)
+	// KeyID 返回密文使用的密钥ID, 用于找出需要重新加密的数据
+	KeyID(ciphertext []byte) (string, error)
+	// BlindIndex 返回明文的 HMAC-SHA256(十六进制), 相同明文得到相同结果, 用于等值查询
+	BlindIndex(plaintext []byte) (string, error)
+}
+
+func NewKeyRing(config config.KeyRingConfig) (KeyRing, error) {
+	return internal.NewKeyRing(config)
+}
@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/LouYuanbo1/go-webservice/cryptutil/config"
+	"github.com/LouYuanbo1/go-webservice/internal/secret"
+)
+
+var (
+	ErrUnknownKey        = errors.New("unknown encryption key")
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+)
+
+// 密文格式: 版本(1字节) | 密钥ID长度(1字节) | 密钥ID | nonce | AES-GCM 密文与认证标签
+const ciphertextVersion = 1
+
+type keyRing struct {
+	primaryID  string
+	aeads      map[string]cipher.AEAD
+	blindIndex []byte
+}
+
+func NewKeyRing(cfg config.KeyRingConfig) (*keyRing, error) {
+	if len(cfg.Keys) == 0 {
+		return nil, fmt.Errorf("NewKeyRing(crypto): 密钥环为空")
+	}
+	kr := &keyRing{primaryID: cfg.PrimaryKeyID, aeads: make(map[string]cipher.AEAD, len(cfg.Keys))}
+	for _, k := range cfg.Keys {
+		if k.ID == "" || len(k.ID) > 255 {
+			return nil, fmt.Errorf("NewKeyRing(crypto): 密钥ID长度必须为 1-255: %q", k.ID)
+		}
+		if _, ok := kr.aeads[k.ID]; ok {
+			return nil, fmt.Errorf("NewKeyRing(crypto): 密钥ID重复: %q", k.ID)
+		}
+		key, err := decodeKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("NewKeyRing(crypto): 密钥 %q: %w", k.ID, err)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("NewKeyRing(crypto): 密钥 %q: %w", k.ID, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("NewKeyRing(crypto): 密钥 %q: %w", k.ID, err)
+		}
+		kr.aeads[k.ID] = aead
+	}
+	if _, ok := kr.aeads[kr.primaryID]; !ok {
+		return nil, fmt.Errorf("NewKeyRing(crypto): 主密钥 %q 不在密钥环中", kr.primaryID)
+	}
+
+	if cfg.BlindIndexKey != (config.KeyConfig{}) {
+		key, err := decodeKey(cfg.BlindIndexKey)
+		if err != nil {
+			return nil, fmt.Errorf("NewKeyRing(crypto): 盲索引密钥: %w", err)
+		}
+		if len(key) < 32 {
+			return nil, fmt.Errorf("NewKeyRing(crypto): 盲索引密钥至少需要 32 字节")
+		}
+		kr.blindIndex = key
+	}
+	return kr, nil
+}
+
+func decodeKey(k config.KeyConfig) ([]byte, error) {
+	encoded, err := secret.Resolve(k.Key, k.KeyEnv, k.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("密钥必须为 base64 编码: %w", err)
+	}
+	return key, nil
+}
+
+func (kr *keyRing) PrimaryKeyID() string {
+	return kr.primaryID
+}
+
+func (kr *keyRing) Seal(plaintext []byte) ([]byte, error) {
+	aead := kr.aeads[kr.primaryID]
+	header := make([]byte, 0, 2+len(kr.primaryID)+aead.NonceSize())
+	header = append(header, ciphertextVersion, byte(len(kr.primaryID)))
+	header = append(header, kr.primaryID...)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("Seal(crypto): 生成 nonce 失败:%v", err)
+	}
+	// 以版本与密钥ID作为附加数据, 防止密文头被篡改
+	return aead.Seal(append(header, nonce...), nonce, plaintext, header), nil
+}
+
+func (kr *keyRing) Open(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < 2 || ciphertext[0] != ciphertextVersion {
+		return nil, ErrInvalidCiphertext
+	}
+	idEnd := 2 + int(ciphertext[1])
+	if len(ciphertext) < idEnd {
+		return nil, ErrInvalidCiphertext
+	}
+	keyID := string(ciphertext[2:idEnd])
+	aead, ok := kr.aeads[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, keyID)
+	}
+	if len(ciphertext) < idEnd+aead.NonceSize()+aead.Overhead() {
+		return nil, ErrInvalidCiphertext
+	}
+	header := ciphertext[:idEnd]
+	nonce := ciphertext[idEnd : idEnd+aead.NonceSize()]
+	plaintext, err := aead.Open(nil, nonce, ciphertext[idEnd+aead.NonceSize():], header)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCiphertext, err)
+	}
+	return plaintext, nil
+}
+
+func (kr *keyRing) KeyID(ciphertext []byte) (string, error) {
+	if len(ciphertext) < 2 || ciphertext[0] != ciphertextVersion || len(ciphertext) < 2+int(ciphertext[1]) {
+		return "", ErrInvalidCiphertext
+	}
+	return string(ciphertext[2 : 2+int(ciphertext[1])]), nil
+}
+
+func (kr *keyRing) BlindIndex(plaintext []byte) (string, error) {
+	if kr.blindIndex == nil {
+		return "", fmt.Errorf("BlindIndex(crypto): 未配置盲索引密钥")
+	}
+	mac := hmac.New(sha256.New, kr.blindIndex)
+	mac.Write(plaintext)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,164 @@
+package cryptutil_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/LouYuanbo1/go-webservice/cryptutil"
+	"github.com/LouYuanbo1/go-webservice/cryptutil/config"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+func newKeyRing(t *testing.T, primary string, keys ...config.KeyConfig) cryptutil.KeyRing {
+	t.Helper()
+	kr, err := cryptutil.NewKeyRing(config.KeyRingConfig{
+		PrimaryKeyID:  primary,
+		Keys:          keys,
+		BlindIndexKey: config.KeyConfig{Key: testKey(9)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kr
+}
+
+var (
+	key1 = config.KeyConfig{ID: "k1", Key: testKey(1)}
+	key2 = config.KeyConfig{ID: "k2", Key: testKey(2)}
+)
+
+func TestSealOpen(t *testing.T) {
+	kr := newKeyRing(t, "k1", key1)
+	plaintext := []byte("13800000000")
+
+	first, err := kr.Seal(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := kr.Seal(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first, second) {
+		t.Error("sealing the same plaintext twice produced the same ciphertext")
+	}
+	if bytes.Contains(first, plaintext) {
+		t.Error("ciphertext contains the plaintext")
+	}
+	for _, ciphertext := range [][]byte{first, second} {
+		got, err := kr.Open(ciphertext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("open = %q, want %q", got, plaintext)
+		}
+	}
+	if id, err := kr.KeyID(first); err != nil || id != "k1" {
+		t.Errorf("key id = %q, %v", id, err)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	old := newKeyRing(t, "k1", key1)
+	ciphertext, err := old.Seal([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 轮换: 追加 k2 并设为主密钥, k1 仍可解密旧数据
+	rotated := newKeyRing(t, "k2", key1, key2)
+	got, err := rotated.Open(ciphertext)
+	if err != nil || string(got) != "secret" {
+		t.Fatalf("open old ciphertext = %q, %v", got, err)
+	}
+	fresh, err := rotated.Seal([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := rotated.KeyID(fresh); id != "k2" {
+		t.Errorf("new ciphertext key id = %q, want k2", id)
+	}
+
+	// 移除 k1 后旧数据无法解密
+	_, err = newKeyRing(t, "k2", key2).Open(ciphertext)
+	if !errors.Is(err, cryptutil.ErrUnknownKey) {
+		t.Errorf("expected ErrUnknownKey, got %v", err)
+	}
+}
+
+func TestTamper(t *testing.T) {
+	kr := newKeyRing(t, "k1", key1, key2)
+	ciphertext, err := kr.Seal([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 密文格式: 版本 | 密钥ID长度 | 密钥ID("k1") | nonce | 密文与认证标签
+	cases := map[string]func(b []byte) []byte{
+		"Version": func(b []byte) []byte { b[0] ^= 0xff; return b },
+		// 将密钥ID改为同样存在的 k2, 只能通过认证发现
+		"KeyID":     func(b []byte) []byte { b[3] = '2'; return b },
+		"Nonce":     func(b []byte) []byte { b[4] ^= 1; return b },
+		"Body":      func(b []byte) []byte { b[len(b)-1] ^= 1; return b },
+		"Truncated": func(b []byte) []byte { return b[:10] },
+		"Empty":     func(b []byte) []byte { return nil },
+	}
+	for name, tamper := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := kr.Open(tamper(bytes.Clone(ciphertext)))
+			if !errors.Is(err, cryptutil.ErrInvalidCiphertext) {
+				t.Errorf("expected ErrInvalidCiphertext, got %v", err)
+			}
+		})
+	}
+
+	// 同一密钥ID但密钥不同
+	other := newKeyRing(t, "k1", config.KeyConfig{ID: "k1", Key: testKey(3)})
+	if _, err := other.Open(ciphertext); !errors.Is(err, cryptutil.ErrInvalidCiphertext) {
+		t.Errorf("wrong key: expected ErrInvalidCiphertext, got %v", err)
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	kr := newKeyRing(t, "k1", key1)
+	a, err := kr.BlindIndex([]byte("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := kr.BlindIndex([]byte("alice"))
+	other, _ := kr.BlindIndex([]byte("bob"))
+	if a != again || a == other || len(a) != 64 {
+		t.Errorf("blind index alice=%s again=%s bob=%s", a, again, other)
+	}
+
+	noIndex, err := cryptutil.NewKeyRing(config.KeyRingConfig{PrimaryKeyID: "k1", Keys: []config.KeyConfig{key1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := noIndex.BlindIndex([]byte("alice")); err == nil {
+		t.Error("expected an error without a blind index key")
+	}
+}
+
+func TestNewKeyRingErrors(t *testing.T) {
+	cases := map[string]config.KeyRingConfig{
+		"Empty":          {PrimaryKeyID: "k1"},
+		"MissingPrimary": {PrimaryKeyID: "k3", Keys: []config.KeyConfig{key1}},
+		"DuplicateID":    {PrimaryKeyID: "k1", Keys: []config.KeyConfig{key1, key1}},
+		"BadBase64":      {PrimaryKeyID: "k1", Keys: []config.KeyConfig{{ID: "k1", Key: "not base64!"}}},
+		"BadLength":      {PrimaryKeyID: "k1", Keys: []config.KeyConfig{{ID: "k1", Key: base64.StdEncoding.EncodeToString([]byte("short"))}}},
+		"ShortBlindKey":  {PrimaryKeyID: "k1", Keys: []config.KeyConfig{key1}, BlindIndexKey: config.KeyConfig{Key: base64.StdEncoding.EncodeToString(make([]byte, 16))}},
+	}
+	for name, cfg := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := cryptutil.NewKeyRing(cfg); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Package ratelimit 提供基于 redisx.RateLimiter 的 Gin 限流中间件.
+
+每个响应都会设置以下响应头, 被拒绝时返回 429 并设置 Retry-After (秒):
+
+	X-RateLimit-Limit     限额
+	X-RateLimit-Remaining 剩余可用的请求数
+	X-RateLimit-Reset     限额完全恢复的秒数
+
+Example:
+
+	limiter, err := redisx.NewRateLimiter(client, redisx.SlidingWindowCounter, 100, time.Minute)
+	if err != nil {
+		return err
+	}
+	// 已登录用户按用户限流, 未登录按 IP 限流
+	r.Use(ratelimit.Middleware(limiter, ratelimit.WithKeyFunc(
+		ratelimit.FirstOf(ratelimit.ByContextKey("user_id"), ratelimit.ByIP()),
+	)))
+*/
+package ratelimit
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/redisx"
+	"github.com/gin-gonic/gin"
+)
+
+// KeyFunc 返回请求的限流 key, 返回 false 时不限流
+type KeyFunc func(c *gin.Context) (string, bool)
+
+// ByIP 按客户端 IP 限流, IP 的解析遵循 gin 的 TrustedProxies 配置
+func ByIP() KeyFunc {
+	return func(c *gin.Context) (string, bool) {
+		ip := c.ClientIP()
+		return "ip:" + ip, ip != ""
+	}
+}
+
+// ByContextKey 按 gin.Context 中保存的值(例如认证中间件设置的用户ID)限流, 值不存在时不限流
+func ByContextKey(key string) KeyFunc {
+	return func(c *gin.Context) (string, bool) {
+		value, ok := c.Get(key)
+		if !ok || value == nil {
+			return "", false
+		}
+		s := fmt.Sprint(value)
+		return key + ":" + s, s != ""
+	}
+}
+
+// ByHeader 按请求头(例如 X-API-Key)限流, 请求头为空时不限流
+func ByHeader(name string) KeyFunc {
+	return func(c *gin.Context) (string, bool) {
+		value := c.GetHeader(name)
+		return name + ":" + value, value != ""
+	}
+}
+
+// FirstOf 依次尝试 fns, 使用第一个返回 true 的 key
+func FirstOf(fns ...KeyFunc) KeyFunc {
+	return func(c *gin.Context) (string, bool) {
+		for _, fn := range fns {
+			if key, ok := fn(c); ok {
+				return key, true
+			}
+		}
+		return "", false
+	}
+}
+
+type config struct {
+	keyFunc       KeyFunc
+	deniedHandler func(c *gin.Context, res redisx.RateLimitResult)
+	errorHandler  func(c *gin.Context, err error)
+}
+
+type Option func(*config)
+
+// WithKeyFunc 设置限流 key 的提取方式, 默认 ByIP()
+func WithKeyFunc(fn KeyFunc) Option {
+	return func(c *config) {
+		c.keyFunc = fn
+	}
+}
+
+// WithDeniedHandler 设置请求被拒绝时的响应, 默认返回 429 与 JSON 错误信息, 响应头已在调用前设置
+func WithDeniedHandler(fn func(c *gin.Context, res redisx.RateLimitResult)) Option {
+	return func(c *config) {
+		c.deniedHandler = fn
+	}
+}
+
+// WithErrorHandler 设置 Redis 出错时的处理, 默认记录日志并放行(fail open)
+func WithErrorHandler(fn func(c *gin.Context, err error)) Option {
+	return func(c *config) {
+		c.errorHandler = fn
+	}
+}
+
+// Middleware 返回使用 limiter 限流的 Gin 中间件
+func Middleware(limiter redisx.RateLimiter, opts ...Option) gin.HandlerFunc {
+	cfg := &config{
+		keyFunc: ByIP(),
+		deniedHandler: func(c *gin.Context, res redisx.RateLimitResult) {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "too many requests"})
+		},
+		errorHandler: func(c *gin.Context, err error) {
+			log.Printf("rate limit error, request allowed: %v", err)
+			c.Next()
+		},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(c *gin.Context) {
+		key, ok := cfg.keyFunc(c)
+		if !ok {
+			c.Next()
+			return
+		}
+		res, err := limiter.Allow(c.Request.Context(), key)
+		if err != nil {
+			cfg.errorHandler(c, err)
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(ceilSeconds(res.ResetAfter), 10))
+		if !res.Allowed {
+			c.Header("Retry-After", strconv.FormatInt(max(ceilSeconds(res.RetryAfter), 1), 10))
+			cfg.deniedHandler(c, res)
+			return
+		}
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
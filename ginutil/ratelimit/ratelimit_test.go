@@ -0,0 +1,138 @@
+package ratelimit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/ginutil/ratelimit"
+	"github.com/LouYuanbo1/go-webservice/redisx"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func newRouter(t *testing.T, opts ...ratelimit.Option) (*miniredis.Miniredis, *gin.Engine) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	m := miniredis.RunT(t)
+	m.SetTime(time.Unix(1_700_000_000, 0))
+	client := redis.NewClient(&redis.Options{Addr: m.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	limiter, err := redisx.NewRateLimiter(client, redisx.SlidingWindowLog, 2, 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.Use(ratelimit.Middleware(limiter, opts...))
+	r.GET("/", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	return m, r
+}
+
+func get(r http.Handler, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddlewareHeadersAndDenial(t *testing.T) {
+	_, r := newRouter(t)
+
+	for i, remaining := range []string{"1", "0"} {
+		w := get(r, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d", i, w.Code)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Errorf("request %d: X-RateLimit-Limit = %q", i, got)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != remaining {
+			t.Errorf("request %d: X-RateLimit-Remaining = %q, want %s", i, got, remaining)
+		}
+		if got := w.Header().Get("X-RateLimit-Reset"); got != "10" {
+			t.Errorf("request %d: X-RateLimit-Reset = %q", i, got)
+		}
+		if w.Header().Get("Retry-After") != "" {
+			t.Errorf("request %d: Retry-After set on an allowed request", i)
+		}
+	}
+
+	w := get(r, nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "10" {
+		t.Errorf("Retry-After = %q, want 10", got)
+	}
+	if got := w.Header().Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("X-RateLimit-Remaining = %q", got)
+	}
+	if w.Body.String() != `{"error":"too many requests"}` {
+		t.Errorf("body = %s", w.Body)
+	}
+}
+
+func TestMiddlewareKeyFunc(t *testing.T) {
+	_, r := newRouter(t, ratelimit.WithKeyFunc(ratelimit.ByHeader("X-API-Key")))
+
+	// 没有 key 的请求不限流, 也不设置响应头
+	for range 3 {
+		w := get(r, nil)
+		if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "" {
+			t.Fatalf("request without key: status %d, headers %v", w.Code, w.Header())
+		}
+	}
+	a := http.Header{"X-Api-Key": {"a"}}
+	b := http.Header{"X-Api-Key": {"b"}}
+	get(r, a)
+	get(r, a)
+	if w := get(r, a); w.Code != http.StatusTooManyRequests {
+		t.Errorf("third request of key a: status %d", w.Code)
+	}
+	if w := get(r, b); w.Code != http.StatusOK {
+		t.Errorf("key b shares the limit of key a: status %d", w.Code)
+	}
+}
+
+func TestMiddlewareFailOpen(t *testing.T) {
+	m, r := newRouter(t)
+	m.Close()
+
+	w := get(r, nil)
+	if w.Code != http.StatusOK || w.Body.String() != "ok" {
+		t.Fatalf("Redis down: status %d, body %s, want the request to pass", w.Code, w.Body)
+	}
+	if w.Header().Get("X-RateLimit-Limit") != "" {
+		t.Error("rate limit headers set without a result")
+	}
+}
+
+func TestMiddlewareCustomHandlers(t *testing.T) {
+	var handled error
+	m, r := newRouter(t,
+		ratelimit.WithDeniedHandler(func(c *gin.Context, res redisx.RateLimitResult) {
+			c.AbortWithStatus(http.StatusServiceUnavailable)
+		}),
+		ratelimit.WithErrorHandler(func(c *gin.Context, err error) {
+			handled = err
+			c.AbortWithStatus(http.StatusInternalServerError)
+		}),
+	)
+	get(r, nil)
+	get(r, nil)
+	if w := get(r, nil); w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Errorf("denied: status %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	m.Close()
+	if w := get(r, nil); w.Code != http.StatusInternalServerError || handled == nil {
+		t.Errorf("fail closed: status %d, error %v", w.Code, handled)
+	}
+}
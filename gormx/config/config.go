@@ -1,12 +1,22 @@
 package config
 
+import "github.com/LouYuanbo1/go-webservice/internal/retry"
+
 type DBConfig struct {
 	Type     string `mapstructure:"type"`
 	Host     string `mapstructure:"host"`
 	Port     int    `mapstructure:"port"`
 	User     string `mapstructure:"user"`
 	Password string `mapstructure:"password"`
-	DBName   string `mapstructure:"dbname"`
+	// 从环境变量读取密码, 与 Password, PasswordFile 互斥
+	PasswordEnv string `mapstructure:"password_env"`
+	// 从文件读取密码 (e.g. Docker / Kubernetes secret), 与 Password, PasswordEnv 互斥
+	PasswordFile string `mapstructure:"password_file"`
+	DBName       string `mapstructure:"dbname"`
+	// 完整的 DSN, 设置后忽略 Host, Port, User, 密码, DBName, TimeZone, ExtraParams 与方言配置
+	DSN string `mapstructure:"dsn"`
+	// 追加到 DSN 的驱动参数 (e.g. MySQL "readTimeout": "3s", Postgres "application_name": "api")
+	ExtraParams map[string]string `mapstructure:"extra_params"`
 	// 最大打开连接数 (建议值: 25)
 	MaxOpenConns int `mapstructure:"max_open_conns"`
 	// 最大空闲连接数 (建议值: 25)
@@ -22,6 +32,8 @@ type DBConfig struct {
 
 	MySQL    MySQL    `mapstructure:"mysql"`
 	Postgres Postgres `mapstructure:"postgres"`
+	// 启动时的连接重试
+	Retry RetryConfig `mapstructure:"retry"`
 }
 
 type MySQL struct {
@@ -31,3 +43,40 @@ type MySQL struct {
 type Postgres struct {
 	SSLMode string `mapstructure:"ssl_mode"`
 }
+
+/*
+IDGenConfig 是 idgen.Snowflake 的配置.
+WorkerIDSource 为 "config"(默认) 时使用 WorkerID, 为 "redis" 时通过 Redis 租约自动分配.
+*/
+type IDGenConfig struct {
+	// worker ID 来源 "config" | "redis"
+	WorkerIDSource string `mapstructure:"worker_id_source"`
+	// worker ID, 取值范围 [0, 1023]
+	WorkerID int64 `mapstructure:"worker_id"`
+	// Redis 租约 key 前缀 (默认 "gormx:idgen:worker")
+	LeaseKeyPrefix string `mapstructure:"lease_key_prefix"`
+	// Redis 租约有效期 (默认 30s)
+	LeaseTTL string `mapstructure:"lease_ttl"`
+	// 纪元, RFC3339 格式 (默认 "2024-01-01T00:00:00Z"), 同一集群的所有实例必须一致
+	Epoch string `mapstructure:"epoch"`
+	// 可等待的最大时钟回拨 (默认 10ms), 超过后返回错误
+	MaxClockBackward string `mapstructure:"max_clock_backward"`
+}
+
+/*
+DatabasesConfig 是多数据库配置, key 为连接名, 用于 gormx.NewRegistry.
+
+Example (yaml):
+
+	databases:
+	  main:
+	    type: postgres
+	    host: 127.0.0.1
+	  analytics:
+	    type: mysql
+	    host: 127.0.0.1
+*/
+type DatabasesConfig map[string]DBConfig
+
+// RetryConfig 是启动时的连接重试配置, 与 redisx 共用, 见 retry.Config
+type RetryConfig = retry.Config
@@ -0,0 +1,271 @@
+/*
+Package drift 比较 Go 模型与数据库中的实际表结构, 用于在 CI 中发现未迁移的模型修改.
+
+表结构通过 gorm Migrator 读取 (Postgres / MySQL 查询 information_schema, SQLite 使用 pragma),
+类型比较规则与 gorm AutoMigrate 一致. 检测结果可以生成建议的迁移文件,
+文件名为 {version}_{name}.up.sql / .down.sql, 与 golang-migrate 等版本化迁移工具兼容.
+
+Package drift compares registered models with the live database schema and
+suggests a versioned migration for the differences.
+
+Example:
+
+	d := drift.New(db)
+	d.Register(&User{}, &Order{})
+	report, err := d.Detect(ctx)
+	if err != nil {
+		return err
+	}
+	if report.HasDrift() {
+		migration, err := report.Migration()
+		if err == nil {
+			drift.WriteMigration("migrations", "sync_models", migration, time.Now())
+		}
+		return fmt.Errorf("schema drift:\n%s", report)
+	}
+*/
+package drift
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/LouYuanbo1/go-webservice/gormx/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Kind 是差异的类型
+type Kind string
+
+const (
+	MissingTable     Kind = "missing_table"
+	MissingColumn    Kind = "missing_column"
+	ExtraColumn      Kind = "extra_column"
+	TypeMismatch     Kind = "type_mismatch"
+	NullableMismatch Kind = "nullable_mismatch"
+	MissingIndex     Kind = "missing_index"
+	ExtraIndex       Kind = "extra_index"
+	IndexMismatch    Kind = "index_mismatch"
+)
+
+// Issue 是一处模型与数据库的差异
+type Issue struct {
+	Kind   Kind
+	Table  string
+	Column string
+	Index  string
+	// 模型期望的值 Expected, 数据库中的实际值 Actual
+	Expected string
+	Actual   string
+}
+
+func (i Issue) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s", i.Kind, i.Table)
+	if i.Column != "" {
+		fmt.Fprintf(&b, ".%s", i.Column)
+	}
+	if i.Index != "" {
+		fmt.Fprintf(&b, " index %s", i.Index)
+	}
+	switch {
+	case i.Expected != "" && i.Actual != "":
+		fmt.Fprintf(&b, " (expected %q, actual %q)", i.Expected, i.Actual)
+	case i.Expected != "":
+		fmt.Fprintf(&b, " (expected %q)", i.Expected)
+	case i.Actual != "":
+		fmt.Fprintf(&b, " (actual %q)", i.Actual)
+	}
+	return b.String()
+}
+
+// Detector 保存需要检查的模型
+type Detector struct {
+	db     *gorm.DB
+	models []schema.Tabler
+}
+
+func New(db *gorm.DB) *Detector {
+	return &Detector{db: db}
+}
+
+// Register 注册需要检查的模型, 通常传入模型指针, 例如 &User{}
+func (d *Detector) Register(models ...schema.Tabler) *Detector {
+	d.models = append(d.models, models...)
+	return d
+}
+
+/*
+Detect 比较已注册的模型与数据库, 报告:
+  - 缺失的表与列, 数据库中多余的列
+  - 列类型与可空性不一致 (主键列不检查类型, 与 AutoMigrate 一致)
+  - 模型声明的索引缺失, 唯一性不一致或列不一致, 以及数据库中多余的索引
+*/
+func (d *Detector) Detect(ctx context.Context) (*Report, error) {
+	db := d.db.WithContext(ctx)
+	report := &Report{db: db}
+	for _, m := range d.models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(m); err != nil {
+			return nil, errors.New(errors.ErrQueryFailed, "Detect", m.TableName(), err)
+		}
+		issues, err := detectTable(db, m, stmt.Schema)
+		if err != nil {
+			return nil, errors.New(errors.ErrQueryFailed, "Detect", stmt.Schema.Table, err)
+		}
+		report.Issues = append(report.Issues, issues...)
+		report.models = append(report.models, m)
+	}
+	return report, nil
+}
+
+func detectTable(db *gorm.DB, m any, s *schema.Schema) ([]Issue, error) {
+	migrator := db.Migrator()
+	if !migrator.HasTable(m) {
+		return []Issue{{Kind: MissingTable, Table: s.Table}}, nil
+	}
+
+	columnTypes, err := migrator.ColumnTypes(m)
+	if err != nil {
+		return nil, err
+	}
+	actual := make(map[string]gorm.ColumnType, len(columnTypes))
+	for _, ct := range columnTypes {
+		actual[strings.ToLower(ct.Name())] = ct
+	}
+
+	var issues []Issue
+	expected := make(map[string]bool)
+	for _, field := range s.Fields {
+		if field.DBName == "" || field.IgnoreMigration {
+			continue
+		}
+		expected[strings.ToLower(field.DBName)] = true
+		ct, ok := actual[strings.ToLower(field.DBName)]
+		if !ok {
+			issues = append(issues, Issue{Kind: MissingColumn, Table: s.Table, Column: field.DBName, Expected: fullDataType(migrator, field)})
+			continue
+		}
+		issues = append(issues, compareColumn(db, s.Table, field, ct)...)
+	}
+	for _, ct := range columnTypes {
+		if !expected[strings.ToLower(ct.Name())] {
+			issues = append(issues, Issue{Kind: ExtraColumn, Table: s.Table, Column: ct.Name(), Actual: ct.DatabaseTypeName()})
+		}
+	}
+
+	indexIssues, err := compareIndexes(migrator, m, s)
+	if err != nil {
+		return nil, err
+	}
+	return append(issues, indexIssues...), nil
+}
+
+func fullDataType(migrator gorm.Migrator, field *schema.Field) string {
+	return strings.TrimSpace(strings.ToLower(migrator.FullDataTypeOf(field).SQL))
+}
+
+// compareColumn 按 gorm Migrator.MigrateColumn 的规则比较类型, 长度与可空性
+func compareColumn(db *gorm.DB, table string, field *schema.Field, ct gorm.ColumnType) []Issue {
+	var issues []Issue
+	migrator := db.Migrator()
+	expectedType := strings.ToLower(db.Dialector.DataTypeOf(field))
+	realType := strings.ToLower(ct.DatabaseTypeName())
+
+	if !field.PrimaryKey && !strings.HasPrefix(expectedType, realType) {
+		sameType := false
+		for _, alias := range migrator.GetTypeAliases(realType) {
+			if strings.HasPrefix(expectedType, alias) {
+				sameType = true
+				break
+			}
+		}
+		if !sameType {
+			issues = append(issues, Issue{Kind: TypeMismatch, Table: table, Column: field.DBName, Expected: expectedType, Actual: realType})
+		}
+	}
+	if length, ok := ct.Length(); ok && length > 0 && field.Size > 0 && length != int64(field.Size) {
+		issues = append(issues, Issue{Kind: TypeMismatch, Table: table, Column: field.DBName, Expected: fmt.Sprintf("size %d", field.Size), Actual: fmt.Sprintf("size %d", length)})
+	}
+	if nullable, ok := ct.Nullable(); ok && !field.PrimaryKey && nullable == field.NotNull {
+		issues = append(issues, Issue{Kind: NullableMismatch, Table: table, Column: field.DBName, Expected: nullability(!field.NotNull), Actual: nullability(nullable)})
+	}
+	return issues
+}
+
+func nullability(nullable bool) string {
+	if nullable {
+		return "NULL"
+	}
+	return "NOT NULL"
+}
+
+func compareIndexes(migrator gorm.Migrator, m any, s *schema.Schema) ([]Issue, error) {
+	indexes, err := migrator.GetIndexes(m)
+	if err != nil {
+		return nil, err
+	}
+	actual := make(map[string]gorm.Index, len(indexes))
+	for _, idx := range indexes {
+		actual[idx.Name()] = idx
+	}
+
+	var issues []Issue
+	expected := make(map[string]bool)
+	for _, idx := range s.ParseIndexes() {
+		expected[idx.Name] = true
+		columns := make([]string, 0, len(idx.Fields))
+		for _, opt := range idx.Fields {
+			columns = append(columns, opt.DBName)
+		}
+		got, ok := actual[idx.Name]
+		if !ok {
+			issues = append(issues, Issue{Kind: MissingIndex, Table: s.Table, Index: idx.Name, Expected: strings.Join(columns, ",")})
+			continue
+		}
+		// 列与唯一性都不一致时只报告一条差异, 否则迁移会重复删除并重建同一个索引
+		wantUnique := idx.Class == "UNIQUE"
+		gotUnique, ok := got.Unique()
+		if !ok {
+			gotUnique = wantUnique
+		}
+		if !slices.Equal(columns, got.Columns()) || gotUnique != wantUnique {
+			issues = append(issues, Issue{Kind: IndexMismatch, Table: s.Table, Index: idx.Name, Expected: describeIndex(columns, wantUnique), Actual: describeIndex(got.Columns(), gotUnique)})
+		}
+	}
+
+	for _, idx := range indexes {
+		if expected[idx.Name()] || isImplicitIndex(s, idx) {
+			continue
+		}
+		issues = append(issues, Issue{Kind: ExtraIndex, Table: s.Table, Index: idx.Name(), Actual: strings.Join(idx.Columns(), ",")})
+	}
+	return issues, nil
+}
+
+func describeIndex(columns []string, unique bool) string {
+	if unique {
+		return fmt.Sprintf("UNIQUE (%s)", strings.Join(columns, ","))
+	}
+	return fmt.Sprintf("NON-UNIQUE (%s)", strings.Join(columns, ","))
+}
+
+// isImplicitIndex 判断索引是否由主键或 `gorm:"unique"` 列约束自动创建
+func isImplicitIndex(s *schema.Schema, idx gorm.Index) bool {
+	if primary, ok := idx.PrimaryKey(); ok && primary {
+		return true
+	}
+	columns := idx.Columns()
+	if len(columns) == len(s.PrimaryFieldDBNames) && slices.Equal(columns, s.PrimaryFieldDBNames) {
+		return true
+	}
+	if unique, ok := idx.Unique(); ok && unique && len(columns) == 1 {
+		if field := s.LookUpField(columns[0]); field != nil && field.Unique {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package drift_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/gormx/drift"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// legacyAccount 是数据库中已有的表结构
+type legacyAccount struct {
+	ID     uint64 `gorm:"primaryKey"`
+	Name   string `gorm:"index:idx_accounts_name"`
+	Legacy string
+}
+
+func (m *legacyAccount) TableName() string { return "accounts" }
+
+// account 是修改后的模型: 新增 email 列与索引, 删除 legacy 列, idx_accounts_name 改为 (name, email) 唯一索引
+type account struct {
+	ID    uint64 `gorm:"primaryKey"`
+	Name  string `gorm:"uniqueIndex:idx_accounts_name,priority:1"`
+	Email string `gorm:"uniqueIndex:idx_accounts_name,priority:2;index:idx_accounts_email"`
+}
+
+func (m *account) TableName() string { return "accounts" }
+
+type auditLog struct {
+	ID      uint64 `gorm:"primaryKey"`
+	Message string
+}
+
+func (m *auditLog) TableName() string { return "audit_logs" }
+
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&legacyAccount{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func kinds(report *drift.Report) map[string]drift.Kind {
+	got := make(map[string]drift.Kind, len(report.Issues))
+	for _, issue := range report.Issues {
+		key := issue.Table + "." + issue.Column + issue.Index
+		if _, dup := got[key]; dup {
+			key += "#dup"
+		}
+		got[key] = issue.Kind
+	}
+	return got
+}
+
+func TestDetect(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+
+	report, err := drift.New(db).Register(&account{}, &auditLog{}).Detect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.HasDrift() {
+		t.Fatal("expected drift")
+	}
+	want := map[string]drift.Kind{
+		"accounts.email":              drift.MissingColumn,
+		"accounts.legacy":             drift.ExtraColumn,
+		"accounts.idx_accounts_email": drift.MissingIndex,
+		"accounts.idx_accounts_name":  drift.IndexMismatch,
+		"audit_logs.":                 drift.MissingTable,
+	}
+	got := kinds(report)
+	if len(got) != len(want) {
+		t.Fatalf("issues = %v, want %v\n%s", got, want, report)
+	}
+	for key, kind := range want {
+		if got[key] != kind {
+			t.Errorf("%s: kind = %q, want %q\n%s", key, got[key], kind, report)
+		}
+	}
+
+	clean, err := drift.New(db).Register(&legacyAccount{}).Detect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clean.HasDrift() {
+		t.Errorf("unchanged model reported drift:\n%s", clean)
+	}
+}
+
+func TestMigration(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	detector := drift.New(db).Register(&account{}, &auditLog{})
+
+	report, err := detector.Detect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migration, err := report.Migration()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(migration.Up, "DROP INDEX `idx_accounts_name`;"); n != 1 {
+		t.Errorf("up drops idx_accounts_name %d times:\n%s", n, migration.Up)
+	}
+	if !strings.Contains(migration.Up, "-- ALTER TABLE `accounts` DROP COLUMN `legacy`") {
+		t.Errorf("extra column should only be dropped in a comment:\n%s", migration.Up)
+	}
+	if !strings.Contains(migration.Down, "DROP TABLE `audit_logs`;") {
+		t.Errorf("down does not drop the new table:\n%s", migration.Down)
+	}
+
+	if err := db.Exec(migration.Up).Error; err != nil {
+		t.Fatalf("apply up: %v\n%s", err, migration.Up)
+	}
+	report, err = detector.Detect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := kinds(report)
+	if len(got) != 1 || got["accounts.legacy"] != drift.ExtraColumn {
+		t.Errorf("after up only the extra column should remain:\n%s", report)
+	}
+
+	// 索引不一致的回滚只生成注释, 需要人工处理, 其余语句按与 Up 相反的顺序撤销
+	for _, stmt := range []string{"DROP TABLE `audit_logs`;", "DROP INDEX `idx_accounts_email`;", "ALTER TABLE `accounts` DROP COLUMN `email`;", "-- revert index_mismatch"} {
+		if !strings.Contains(migration.Down, stmt) {
+			t.Errorf("down is missing %q:\n%s", stmt, migration.Down)
+		}
+	}
+}
+
+func TestWriteMigration(t *testing.T) {
+	dir := t.TempDir()
+	at := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	m := drift.Migration{Up: "CREATE TABLE t (id int);\n", Down: "DROP TABLE t;\n"}
+
+	up, down, err := drift.WriteMigration(dir, "create_t", m, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(up) != "20240506070809_create_t.up.sql" || filepath.Base(down) != "20240506070809_create_t.down.sql" {
+		t.Errorf("paths = %s, %s", up, down)
+	}
+	if data, _ := os.ReadFile(up); string(data) != m.Up {
+		t.Errorf("up content = %q", data)
+	}
+	if _, _, err := drift.WriteMigration(dir, "create_t", m, at); err == nil {
+		t.Error("expected an error when the migration already exists")
+	}
+
+	// .down.sql 已存在时写入失败, 不应留下新建的 .up.sql
+	conflict := filepath.Join(dir, "20240506070810_conflict.down.sql")
+	if err := os.WriteFile(conflict, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := drift.WriteMigration(dir, "conflict", m, at.Add(time.Second)); err == nil {
+		t.Fatal("expected an error when the down file exists")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	want := []string{"20240506070809_create_t.down.sql", "20240506070809_create_t.up.sql", "20240506070810_conflict.down.sql"}
+	if !slices.Equal(names, want) {
+		t.Errorf("files = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,230 @@
+package drift
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// Report 是一次检测的结果
+type Report struct {
+	Issues []Issue
+
+	db     *gorm.DB
+	models []schema.Tabler
+}
+
+// HasDrift 判断模型与数据库是否存在差异
+func (r *Report) HasDrift() bool {
+	return len(r.Issues) > 0
+}
+
+func (r *Report) String() string {
+	lines := make([]string, len(r.Issues))
+	for i, issue := range r.Issues {
+		lines[i] = issue.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Migration 是建议的迁移脚本
+type Migration struct {
+	Up   string
+	Down string
+}
+
+/*
+Migration 根据差异生成建议的迁移脚本, 提交前需要人工检查:
+  - 缺失的表, 列与索引生成创建语句, Down 中生成对应的删除语句
+  - 类型与可空性不一致生成 ALTER 语句 (SQLite 不支持修改列, 生成注释)
+  - 多余的列与索引生成注释掉的删除语句, 避免误删数据
+*/
+func (r *Report) Migration() (Migration, error) {
+	var up, down []string
+	for _, issue := range r.Issues {
+		m := r.model(issue.Table)
+		if m == nil {
+			continue
+		}
+		u, d, err := r.statements(m, issue)
+		if err != nil {
+			return Migration{}, err
+		}
+		up = append(up, u...)
+		// Down 按相反的顺序撤销
+		down = append(d, down...)
+	}
+	return Migration{Up: joinStatements(up), Down: joinStatements(down)}, nil
+}
+
+func (r *Report) model(table string) schema.Tabler {
+	for _, m := range r.models {
+		if m.TableName() == table {
+			return m
+		}
+	}
+	return nil
+}
+
+func (r *Report) statements(m schema.Tabler, issue Issue) (up, down []string, err error) {
+	quote := r.db.Statement.Quote
+	table := quote(issue.Table)
+	dialect := r.db.Dialector.Name()
+
+	switch issue.Kind {
+	case MissingTable:
+		up, err = r.capture(func(migrator gorm.Migrator) error {
+			return migrator.CreateTable(m)
+		})
+		down = []string{fmt.Sprintf("DROP TABLE %s", table)}
+	case MissingColumn:
+		up = []string{fmt.Sprintf("ALTER TABLE %s ADD %s %s", table, quote(issue.Column), issue.Expected)}
+		down = []string{fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, quote(issue.Column))}
+	case ExtraColumn:
+		up = []string{fmt.Sprintf("-- ALTER TABLE %s DROP COLUMN %s", table, quote(issue.Column))}
+	case TypeMismatch, NullableMismatch:
+		up, err = r.alterColumn(m, issue)
+		down = []string{fmt.Sprintf("-- revert %s", issue)}
+	case MissingIndex:
+		up, err = r.capture(func(migrator gorm.Migrator) error {
+			return migrator.CreateIndex(m, issue.Index)
+		})
+		down = []string{dropIndex(dialect, quote, issue.Table, issue.Index)}
+	case IndexMismatch:
+		var create []string
+		create, err = r.capture(func(migrator gorm.Migrator) error {
+			return migrator.CreateIndex(m, issue.Index)
+		})
+		up = append([]string{dropIndex(dialect, quote, issue.Table, issue.Index)}, create...)
+		down = []string{fmt.Sprintf("-- revert %s", issue)}
+	case ExtraIndex:
+		up = []string{"-- " + dropIndex(dialect, quote, issue.Table, issue.Index)}
+	}
+	return up, down, err
+}
+
+func (r *Report) alterColumn(m schema.Tabler, issue Issue) ([]string, error) {
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(m); err != nil {
+		return nil, err
+	}
+	field := stmt.Schema.LookUpField(issue.Column)
+	if field == nil {
+		return nil, fmt.Errorf("unknown column %q", issue.Column)
+	}
+
+	quote := r.db.Statement.Quote
+	table, column := quote(issue.Table), quote(issue.Column)
+	migrator := r.db.Migrator()
+	switch r.db.Dialector.Name() {
+	case "postgres":
+		if issue.Kind == NullableMismatch {
+			action := "SET NOT NULL"
+			if !field.NotNull {
+				action = "DROP NOT NULL"
+			}
+			return []string{fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s", table, column, action)}, nil
+		}
+		dataType := r.db.Dialector.DataTypeOf(field)
+		return []string{fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::%s", table, column, dataType, column, dataType)}, nil
+	case "mysql":
+		return []string{fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s", table, column, migrator.FullDataTypeOf(field).SQL)}, nil
+	default:
+		return []string{fmt.Sprintf("-- %s cannot alter %s.%s in place, rebuild the table: %s", r.db.Dialector.Name(), issue.Table, issue.Column, issue)}, nil
+	}
+}
+
+func dropIndex(dialect string, quote func(any) string, table, index string) string {
+	if dialect == "mysql" {
+		return fmt.Sprintf("DROP INDEX %s ON %s", quote(index), quote(table))
+	}
+	return fmt.Sprintf("DROP INDEX %s", quote(index))
+}
+
+// recorder 记录 DryRun 模式下生成的 SQL
+type recorder struct {
+	logger.Interface
+	statements []string
+}
+
+func (r *recorder) LogMode(logger.LogLevel) logger.Interface {
+	return r
+}
+
+func (r *recorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
+
+// capture 在 DryRun 模式下执行 Migrator 操作, 返回生成的语句而不访问数据库
+func (r *Report) capture(fn func(migrator gorm.Migrator) error) ([]string, error) {
+	rec := &recorder{Interface: logger.Discard}
+	tx := r.db.Session(&gorm.Session{DryRun: true, Logger: rec})
+	if err := fn(tx.Migrator()); err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(rec.statements, func(s string) bool { return strings.TrimSpace(s) == "" }), nil
+}
+
+func joinStatements(statements []string) string {
+	var b strings.Builder
+	for _, s := range statements {
+		b.WriteString(s)
+		if !strings.HasPrefix(s, "--") {
+			b.WriteString(";")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+/*
+WriteMigration 将迁移写入 dir/{version}_{name}.up.sql 与 .down.sql, version 格式为 20060102150405,
+返回两个文件的路径. 文件已存在时返回错误. 写入失败时删除本次已创建的文件, 不会留下只有一半的迁移.
+*/
+func WriteMigration(dir, name string, m Migration, at time.Time) (string, string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", "", err
+	}
+	prefix := filepath.Join(dir, fmt.Sprintf("%s_%s", at.UTC().Format("20060102150405"), name))
+	upPath, downPath := prefix+".up.sql", prefix+".down.sql"
+	files := []struct {
+		path    string
+		content string
+	}{
+		{upPath, m.Up},
+		{downPath, m.Down},
+	}
+	var created []string
+	for _, file := range files {
+		if err := writeNewFile(file.path, file.content, &created); err != nil {
+			for _, path := range created {
+				_ = os.Remove(path)
+			}
+			return "", "", err
+		}
+	}
+	return upPath, downPath, nil
+}
+
+// writeNewFile 创建并写入文件, 文件创建成功后即记录到 created 中, 以便失败时清理
+func writeNewFile(path, content string, created *[]string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	*created = append(*created, path)
+	_, err = f.WriteString(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,109 @@
+package gormx
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/gormx/config"
+	"github.com/LouYuanbo1/go-webservice/gormx/errors"
+	"github.com/LouYuanbo1/go-webservice/internal/secret"
+	mysqldriver "github.com/go-sql-driver/mysql"
+)
+
+/*
+BuildDSN 根据配置构建连接字符串, 设置了 DSN 时直接返回 DSN.
+密码按 Password, PasswordEnv, PasswordFile 解析, 所有值都会按驱动的规则转义,
+因此密码中的 @ / 空格 引号等字符不会破坏 DSN. ExtraParams 按键名排序追加.
+
+BuildDSN builds a properly escaped DSN for config, or returns config.DSN when set.
+*/
+func BuildDSN(config *config.DBConfig) (string, error) {
+	if config == nil {
+		return "", errors.ErrInvalidInitConfig
+	}
+	if config.DSN != "" {
+		return config.DSN, nil
+	}
+
+	password, err := secret.Resolve(config.Password, config.PasswordEnv, config.PasswordFile)
+	if err != nil {
+		return "", errors.New(errors.ErrInvalidInitConfig, "BuildDSN", config.DBName, err)
+	}
+
+	// 构建时区参数（默认Local）
+	timeZone := config.TimeZone
+	if timeZone == "" {
+		timeZone = "Asia/Shanghai"
+	}
+
+	switch config.Type {
+	case "postgres":
+		sslMode := config.Postgres.SSLMode
+		if sslMode == "" {
+			sslMode = "disable"
+		}
+		params := [][2]string{
+			{"host", config.Host},
+			{"user", config.User},
+			{"password", password},
+			{"dbname", config.DBName},
+			{"port", strconv.Itoa(config.Port)},
+			{"sslmode", sslMode},
+			{"TimeZone", timeZone},
+		}
+		for _, key := range slices.Sorted(maps.Keys(config.ExtraParams)) {
+			params = append(params, [2]string{key, config.ExtraParams[key]})
+		}
+		pairs := make([]string, len(params))
+		for i, p := range params {
+			pairs[i] = p[0] + "=" + quotePostgresValue(p[1])
+		}
+		return strings.Join(pairs, " "), nil
+	case "mysql":
+		tls := config.MySQL.TLS
+		if tls == "" {
+			tls = "false"
+		}
+		loc, err := time.LoadLocation(timeZone)
+		if err != nil {
+			return "", errors.New(errors.ErrInvalidInitConfig, "BuildDSN", config.DBName, fmt.Errorf("load time zone: %w", err))
+		}
+		cfg := mysqldriver.NewConfig()
+		cfg.User = config.User
+		cfg.Passwd = password
+		cfg.Net = "tcp"
+		cfg.Addr = net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+		cfg.DBName = config.DBName
+		cfg.ParseTime = true
+		cfg.Loc = loc
+		cfg.TLSConfig = tls
+		cfg.Params = map[string]string{"charset": "utf8mb4"}
+		// FormatDSN 会对参数值做 URL 转义, 这里保存未转义的原始值
+		maps.Copy(cfg.Params, config.ExtraParams)
+		return cfg.FormatDSN(), nil
+	default:
+		return "", errors.New(errors.ErrInvalidInitConfig, "BuildDSN", config.DBName, fmt.Errorf("暂时不支持的数据库类型: %s", config.Type))
+	}
+}
+
+// quotePostgresValue 按 libpq key=value 格式转义: 空值或包含空白, 引号, 反斜杠的值用单引号包裹
+func quotePostgresValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n\r\v\f'\\") {
+		return value
+	}
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range value {
+		if r == '\'' || r == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
@@ -3,6 +3,7 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 var (
@@ -17,6 +18,7 @@ var (
 	WarnInvalidLimit      = "gormx: invalid limit"
 	WarnInvalidUpdateData = "gormx: invalid update data"
 	WarnNoRowsAffected    = "gormx: no rows affected"
+	WarnEmptyFields       = "gormx: empty fields"
 )
 
 var (
@@ -35,6 +37,27 @@ var (
 	ErrQueryFailed  = errors.New("gormx: query failed")
 	ErrUpdateFailed = errors.New("gormx: update failed")
 	ErrDeleteFailed = errors.New("gormx: delete failed")
+	// 模型校验错误
+	ErrValidation = errors.New("gormx: validation failed")
+	// 字段更新错误
+	ErrUnknownField      = errors.New("gormx: unknown field")
+	ErrInvalidPatch      = errors.New("gormx: invalid patch")
+	ErrFieldNotPatchable = errors.New("gormx: field not patchable")
+	// 受影响行数检查错误
+	ErrNoRowsAffected       = errors.New("gormx: no rows affected")
+	ErrRowsAffectedMismatch = errors.New("gormx: rows affected mismatch")
+	// ID 生成错误
+	ErrInvalidWorkerID     = errors.New("gormx: invalid worker id")
+	ErrClockMovedBackwards = errors.New("gormx: clock moved backwards")
+	ErrWorkerLeaseLost     = errors.New("gormx: worker lease lost")
+	// 分表错误
+	ErrShardKeyRequired      = errors.New("gormx: shard key required")
+	ErrShardRouting          = errors.New("gormx: shard routing failed")
+	ErrShardKeyImmutable     = errors.New("gormx: shard key cannot be updated")
+	ErrCrossShardTransaction = errors.New("gormx: cross database shard in transaction")
+	// 多数据库错误
+	ErrUnknownDatabase = errors.New("gormx: unknown database")
+	ErrRegistryClosed  = errors.New("gormx: registry closed")
 )
 
 // 带上下文的错误类型
@@ -65,6 +88,36 @@ func (e *Error) Unwrap() error {
 	return e.Err
 }
 
+// 字段级校验错误 Field-level validation error
+type FieldError struct {
+	// 字段名 Field (e.g. "Email", 批量操作时为 "[3].Email")
+	Field string
+	// 校验标签 Tag (e.g. "required", 自定义 Validator 为空)
+	Tag string
+	// 标签参数 Param (e.g. "max=32" 中的 "32")
+	Param string
+	// 错误信息 Message
+	Message string
+}
+
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// FieldErrors 作为 ErrValidation 的 Cause 返回, 可通过 GetFieldErrors 取出
+type FieldErrors []FieldError
+
+func (e FieldErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fe := range e {
+		msgs = append(msgs, fe.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
 // 错误构建函数
 func New(err error, op, table string, cause error) error {
 	return &Error{
@@ -127,3 +180,76 @@ func IsUpdateFailed(err error) bool {
 func IsDeleteFailed(err error) bool {
 	return errors.Is(err, ErrDeleteFailed)
 }
+
+func IsValidation(err error) bool {
+	return errors.Is(err, ErrValidation)
+}
+
+func IsUnknownField(err error) bool {
+	return errors.Is(err, ErrUnknownField)
+}
+
+func IsInvalidPatch(err error) bool {
+	return errors.Is(err, ErrInvalidPatch)
+}
+
+func IsFieldNotPatchable(err error) bool {
+	return errors.Is(err, ErrFieldNotPatchable)
+}
+
+func IsNoRowsAffected(err error) bool {
+	return errors.Is(err, ErrNoRowsAffected)
+}
+
+func IsRowsAffectedMismatch(err error) bool {
+	return errors.Is(err, ErrRowsAffectedMismatch)
+}
+
+func IsInvalidWorkerID(err error) bool {
+	return errors.Is(err, ErrInvalidWorkerID)
+}
+
+func IsClockMovedBackwards(err error) bool {
+	return errors.Is(err, ErrClockMovedBackwards)
+}
+
+func IsWorkerLeaseLost(err error) bool {
+	return errors.Is(err, ErrWorkerLeaseLost)
+}
+
+func IsShardKeyRequired(err error) bool {
+	return errors.Is(err, ErrShardKeyRequired)
+}
+
+func IsShardRouting(err error) bool {
+	return errors.Is(err, ErrShardRouting)
+}
+
+func IsShardKeyImmutable(err error) bool {
+	return errors.Is(err, ErrShardKeyImmutable)
+}
+
+func IsCrossShardTransaction(err error) bool {
+	return errors.Is(err, ErrCrossShardTransaction)
+}
+
+func IsUnknownDatabase(err error) bool {
+	return errors.Is(err, ErrUnknownDatabase)
+}
+
+func IsRegistryClosed(err error) bool {
+	return errors.Is(err, ErrRegistryClosed)
+}
+
+// GetFieldErrors 从 ErrValidation 错误中取出字段级错误
+func GetFieldErrors(err error) (FieldErrors, bool) {
+	var e *Error
+	if !errors.As(err, &e) || !errors.Is(e.Err, ErrValidation) {
+		return nil, false
+	}
+	var fieldErrs FieldErrors
+	if !errors.As(e.Cause, &fieldErrs) {
+		return nil, false
+	}
+	return fieldErrs, true
+}
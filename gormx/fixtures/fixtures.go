@@ -0,0 +1,495 @@
+/*
+Package fixtures 将 YAML/JSON 夹具文件加载到已注册的 GormX 模型中, 用于集成测试和演示环境.
+
+文件格式: 顶层为表名, 第二层为行标签, 第三层为列名(或字段名)到值的映射.
+字符串值支持 text/template 模板:
+  - {{ now }}               本次加载的当前时间 (RFC3339Nano)
+  - {{ nowAdd "-24h" }}     当前时间加上一个 time.Duration
+  - {{ seq "users" }}       按名称递增的序列, 从 1 开始
+  - {{ ref "users.alice.id" }} 引用已插入行的列值
+
+加载顺序: 按 Register 声明的依赖以及 ref 引用推导出的依赖进行拓扑排序, 被依赖的表先插入.
+启用 WithTruncate 时, 在插入前按相反顺序清空全部已注册的表.
+清空使用 DELETE 而不是 TRUNCATE, 因为 MySQL 的 TRUNCATE 会隐式提交事务.
+整个加载过程在一个 GormXTx 事务中执行, 任何错误都会回滚.
+
+Package fixtures loads YAML or JSON fixture files into registered GormX models.
+
+Example (testdata/users.yml):
+
+	users:
+	  alice:
+	    name: Alice
+	    email: "alice{{ seq \"email\" }}@example.com"
+	    created_at: "{{ now }}"
+	orders:
+	  first:
+	    user_id: '{{ ref "users.alice.id" }}'
+	    amount: 100
+
+Example (Go):
+
+	loader := fixtures.New(gormx.NewGormXTx(db), fixtures.WithTruncate())
+	if err := fixtures.Register(loader, gormx.NewGormX[User, uint64, *User](db)); err != nil {
+		return err
+	}
+	if err := fixtures.Register(loader, gormx.NewGormX[Order, uint64, *Order](db), "users"); err != nil {
+		return err
+	}
+	err := loader.Load(ctx, "testdata/users.yml")
+*/
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/gormx"
+	"github.com/LouYuanbo1/go-webservice/gormx/model"
+	"github.com/goccy/go-yaml"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// 从模板中提取 ref 引用的表名, 用于推导插入顺序
+var refPattern = regexp.MustCompile(`ref\s+"([^".]+)\.`)
+
+type Loader struct {
+	tx        gormx.GormXTx
+	truncate  bool
+	batchSize int
+	clock     func() time.Time
+
+	schemaCache   sync.Map
+	registrations map[string]*registration
+}
+
+type Option func(*Loader)
+
+// WithTruncate 加载前清空全部已注册的表
+func WithTruncate() Option {
+	return func(l *Loader) {
+		l.truncate = true
+	}
+}
+
+// WithBatchSize 设置 CreateInBatches 的批大小 (默认 100)
+func WithBatchSize(batchSize int) Option {
+	return func(l *Loader) {
+		l.batchSize = batchSize
+	}
+}
+
+// WithClock 设置 now 模板函数使用的时钟 (默认 time.Now)
+func WithClock(clock func() time.Time) Option {
+	return func(l *Loader) {
+		l.clock = clock
+	}
+}
+
+func New(tx gormx.GormXTx, opts ...Option) *Loader {
+	l := &Loader{
+		tx:            tx,
+		batchSize:     100,
+		clock:         time.Now,
+		registrations: make(map[string]*registration),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// row 是夹具文件中的一行, columns 保持文件中的顺序
+type row struct {
+	label   string
+	columns yaml.MapSlice
+}
+
+type registration struct {
+	table  string
+	deps   []string
+	schema *schema.Schema
+	// insert 将已渲染的行转换为模型并批量插入, 返回插入后的模型指针
+	insert   func(ctx context.Context, rows []map[string]any) ([]any, error)
+	truncate func(ctx context.Context) error
+}
+
+/*
+Register 注册一个可以加载夹具的模型, 表名取自模型的 TableName().
+dependsOn 声明该表依赖(外键引用)的其他表, 被依赖的表会先插入.
+
+Register registers a model that fixtures can be loaded into.
+dependsOn lists the tables this table references through foreign keys.
+*/
+func Register[T any, ID comparable, PT model.PointerModel[T, ID]](l *Loader, gx gormx.GormX[T, ID, PT], dependsOn ...string) error {
+	var m T
+	ptr := PT(&m)
+	s, err := schema.Parse(ptr, &l.schemaCache, schema.NamingStrategy{})
+	if err != nil {
+		return fmt.Errorf("fixtures: parse model %T failed: %w", ptr, err)
+	}
+
+	table := ptr.TableName()
+	if _, ok := l.registrations[table]; ok {
+		return fmt.Errorf("fixtures: table %s already registered", table)
+	}
+
+	l.registrations[table] = &registration{
+		table:  table,
+		deps:   dependsOn,
+		schema: s,
+		insert: func(ctx context.Context, rows []map[string]any) ([]any, error) {
+			models := make([]PT, len(rows))
+			for i, columns := range rows {
+				var item T
+				models[i] = PT(&item)
+				rv := reflect.ValueOf(models[i])
+				for column, value := range columns {
+					field := s.LookUpField(column)
+					if field == nil || field.DBName == "" {
+						return nil, fmt.Errorf("unknown column %q", column)
+					}
+					if err := field.Set(ctx, rv, value); err != nil {
+						return nil, fmt.Errorf("set column %q: %w", column, err)
+					}
+				}
+			}
+			if _, err := gx.CreateInBatches(ctx, models, l.batchSize); err != nil {
+				return nil, err
+			}
+			created := make([]any, len(models))
+			for i, m := range models {
+				created[i] = m
+			}
+			return created, nil
+		},
+		truncate: func(ctx context.Context) error {
+			if db := gx.GetDBWithContext(ctx); db != nil {
+				return db.Session(&gorm.Session{AllowGlobalUpdate: true}).
+					Unscoped().
+					Delete(PT(new(T))).Error
+			}
+			// 没有 *gorm.DB 时(如 gormxtest 内存实现), 逐页删除
+			for {
+				page, err := gx.FindByPage(ctx, 1, 500)
+				if err != nil {
+					return err
+				}
+				if len(page) == 0 {
+					return nil
+				}
+				ids := make([]ID, len(page))
+				for i, m := range page {
+					ids[i] = m.GetID()
+				}
+				if _, err := gx.DeleteByIDs(ctx, ids); err != nil {
+					return err
+				}
+			}
+		},
+	}
+	return nil
+}
+
+// Load 从文件系统加载夹具文件 (.yml, .yaml, .json)
+func (l *Loader) Load(ctx context.Context, paths ...string) error {
+	files := make(map[string][]byte, len(paths))
+	for _, p := range paths {
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("fixtures: read %s: %w", p, err)
+		}
+		files[p] = content
+	}
+	return l.load(ctx, paths, files)
+}
+
+// LoadFS 从 fs.FS 加载匹配 patterns 的夹具文件, 文件按名称排序后加载
+func (l *Loader) LoadFS(ctx context.Context, fsys fs.FS, patterns ...string) error {
+	var paths []string
+	for _, pattern := range patterns {
+		matches, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return fmt.Errorf("fixtures: glob %s: %w", pattern, err)
+		}
+		paths = append(paths, matches...)
+	}
+	slices.Sort(paths)
+	paths = slices.Compact(paths)
+
+	files := make(map[string][]byte, len(paths))
+	for _, p := range paths {
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return fmt.Errorf("fixtures: read %s: %w", p, err)
+		}
+		files[p] = content
+	}
+	return l.load(ctx, paths, files)
+}
+
+func (l *Loader) load(ctx context.Context, paths []string, files map[string][]byte) error {
+	// 解析全部文件, 同一张表在多个文件中出现时按文件顺序合并
+	tables := make(map[string][]row)
+	for _, p := range paths {
+		if err := parseFile(p, files[p], tables); err != nil {
+			return err
+		}
+	}
+	for table := range tables {
+		if _, ok := l.registrations[table]; !ok {
+			return fmt.Errorf("fixtures: table %s is not registered", table)
+		}
+	}
+
+	order, err := l.sortTables(tables)
+	if err != nil {
+		return err
+	}
+
+	return l.tx.Exec(ctx, func(ctx context.Context) error {
+		if l.truncate {
+			for i := len(order) - 1; i >= 0; i-- {
+				if err := l.registrations[order[i]].truncate(ctx); err != nil {
+					return fmt.Errorf("fixtures: truncate %s: %w", order[i], err)
+				}
+			}
+		}
+
+		state := newRenderState(l.clock())
+		for _, table := range order {
+			rows, ok := tables[table]
+			if !ok || len(rows) == 0 {
+				continue
+			}
+			if err := l.insertTable(ctx, l.registrations[table], rows, state); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (l *Loader) insertTable(ctx context.Context, reg *registration, rows []row, state *renderState) error {
+	rendered := make([]map[string]any, len(rows))
+	for i, r := range rows {
+		columns := make(map[string]any, len(r.columns))
+		for _, item := range r.columns {
+			column := fmt.Sprint(item.Key)
+			value, err := state.render(item.Value)
+			if err != nil {
+				return fmt.Errorf("fixtures: %s.%s.%s: %w", reg.table, r.label, column, err)
+			}
+			columns[column] = value
+		}
+		rendered[i] = columns
+	}
+
+	created, err := reg.insert(ctx, rendered)
+	if err != nil {
+		return fmt.Errorf("fixtures: insert %s: %w", reg.table, err)
+	}
+
+	// 记录插入后的列值(含自增主键, 默认值等), 供后续 ref 引用
+	labels := make(map[string]map[string]any, len(created))
+	for i, m := range created {
+		rv := reflect.ValueOf(m)
+		values := make(map[string]any, len(reg.schema.DBNames))
+		for _, field := range reg.schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			value := field.ReflectValueOf(ctx, rv).Interface()
+			values[field.DBName] = value
+			values[field.Name] = value
+		}
+		labels[rows[i].label] = values
+	}
+	state.refs[reg.table] = labels
+	return nil
+}
+
+// sortTables 按依赖拓扑排序全部已注册的表, 被依赖的表在前
+func (l *Loader) sortTables(tables map[string][]row) ([]string, error) {
+	deps := make(map[string][]string, len(l.registrations))
+	for table, reg := range l.registrations {
+		deps[table] = append(deps[table], reg.deps...)
+	}
+	for table, rows := range tables {
+		for _, r := range rows {
+			for _, dep := range referencedTables(r.columns) {
+				if dep != table {
+					deps[table] = append(deps[table], dep)
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(l.registrations))
+	for table := range l.registrations {
+		names = append(names, table)
+	}
+	slices.Sort(names)
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := make(map[string]int, len(names))
+	order := make([]string, 0, len(names))
+	var visit func(table string, stack []string) error
+	visit = func(table string, stack []string) error {
+		switch marks[table] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("fixtures: dependency cycle: %s", strings.Join(append(stack, table), " -> "))
+		}
+		if _, ok := l.registrations[table]; !ok {
+			return fmt.Errorf("fixtures: table %s is referenced but not registered", table)
+		}
+		marks[table] = visiting
+		for _, dep := range deps[table] {
+			if err := visit(dep, append(stack, table)); err != nil {
+				return err
+			}
+		}
+		marks[table] = visited
+		order = append(order, table)
+		return nil
+	}
+	for _, table := range names {
+		if err := visit(table, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+func referencedTables(value any) []string {
+	var tables []string
+	switch v := value.(type) {
+	case string:
+		for _, match := range refPattern.FindAllStringSubmatch(v, -1) {
+			tables = append(tables, match[1])
+		}
+	case yaml.MapSlice:
+		for _, item := range v {
+			tables = append(tables, referencedTables(item.Value)...)
+		}
+	case []any:
+		for _, item := range v {
+			tables = append(tables, referencedTables(item)...)
+		}
+	}
+	return tables
+}
+
+func parseFile(name string, content []byte, tables map[string][]row) error {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".yml", ".yaml", ".json":
+	default:
+		return fmt.Errorf("fixtures: unsupported fixture file %s", name)
+	}
+
+	// JSON 是 YAML 的子集, 统一使用 YAML 解析并保持键顺序
+	var doc yaml.MapSlice
+	if err := yaml.UnmarshalWithOptions(content, &doc, yaml.UseOrderedMap()); err != nil {
+		return fmt.Errorf("fixtures: parse %s: %w", name, err)
+	}
+	for _, tableItem := range doc {
+		table := fmt.Sprint(tableItem.Key)
+		labels, ok := tableItem.Value.(yaml.MapSlice)
+		if !ok {
+			return fmt.Errorf("fixtures: %s: table %s must be a map of labeled rows", name, table)
+		}
+		for _, labelItem := range labels {
+			label := fmt.Sprint(labelItem.Key)
+			columns, ok := labelItem.Value.(yaml.MapSlice)
+			if !ok {
+				return fmt.Errorf("fixtures: %s: row %s.%s must be a map of columns", name, table, label)
+			}
+			tables[table] = append(tables[table], row{label: label, columns: columns})
+		}
+	}
+	return nil
+}
+
+// renderState 保存一次加载过程中的模板状态
+type renderState struct {
+	now  time.Time
+	seqs map[string]int64
+	// refs 表名 -> 行标签 -> 列名/字段名 -> 值
+	refs map[string]map[string]map[string]any
+}
+
+func newRenderState(now time.Time) *renderState {
+	return &renderState{
+		now:  now,
+		seqs: make(map[string]int64),
+		refs: make(map[string]map[string]map[string]any),
+	}
+}
+
+func (s *renderState) funcs() template.FuncMap {
+	return template.FuncMap{
+		"now": func() string {
+			return s.now.Format(time.RFC3339Nano)
+		},
+		"nowAdd": func(d string) (string, error) {
+			duration, err := time.ParseDuration(d)
+			if err != nil {
+				return "", err
+			}
+			return s.now.Add(duration).Format(time.RFC3339Nano), nil
+		},
+		"seq": func(name string) int64 {
+			s.seqs[name]++
+			return s.seqs[name]
+		},
+		"ref": func(ref string) (string, error) {
+			parts := strings.SplitN(ref, ".", 3)
+			if len(parts) != 3 {
+				return "", fmt.Errorf("invalid reference %q, expected table.label.column", ref)
+			}
+			value, ok := s.refs[parts[0]][parts[1]][parts[2]]
+			if !ok {
+				return "", fmt.Errorf("unresolved reference %q", ref)
+			}
+			if t, ok := value.(time.Time); ok {
+				return t.Format(time.RFC3339Nano), nil
+			}
+			return fmt.Sprint(value), nil
+		},
+	}
+}
+
+// render 渲染字符串中的模板, 非字符串值原样返回
+func (s *renderState) render(value any) (any, error) {
+	str, ok := value.(string)
+	if !ok || !strings.Contains(str, "{{") {
+		return value, nil
+	}
+	tmpl, err := template.New("fixture").Funcs(s.funcs()).Option("missingkey=error").Parse(str)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		return nil, err
+	}
+	return buf.String(), nil
+}
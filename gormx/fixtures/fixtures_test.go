@@ -0,0 +1,193 @@
+package fixtures_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/gormx"
+	"github.com/LouYuanbo1/go-webservice/gormx/fixtures"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type fixtureUser struct {
+	ID        uint64 `gorm:"primaryKey"`
+	Name      string
+	Email     string
+	CreatedAt time.Time
+}
+
+func (m *fixtureUser) TableName() string  { return "fixture_users" }
+func (m *fixtureUser) PrimaryKey() string { return "id" }
+func (m *fixtureUser) GetID() uint64      { return m.ID }
+
+type fixtureOrder struct {
+	ID     uint64 `gorm:"primaryKey"`
+	UserID uint64
+	Amount int
+}
+
+func (m *fixtureOrder) TableName() string  { return "fixture_orders" }
+func (m *fixtureOrder) PrimaryKey() string { return "id" }
+func (m *fixtureOrder) GetID() uint64      { return m.ID }
+
+type fixtureNote struct {
+	ID   uint64 `gorm:"primaryKey"`
+	Text string
+}
+
+func (m *fixtureNote) TableName() string  { return "fixture_notes" }
+func (m *fixtureNote) PrimaryKey() string { return "id" }
+func (m *fixtureNote) GetID() uint64      { return m.ID }
+
+// 表按字母序排列时 orders 在 users 之前, 只有依赖排序才能让 ref 先解析到用户
+const usersYAML = `
+fixture_orders:
+  first:
+    user_id: '{{ ref "fixture_users.alice.id" }}'
+    amount: 100
+  second:
+    user_id: '{{ ref "fixture_users.bob.id" }}'
+    amount: 200
+fixture_users:
+  alice:
+    name: Alice
+    email: 'user{{ seq "email" }}@example.com'
+    created_at: '{{ now }}'
+  bob:
+    name: Bob
+    email: 'user{{ seq "email" }}@example.com'
+    created_at: '{{ nowAdd "-24h" }}'
+`
+
+const notesJSON = `{"fixture_notes": {"n1": {"text": "order {{ ref \"fixture_orders.second.amount\" }}"}}}`
+
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&fixtureUser{}, &fixtureOrder{}, &fixtureNote{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func newLoader(t *testing.T, db *gorm.DB, now time.Time, opts ...fixtures.Option) *fixtures.Loader {
+	t.Helper()
+	loader := fixtures.New(gormx.NewGormXTx(db), append([]fixtures.Option{fixtures.WithClock(func() time.Time { return now })}, opts...)...)
+	if err := fixtures.Register(loader, gormx.NewGormX[fixtureUser, uint64, *fixtureUser](db)); err != nil {
+		t.Fatal(err)
+	}
+	if err := fixtures.Register(loader, gormx.NewGormX[fixtureOrder, uint64, *fixtureOrder](db), "fixture_users"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fixtures.Register(loader, gormx.NewGormX[fixtureNote, uint64, *fixtureNote](db)); err != nil {
+		t.Fatal(err)
+	}
+	return loader
+}
+
+func TestLoadResolvesRefsAndTemplates(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"testdata/notes.json": {Data: []byte(notesJSON)},
+		"testdata/users.yml":  {Data: []byte(usersYAML)},
+	}
+	if err := newLoader(t, db, now).LoadFS(ctx, fsys, "testdata/*"); err != nil {
+		t.Fatal(err)
+	}
+
+	var users []fixtureUser
+	db.Order("name").Find(&users)
+	if len(users) != 2 {
+		t.Fatalf("users = %+v", users)
+	}
+	alice, bob := users[0], users[1]
+	if alice.Email != "user1@example.com" || bob.Email != "user2@example.com" {
+		t.Errorf("seq emails = %q, %q", alice.Email, bob.Email)
+	}
+	if !alice.CreatedAt.Equal(now) || !bob.CreatedAt.Equal(now.Add(-24*time.Hour)) {
+		t.Errorf("created_at = %v, %v", alice.CreatedAt, bob.CreatedAt)
+	}
+
+	var orders []fixtureOrder
+	db.Order("amount").Find(&orders)
+	if len(orders) != 2 || orders[0].UserID != alice.ID || orders[1].UserID != bob.ID {
+		t.Errorf("orders = %+v, want user ids %d, %d", orders, alice.ID, bob.ID)
+	}
+	var note fixtureNote
+	if err := db.First(&note).Error; err != nil || note.Text != "order 200" {
+		t.Errorf("note = %+v, %v", note, err)
+	}
+}
+
+func TestLoadTruncate(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	fsys := fstest.MapFS{"users.yml": {Data: []byte(usersYAML)}}
+
+	if err := newLoader(t, db, time.Now()).LoadFS(ctx, fsys, "*.yml"); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&fixtureNote{Text: "stale"})
+	if err := newLoader(t, db, time.Now(), fixtures.WithTruncate()).LoadFS(ctx, fsys, "*.yml"); err != nil {
+		t.Fatal(err)
+	}
+
+	var users, orders, notes int64
+	db.Model(&fixtureUser{}).Count(&users)
+	db.Model(&fixtureOrder{}).Count(&orders)
+	db.Model(&fixtureNote{}).Count(&notes)
+	if users != 2 || orders != 2 || notes != 0 {
+		t.Errorf("rows after truncate = %d users, %d orders, %d notes, want 2, 2, 0", users, orders, notes)
+	}
+}
+
+func TestLoadRollsBackOnError(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	fsys := fstest.MapFS{"bad.yml": {Data: []byte(`
+fixture_users:
+  alice:
+    name: Alice
+fixture_orders:
+  first:
+    user_id: '{{ ref "fixture_users.carol.id" }}'
+`)}}
+	err := newLoader(t, db, time.Now()).LoadFS(ctx, fsys, "*.yml")
+	if err == nil || !strings.Contains(err.Error(), "unresolved reference") {
+		t.Fatalf("LoadFS() = %v, want unresolved reference", err)
+	}
+	var users int64
+	db.Model(&fixtureUser{}).Count(&users)
+	if users != 0 {
+		t.Errorf("users = %d after failed load, want 0", users)
+	}
+}
+
+func TestRegisterAndLoadErrors(t *testing.T) {
+	db := openDB(t)
+	loader := newLoader(t, db, time.Now())
+	if err := fixtures.Register(loader, gormx.NewGormX[fixtureUser, uint64, *fixtureUser](db)); err == nil {
+		t.Error("Register() of a registered table succeeded")
+	}
+	fsys := fstest.MapFS{"unknown.yml": {Data: []byte("missing_table:\n  a:\n    x: 1\n")}}
+	if err := loader.LoadFS(context.Background(), fsys, "*.yml"); err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Errorf("LoadFS() = %v, want not registered", err)
+	}
+}
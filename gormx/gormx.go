@@ -6,14 +6,33 @@ import (
 	"github.com/LouYuanbo1/go-webservice/gormx/internal"
 	"github.com/LouYuanbo1/go-webservice/gormx/model"
 	"github.com/LouYuanbo1/go-webservice/gormx/options"
+	"github.com/LouYuanbo1/go-webservice/gormx/sharding"
 	"gorm.io/gorm"
 )
 
+/*
+Result 是写操作的结果, 包含受影响的行数与最后插入的自增主键.
+需要将 0 行视为错误时, 传入 options.RequireRowsAffectedOption() 或 options.ExpectRowsAffectedOption(n).
+
+Result reports rows affected and the last insert ID of a mutation.
+*/
+type Result = internal.Result
+
+/*
+GormX 是单表的泛型数据访问接口.
+写操作传入 options.MutationOption 时在事务中执行(已在 GormXTx 事务中时使用保存点),
+受影响行数检查失败时回滚该写操作, 原样返回 ErrNoRowsAffected 或 ErrRowsAffectedMismatch.
+未传入选项时不检查行数, 0 行只记录日志.
+
+GormX is a generic data access layer for a single table.
+Mutations given row count options run in a transaction (a savepoint inside GormXTx)
+and are rolled back when the check fails.
+*/
 type GormX[T any, ID comparable, PT model.PointerModel[T, ID]] interface {
 	GetDBWithContext(ctx context.Context) *gorm.DB
 	InTransaction(ctx context.Context) bool
-	Create(ctx context.Context, model PT, opts ...options.ConflictOption) error
-	CreateInBatches(ctx context.Context, models []PT, batchSize int, opts ...options.ConflictOption) error
+	Create(ctx context.Context, model PT, opts ...options.ConflictOption) (Result, error)
+	CreateInBatches(ctx context.Context, models []PT, batchSize int, opts ...options.ConflictOption) (Result, error)
 	GetByID(ctx context.Context, id ID) (PT, error)
 	FindByIDs(ctx context.Context, ids []ID, opts ...options.OrderOption) ([]PT, error)
 	GetByStructFilter(ctx context.Context, filter PT) (PT, error)
@@ -22,15 +41,38 @@ type GormX[T any, ID comparable, PT model.PointerModel[T, ID]] interface {
 	FindByMapFilter(ctx context.Context, filter map[string]any, opts ...options.OrderOption) ([]PT, error)
 	FindByPage(ctx context.Context, page, pageSize int, opts ...options.OrderOption) ([]PT, error)
 	FindByCursor(ctx context.Context, cursor ID, limit int) ([]PT, ID, bool, error)
-	Update(ctx context.Context, updateData PT) error
-	UpdateByStructFilter(ctx context.Context, filter PT, updateData PT) error
-	UpdateByMapFilter(ctx context.Context, filter map[string]any, updateData map[string]any) error
-	DeleteByID(ctx context.Context, id ID) error
-	DeleteByIDs(ctx context.Context, ids []ID) error
-	DeleteByStructFilter(ctx context.Context, filter PT) error
-	DeleteByMapFilter(ctx context.Context, filter map[string]any) error
+	Update(ctx context.Context, updateData PT, opts ...options.MutationOption) (Result, error)
+	UpdateFields(ctx context.Context, updateData PT, fields []string, opts ...options.MutationOption) (Result, error)
+	Save(ctx context.Context, model PT, opts ...options.MutationOption) (Result, error)
+	PatchByID(ctx context.Context, id ID, patch []byte, patchable ...string) (PT, error)
+	UpdateInBatches(ctx context.Context, models []PT, fields []string, batchSize int, opts ...options.MutationOption) (Result, error)
+	UpdateByStructFilter(ctx context.Context, filter PT, updateData PT, opts ...options.MutationOption) (Result, error)
+	UpdateByMapFilter(ctx context.Context, filter map[string]any, updateData map[string]any, opts ...options.MutationOption) (Result, error)
+	DeleteByID(ctx context.Context, id ID, opts ...options.MutationOption) (Result, error)
+	DeleteByIDs(ctx context.Context, ids []ID, opts ...options.MutationOption) (Result, error)
+	DeleteByStructFilter(ctx context.Context, filter PT, opts ...options.MutationOption) (Result, error)
+	DeleteByMapFilter(ctx context.Context, filter map[string]any, opts ...options.MutationOption) (Result, error)
 }
 
 func NewGormX[T any, ID comparable, PT model.PointerModel[T, ID]](db *gorm.DB) GormX[T, ID, PT] {
 	return internal.NewGormX[T, ID, PT](db)
 }
+
+/*
+NewShardedGormX 为实现了 model.Sharded 的模型创建分表 GormX.
+带有分片键的操作只访问对应的物理表(或数据库), 缺少分片键的查询与写入扇出到全部分片并合并结果,
+FindByPage 与多分片结果在内存中按排序选项(默认按主键)合并. 使用 sharding.WithRejectFanOut 可拒绝扇出.
+Create, CreateInBatches, Save 与 UpdateInBatches 按模型的分片键路由(零值是否有效由策略决定), 分片键不能通过更新修改.
+Target.Database 非空的分片需要通过 sharding.WithDatabase 注册连接, 事务(GormXTx)只能访问 db 上的分片,
+写操作涉及这些分片时按分片顺序执行, 行数检查失败时无法回滚.
+
+NewShardedGormX creates a GormX that routes operations to physical tables by the model's shard key,
+and fans out and merges queries that lack it.
+
+Example:
+
+	gx, err := gormx.NewShardedGormX[Order, uint64](db, sharding.WithRejectFanOut())
+*/
+func NewShardedGormX[T any, ID comparable, PT model.PointerModel[T, ID]](db *gorm.DB, opts ...sharding.Option) (GormX[T, ID, PT], error) {
+	return internal.NewShardedGormX[T, ID, PT](db, opts...)
+}
@@ -0,0 +1,535 @@
+package gormxtest
+
+import (
+	"context"
+	stderrors "errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/gormx"
+	"github.com/LouYuanbo1/go-webservice/gormx/errors"
+	"github.com/LouYuanbo1/go-webservice/gormx/model"
+	"github.com/LouYuanbo1/go-webservice/gormx/options"
+)
+
+// ContractItem 是契约测试使用的模型, 真实数据库需要先 AutoMigrate 该模型
+type ContractItem struct {
+	ID        uint64 `gorm:"primaryKey"`
+	Name      string `gorm:"size:64;not null;uniqueIndex" validate:"required"`
+	Category  string `gorm:"size:32;not null;index"`
+	Score     int    `gorm:"not null"`
+	Attrs     model.JSON[ContractAttrs]
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ContractAttrs 存储在 ContractItem 的 JSON 列中, 用于检查 model.JSONPath 过滤
+type ContractAttrs struct {
+	Color string `json:"color"`
+	Size  struct {
+		Width int `json:"width"`
+	} `json:"size"`
+	Tags []string `json:"tags"`
+	Note *string  `json:"note"`
+}
+
+func (m *ContractItem) TableName() string {
+	return "gormx_contract_items"
+}
+
+func (m *ContractItem) PrimaryKey() string {
+	return "id"
+}
+
+func (m *ContractItem) GetID() uint64 {
+	return m.ID
+}
+
+// Factory 为每个子测试返回一组基于空表的 GormX 与 GormXTx
+type Factory func(t *testing.T) (gormx.GormX[ContractItem, uint64, *ContractItem], gormx.GormXTx)
+
+/*
+RunContract 运行 GormX 契约测试, 用于确认内存实现与真实实现行为一致.
+
+RunContract runs the GormX contract suite against the implementation returned by factory.
+Run it once with the fake and once with a real database to check that both behave the same.
+
+Example:
+
+	func TestContractFake(t *testing.T) {
+		gormxtest.RunContract(t, func(t *testing.T) (gormx.GormX[gormxtest.ContractItem, uint64, *gormxtest.ContractItem], gormx.GormXTx) {
+			db := gormxtest.NewDB()
+			return gormxtest.NewGormX[gormxtest.ContractItem, uint64, *gormxtest.ContractItem](db), gormxtest.NewGormXTx(db)
+		})
+	}
+
+	func TestContractSQLite(t *testing.T) {
+		gormxtest.RunContract(t, func(t *testing.T) (gormx.GormX[gormxtest.ContractItem, uint64, *gormxtest.ContractItem], gormx.GormXTx) {
+			db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+			_ = db.AutoMigrate(&gormxtest.ContractItem{})
+			return gormx.NewGormX[gormxtest.ContractItem, uint64, *gormxtest.ContractItem](db), gormx.NewGormXTx(db)
+		})
+	}
+*/
+func RunContract(t *testing.T, factory Factory) {
+	t.Helper()
+	for _, c := range contractCases {
+		t.Run(c.name, func(t *testing.T) {
+			gx, tx := factory(t)
+			c.run(t, gx, tx)
+		})
+	}
+}
+
+type contractCase struct {
+	name string
+	run  func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx)
+}
+
+// seedContractItems 插入 a(10), b(20), c(30), d(40), 类别交替为 x, y
+func seedContractItems(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem]) []*ContractItem {
+	t.Helper()
+	items := []*ContractItem{
+		{Name: "a", Category: "x", Score: 10},
+		{Name: "b", Category: "y", Score: 20},
+		{Name: "c", Category: "x", Score: 30},
+		{Name: "d", Category: "y", Score: 40},
+	}
+	if _, err := gx.CreateInBatches(context.Background(), items, 2); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	for _, item := range items {
+		if item.ID == 0 {
+			t.Fatalf("seed: id of %q not assigned", item.Name)
+		}
+	}
+	return items
+}
+
+func names(items []*ContractItem) []string {
+	result := make([]string, len(items))
+	for i, item := range items {
+		result[i] = item.Name
+	}
+	return result
+}
+
+func expectNames(t *testing.T, got []*ContractItem, want ...string) {
+	t.Helper()
+	gotNames := names(got)
+	if len(gotNames) != len(want) {
+		t.Fatalf("got %v, want %v", gotNames, want)
+	}
+	for i := range want {
+		if gotNames[i] != want[i] {
+			t.Fatalf("got %v, want %v", gotNames, want)
+		}
+	}
+}
+
+var contractCases = []contractCase{
+	{"CreateAndGetByID", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		ctx := context.Background()
+		item := &ContractItem{Name: "a", Category: "x", Score: 1}
+		if _, err := gx.Create(ctx, item); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		if item.ID == 0 || item.CreatedAt.IsZero() {
+			t.Fatalf("create did not fill id/created_at: %+v", item)
+		}
+		got, err := gx.GetByID(ctx, item.ID)
+		if err != nil {
+			t.Fatalf("get by id: %v", err)
+		}
+		if got.Name != "a" || got.Score != 1 {
+			t.Fatalf("unexpected item: %+v", got)
+		}
+	}},
+	{"GetByIDNotFound", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		_, err := gx.GetByID(context.Background(), 999)
+		if !errors.IsQueryFailed(err) {
+			t.Fatalf("expected query failed error, got %v", err)
+		}
+	}},
+	{"CreateValidation", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		_, err := gx.Create(context.Background(), &ContractItem{Category: "x"})
+		fieldErrs, ok := errors.GetFieldErrors(err)
+		if !errors.IsValidation(err) || !ok || len(fieldErrs) != 1 || fieldErrs[0].Field != "Name" {
+			t.Fatalf("expected validation error on Name, got %v", err)
+		}
+	}},
+	{"CreateDuplicate", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		seedContractItems(t, gx)
+		_, err := gx.Create(context.Background(), &ContractItem{Name: "a", Category: "z"})
+		if !errors.IsCreateFailed(err) {
+			t.Fatalf("expected create failed error, got %v", err)
+		}
+	}},
+	{"UpsertDoNothing", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		ctx := context.Background()
+		seedContractItems(t, gx)
+		_, err := gx.Create(ctx, &ContractItem{Name: "a", Category: "z", Score: 99},
+			options.OnConstraintColumns("name"), options.DoNothingOption())
+		if err != nil {
+			t.Fatalf("upsert: %v", err)
+		}
+		got, err := gx.GetByMapFilter(ctx, map[string]any{"name": "a"})
+		if err != nil || got.Score != 10 {
+			t.Fatalf("do nothing changed row: %+v, %v", got, err)
+		}
+	}},
+	{"UpsertUpdateColumns", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		ctx := context.Background()
+		seedContractItems(t, gx)
+		_, err := gx.Create(ctx, &ContractItem{Name: "a", Category: "z", Score: 99},
+			options.OnConstraintColumns("name"), options.UpdateColumnsOption("score"))
+		if err != nil {
+			t.Fatalf("upsert: %v", err)
+		}
+		got, err := gx.GetByMapFilter(ctx, map[string]any{"name": "a"})
+		if err != nil || got.Score != 99 || got.Category != "x" {
+			t.Fatalf("update columns not applied: %+v, %v", got, err)
+		}
+	}},
+	{"UpsertUpdateAllByPrimaryKey", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		ctx := context.Background()
+		items := seedContractItems(t, gx)
+		_, err := gx.Create(ctx, &ContractItem{ID: items[1].ID, Name: "b2", Category: "z", Score: 99}, options.UpdateAllOption())
+		if err != nil {
+			t.Fatalf("upsert: %v", err)
+		}
+		got, err := gx.GetByID(ctx, items[1].ID)
+		if err != nil || got.Name != "b2" || got.Category != "z" || got.Score != 99 {
+			t.Fatalf("update all not applied: %+v, %v", got, err)
+		}
+	}},
+	{"FindByIDs", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		items := seedContractItems(t, gx)
+		got, err := gx.FindByIDs(context.Background(), []uint64{items[3].ID, items[0].ID, 999}, options.WithAscOption("score"))
+		if err != nil {
+			t.Fatalf("find by ids: %v", err)
+		}
+		expectNames(t, got, "a", "d")
+	}},
+	{"FindByStructFilter", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		seedContractItems(t, gx)
+		got, err := gx.FindByStructFilter(context.Background(), &ContractItem{Category: "x"}, options.WithDescOption("score"))
+		if err != nil {
+			t.Fatalf("find by struct filter: %v", err)
+		}
+		expectNames(t, got, "c", "a")
+	}},
+	{"FindByMapFilter", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		seedContractItems(t, gx)
+		got, err := gx.FindByMapFilter(context.Background(),
+			map[string]any{"category": "y", "name": []string{"b", "c", "d"}}, options.WithDescOption("name"))
+		if err != nil {
+			t.Fatalf("find by map filter: %v", err)
+		}
+		expectNames(t, got, "d", "b")
+	}},
+	{"FindByJSONPath", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		ctx := context.Background()
+		note := "fragile"
+		items := []*ContractItem{
+			{Name: "e", Category: "x", Attrs: model.NewJSON(ContractAttrs{Color: "red", Tags: []string{"sale", "new"}, Note: &note})},
+			{Name: "f", Category: "x", Attrs: model.NewJSON(ContractAttrs{Color: "blue", Tags: []string{"new"}})},
+			{Name: "g", Category: "y", Attrs: model.NewJSON(ContractAttrs{Color: "green", Tags: []string{}, Note: &note})},
+		}
+		items[0].Attrs.Data.Size.Width = 1
+		items[1].Attrs.Data.Size.Width = 2
+		items[2].Attrs.Data.Size.Width = 2
+		if _, err := gx.CreateInBatches(ctx, items, 10); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+
+		cases := []struct {
+			name   string
+			filter map[string]any
+			want   []string
+		}{
+			{"NestedObject", map[string]any{model.JSONPath("attrs", "size", "width"): 2}, []string{"f", "g"}},
+			{"ArrayIndex", map[string]any{model.JSONPath("attrs", "tags", "0"): "new"}, []string{"f"}},
+			{"InSlice", map[string]any{model.JSONPath("attrs", "color"): []string{"red", "blue"}}, []string{"e", "f"}},
+			{"NilIsNull", map[string]any{model.JSONPath("attrs", "note"): nil}, []string{"f"}},
+			{"MissingPathIsNull", map[string]any{model.JSONPath("attrs", "tags", "1"): nil}, []string{"f", "g"}},
+			{"WithPlainColumn", map[string]any{model.JSONPath("attrs", "size", "width"): 2, "category": "y"}, []string{"g"}},
+		}
+		for _, c := range cases {
+			got, err := gx.FindByMapFilter(ctx, c.filter)
+			if err != nil {
+				t.Fatalf("%s: find by map filter: %v", c.name, err)
+			}
+			if gotNames := names(got); !slices.Equal(gotNames, c.want) {
+				t.Errorf("%s: got %v, want %v", c.name, gotNames, c.want)
+			}
+		}
+	}},
+	{"FindByPage", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		ctx := context.Background()
+		seedContractItems(t, gx)
+		got, err := gx.FindByPage(ctx, 2, 3)
+		if err != nil {
+			t.Fatalf("find by page: %v", err)
+		}
+		expectNames(t, got, "d")
+		got, err = gx.FindByPage(ctx, 1, 2, options.WithDescOption("score"))
+		if err != nil {
+			t.Fatalf("find by page (order): %v", err)
+		}
+		expectNames(t, got, "d", "c")
+	}},
+	{"FindByCursor", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		ctx := context.Background()
+		items := seedContractItems(t, gx)
+		got, cursor, hasMore, err := gx.FindByCursor(ctx, items[0].ID, 2)
+		if err != nil {
+			t.Fatalf("find by cursor: %v", err)
+		}
+		expectNames(t, got, "b", "c")
+		if !hasMore || cursor != items[2].ID {
+			t.Fatalf("unexpected cursor %d, hasMore %v", cursor, hasMore)
+		}
+		got, _, hasMore, err = gx.FindByCursor(ctx, cursor, 2)
+		if err != nil {
+			t.Fatalf("find by cursor: %v", err)
+		}
+		expectNames(t, got, "d")
+		if hasMore {
+			t.Fatalf("expected no more rows")
+		}
+	}},
+	{"Update", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		ctx := context.Background()
+		items := seedContractItems(t, gx)
+		// Name 带有 required 标签, 未更新时为零值, 不应拒绝部分更新
+		if _, err := gx.Update(ctx, &ContractItem{ID: items[0].ID, Score: 11}); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+		got, err := gx.GetByID(ctx, items[0].ID)
+		if err != nil || got.Score != 11 || got.Name != "a" || got.Category != "x" {
+			t.Fatalf("update changed zero-value fields or missed score: %+v, %v", got, err)
+		}
+	}},
+	{"UpdateFields", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		ctx := context.Background()
+		items := seedContractItems(t, gx)
+		if _, err := gx.UpdateFields(ctx, &ContractItem{ID: items[0].ID, Name: "ignored", Score: 0}, []string{"score"}); err != nil {
+			t.Fatalf("update fields: %v", err)
+		}
+		got, err := gx.GetByID(ctx, items[0].ID)
+		if err != nil || got.Score != 0 || got.Name != "a" {
+			t.Fatalf("update fields did not write zero value or touched unselected field: %+v, %v", got, err)
+		}
+		if _, err := gx.UpdateFields(ctx, &ContractItem{ID: items[0].ID}, []string{"missing"}); !errors.IsUnknownField(err) {
+			t.Fatalf("expected unknown field error, got %v", err)
+		}
+	}},
+	{"Save", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		ctx := context.Background()
+		items := seedContractItems(t, gx)
+		saved := *items[1]
+		saved.Category = ""
+		saved.Score = 0
+		if _, err := gx.Save(ctx, &saved); err != nil {
+			t.Fatalf("save existing: %v", err)
+		}
+		got, err := gx.GetByID(ctx, items[1].ID)
+		if err != nil || got.Category != "" || got.Score != 0 {
+			t.Fatalf("save did not replace the row: %+v, %v", got, err)
+		}
+		created := &ContractItem{Name: "e", Category: "x"}
+		if _, err := gx.Save(ctx, created); err != nil || created.ID == 0 {
+			t.Fatalf("save new: %+v, %v", created, err)
+		}
+	}},
+	{"PatchByID", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		ctx := context.Background()
+		items := seedContractItems(t, gx)
+		got, err := gx.PatchByID(ctx, items[0].ID, []byte(`{"Score": 0, "Category": "z"}`), "score", "category")
+		if err != nil || got.Score != 0 || got.Category != "z" || got.Name != "a" {
+			t.Fatalf("patch by id: %+v, %v", got, err)
+		}
+		if _, err := gx.PatchByID(ctx, items[0].ID, []byte(`{"Name": "x"}`), "score"); !errors.IsFieldNotPatchable(err) {
+			t.Fatalf("expected field not patchable error, got %v", err)
+		}
+		if _, err := gx.PatchByID(ctx, items[0].ID, []byte(`[1]`), "score"); !errors.IsInvalidPatch(err) {
+			t.Fatalf("expected invalid patch error, got %v", err)
+		}
+		stored, err := gx.GetByID(ctx, items[0].ID)
+		if err != nil || stored.Score != 0 || stored.Category != "z" {
+			t.Fatalf("patch was not persisted: %+v, %v", stored, err)
+		}
+	}},
+	{"UpdateInBatches", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		ctx := context.Background()
+		items := seedContractItems(t, gx)
+		updates := []*ContractItem{
+			{ID: items[0].ID, Name: "ignored", Score: 0},
+			{ID: items[1].ID, Score: 21, Category: "z"},
+			{ID: items[2].ID, Score: 31},
+		}
+		res, err := gx.UpdateInBatches(ctx, updates, []string{"score", "category"}, 2)
+		if err != nil || res.RowsAffected != 3 {
+			t.Fatalf("update in batches: %+v, %v", res, err)
+		}
+		got, err := gx.FindByIDs(ctx, []uint64{items[0].ID, items[1].ID, items[2].ID, items[3].ID})
+		if err != nil {
+			t.Fatalf("find by ids: %v", err)
+		}
+		expectNames(t, got, "a", "b", "c", "d")
+		want := []struct {
+			score    int
+			category string
+		}{{0, ""}, {21, "z"}, {31, ""}, {40, "y"}}
+		for i, item := range got {
+			if item.Score != want[i].score || item.Category != want[i].category {
+				t.Fatalf("row %s: got score %d category %q, want %d %q", item.Name, item.Score, item.Category, want[i].score, want[i].category)
+			}
+		}
+		if _, err := gx.UpdateInBatches(ctx, []*ContractItem{{Score: 1}}, []string{"score"}, 10); err == nil {
+			t.Fatalf("expected error for model without primary key")
+		}
+	}},
+	{"UpdateByMapFilter", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		ctx := context.Background()
+		seedContractItems(t, gx)
+		if _, err := gx.UpdateByMapFilter(ctx, map[string]any{"category": "x"}, map[string]any{"score": 0}); err != nil {
+			t.Fatalf("update by map filter: %v", err)
+		}
+		got, err := gx.FindByMapFilter(ctx, map[string]any{"score": 0})
+		if err != nil {
+			t.Fatalf("find by map filter: %v", err)
+		}
+		expectNames(t, got, "a", "c")
+	}},
+	{"Delete", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		ctx := context.Background()
+		items := seedContractItems(t, gx)
+		if _, err := gx.DeleteByID(ctx, items[0].ID); err != nil {
+			t.Fatalf("delete by id: %v", err)
+		}
+		if res, err := gx.DeleteByIDs(ctx, []uint64{items[1].ID, items[1].ID + 100}); err != nil || res.RowsAffected != 1 {
+			t.Fatalf("delete by ids: %+v, %v", res, err)
+		}
+		if _, err := gx.DeleteByMapFilter(ctx, map[string]any{"name": "c"}); err != nil {
+			t.Fatalf("delete by map filter: %v", err)
+		}
+		got, err := gx.FindByPage(ctx, 1, 10)
+		if err != nil {
+			t.Fatalf("find by page: %v", err)
+		}
+		expectNames(t, got, "d")
+	}},
+	{"RowsAffected", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		ctx := context.Background()
+		items := seedContractItems(t, gx)
+		created := &ContractItem{Name: "e", Category: "x"}
+		res, err := gx.Create(ctx, created)
+		if err != nil || res.RowsAffected != 1 || res.LastInsertID != int64(created.ID) {
+			t.Fatalf("create: %+v, %v", res, err)
+		}
+		res, err = gx.UpdateByMapFilter(ctx, map[string]any{"category": "y"}, map[string]any{"score": 1})
+		if err != nil || res.RowsAffected != 2 {
+			t.Fatalf("update by map filter: %+v, %v", res, err)
+		}
+		res, err = gx.DeleteByMapFilter(ctx, map[string]any{"name": "missing"})
+		if err != nil || res.RowsAffected != 0 {
+			t.Fatalf("delete missing row: %+v, %v", res, err)
+		}
+		_, err = gx.DeleteByMapFilter(ctx, map[string]any{"name": "missing"}, options.RequireRowsAffectedOption())
+		if !errors.IsNoRowsAffected(err) {
+			t.Fatalf("expected no rows affected error, got %v", err)
+		}
+		_, err = gx.DeleteByIDs(ctx, []uint64{items[0].ID, items[1].ID + 100}, options.ExpectRowsAffectedOption(2))
+		if !errors.IsRowsAffectedMismatch(err) {
+			t.Fatalf("expected rows affected mismatch error, got %v", err)
+		}
+		if got, err := gx.GetByID(ctx, items[0].ID); err != nil || got == nil {
+			t.Fatalf("expected delete by ids to be rolled back: %+v, %v", got, err)
+		}
+	}},
+	{"RowsAffectedCheckRollsBack", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		ctx := context.Background()
+		seedContractItems(t, gx)
+		_, err := gx.UpdateByMapFilter(ctx, map[string]any{"category": "y"}, map[string]any{"score": 1}, options.ExpectRowsAffectedOption(1))
+		if !errors.IsRowsAffectedMismatch(err) {
+			t.Fatalf("expected rows affected mismatch error, got %v", err)
+		}
+		_, err = gx.DeleteByStructFilter(ctx, &ContractItem{Category: "x"}, options.ExpectRowsAffectedOption(1))
+		if !errors.IsRowsAffectedMismatch(err) {
+			t.Fatalf("expected rows affected mismatch error, got %v", err)
+		}
+		// 事务中检查失败只回滚该写操作, 事务的其他修改照常提交
+		err = tx.Exec(ctx, func(ctx context.Context) error {
+			if _, err := gx.Create(ctx, &ContractItem{Name: "e", Category: "x"}); err != nil {
+				return err
+			}
+			if _, err := gx.Update(ctx, &ContractItem{ID: 1 << 40, Score: 1}, options.RequireRowsAffectedOption()); !errors.IsNoRowsAffected(err) {
+				t.Errorf("expected no rows affected error, got %v", err)
+			}
+			_, err := gx.UpdateByMapFilter(ctx, map[string]any{"category": "x"}, map[string]any{"score": 1}, options.ExpectRowsAffectedOption(1))
+			if !errors.IsRowsAffectedMismatch(err) {
+				t.Errorf("expected rows affected mismatch error, got %v", err)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("exec: %v", err)
+		}
+		got, err := gx.FindByMapFilter(ctx, map[string]any{"score": 1})
+		if err != nil || len(got) != 0 {
+			t.Fatalf("expected failed checks to be rolled back: %v, %v", names(got), err)
+		}
+		got, err = gx.FindByPage(ctx, 1, 10)
+		if err != nil {
+			t.Fatalf("find by page: %v", err)
+		}
+		expectNames(t, got, "a", "b", "c", "d", "e")
+	}},
+	{"TransactionRollback", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		ctx := context.Background()
+		items := seedContractItems(t, gx)
+		errRollback := stderrors.New("rollback")
+		err := tx.Exec(ctx, func(ctx context.Context) error {
+			if !gx.InTransaction(ctx) {
+				t.Errorf("expected context to carry the transaction")
+			}
+			if _, err := gx.Create(ctx, &ContractItem{Name: "e", Category: "x"}); err != nil {
+				return err
+			}
+			if _, err := gx.DeleteByID(ctx, items[0].ID); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if !stderrors.Is(err, errRollback) {
+			t.Fatalf("expected rollback error, got %v", err)
+		}
+		got, err := gx.FindByPage(ctx, 1, 10)
+		if err != nil {
+			t.Fatalf("find by page: %v", err)
+		}
+		expectNames(t, got, "a", "b", "c", "d")
+	}},
+	{"TransactionCommit", func(t *testing.T, gx gormx.GormX[ContractItem, uint64, *ContractItem], tx gormx.GormXTx) {
+		ctx := context.Background()
+		seedContractItems(t, gx)
+		err := tx.Exec(ctx, func(ctx context.Context) error {
+			_, err := gx.Create(ctx, &ContractItem{Name: "e", Category: "x"})
+			return err
+		})
+		if err != nil {
+			t.Fatalf("exec: %v", err)
+		}
+		if gx.InTransaction(ctx) {
+			t.Fatalf("expected context outside the transaction")
+		}
+		got, err := gx.FindByPage(ctx, 1, 10)
+		if err != nil {
+			t.Fatalf("find by page: %v", err)
+		}
+		expectNames(t, got, "a", "b", "c", "d", "e")
+	}},
+}
@@ -0,0 +1,40 @@
+package gormxtest_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/LouYuanbo1/go-webservice/gormx"
+	"github.com/LouYuanbo1/go-webservice/gormx/gormxtest"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestContractFake(t *testing.T) {
+	gormxtest.RunContract(t, func(t *testing.T) (gormx.GormX[gormxtest.ContractItem, uint64, *gormxtest.ContractItem], gormx.GormXTx) {
+		db := gormxtest.NewDB()
+		return gormxtest.NewGormX[gormxtest.ContractItem, uint64, *gormxtest.ContractItem](db), gormxtest.NewGormXTx(db)
+	})
+}
+
+func TestContractSQLite(t *testing.T) {
+	gormxtest.RunContract(t, func(t *testing.T) (gormx.GormX[gormxtest.ContractItem, uint64, *gormxtest.ContractItem], gormx.GormXTx) {
+		// 每个子测试使用独立的内存数据库, 同一个数据库的多个连接共享数据
+		dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+		db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { sqlDB.Close() })
+		if err := db.AutoMigrate(&gormxtest.ContractItem{}); err != nil {
+			t.Fatal(err)
+		}
+		return gormx.NewGormX[gormxtest.ContractItem, uint64, *gormxtest.ContractItem](db), gormx.NewGormXTx(db)
+	})
+}
@@ -0,0 +1,907 @@
+package gormxtest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/gormx"
+	"github.com/LouYuanbo1/go-webservice/gormx/errors"
+	"github.com/LouYuanbo1/go-webservice/gormx/internal"
+	"github.com/LouYuanbo1/go-webservice/gormx/model"
+	"github.com/LouYuanbo1/go-webservice/gormx/options"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var deletedAtType = reflect.TypeFor[gorm.DeletedAt]()
+
+type fakeGormX[T any, ID comparable, PT model.PointerModel[T, ID]] struct {
+	db        *DB
+	schema    *schema.Schema
+	tableName string
+	// 主键列, 复合主键按 ID 结构体字段顺序
+	keyFields []*schema.Field
+	// 唯一约束: 主键, unique 字段, uniqueIndex
+	uniques    [][]*schema.Field
+	softDelete *schema.Field
+}
+
+// condition 表示 column = value 或 column IN (values), path 非空时比较 JSON 列内部的字段
+type condition struct {
+	field  *schema.Field
+	path   []string
+	values []any
+}
+
+func newFakeGormX[T any, ID comparable, PT model.PointerModel[T, ID]](db *DB) *fakeGormX[T, ID, PT] {
+	var m T
+	ptr := PT(&m)
+	s, err := schema.Parse(ptr, &db.schemaCache, schema.NamingStrategy{})
+	if err != nil {
+		panic(fmt.Sprintf("gormxtest: parse model %T failed: %v", ptr, err))
+	}
+
+	f := &fakeGormX[T, ID, PT]{db: db, schema: s, tableName: ptr.TableName()}
+	for _, col := range model.KeyColumns[ID](ptr) {
+		field := s.LookUpField(col)
+		if field == nil {
+			panic(fmt.Sprintf("gormxtest: primary key column %q not found in model %T", col, ptr))
+		}
+		f.keyFields = append(f.keyFields, field)
+	}
+
+	f.uniques = append(f.uniques, f.keyFields)
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		if field.Unique {
+			f.uniques = append(f.uniques, []*schema.Field{field})
+		}
+		if field.FieldType == deletedAtType {
+			f.softDelete = field
+		}
+	}
+	for _, idx := range s.ParseIndexes() {
+		if idx.Class != "UNIQUE" {
+			continue
+		}
+		fields := make([]*schema.Field, 0, len(idx.Fields))
+		for _, opt := range idx.Fields {
+			fields = append(fields, opt.Field)
+		}
+		f.uniques = append(f.uniques, fields)
+	}
+	return f
+}
+
+func (f *fakeGormX[T, ID, PT]) GetDBWithContext(ctx context.Context) *gorm.DB {
+	return nil
+}
+
+func (f *fakeGormX[T, ID, PT]) InTransaction(ctx context.Context) bool {
+	return ctx.Value(contextTxKey{}) != nil
+}
+
+func (f *fakeGormX[T, ID, PT]) Create(ctx context.Context, m PT, opts ...options.ConflictOption) (gormx.Result, error) {
+	if m == nil {
+		return gormx.Result{}, nil
+	}
+	if err := internal.Validate("Create", f.tableName, m); err != nil {
+		return gormx.Result{}, err
+	}
+
+	op := "Create"
+	var conflict *clause.OnConflict
+	if len(opts) > 0 {
+		op = "Create(Upsert)"
+		c, err := f.conflictBuilder(opts...)
+		if err != nil {
+			return gormx.Result{}, errors.New(errors.ErrInvalidOnConflictClause, "Create", f.tableName, err)
+		}
+		conflict = c
+	}
+
+	f.db.mu.Lock()
+	defer f.db.mu.Unlock()
+	n, err := f.insert(f.db.table(f.tableName), m, conflict)
+	if err != nil {
+		return gormx.Result{}, errors.New(errors.ErrCreateFailed, op, f.tableName, err)
+	}
+	return gormx.Result{RowsAffected: n, LastInsertID: internal.LastInsertID(ctx, f.schema, m)}, nil
+}
+
+func (f *fakeGormX[T, ID, PT]) CreateInBatches(ctx context.Context, models []PT, batchSize int, opts ...options.ConflictOption) (gormx.Result, error) {
+	if batchSize <= 0 || len(models) == 0 {
+		return gormx.Result{}, nil
+	}
+	if err := internal.ValidateBatch("CreateInBatches", f.tableName, models); err != nil {
+		return gormx.Result{}, err
+	}
+
+	op := "CreateInBatches"
+	var conflict *clause.OnConflict
+	if len(opts) > 0 {
+		op = "CreateInBatches(Upsert)"
+		c, err := f.conflictBuilder(opts...)
+		if err != nil {
+			return gormx.Result{}, errors.New(errors.ErrInvalidOnConflictClause, "CreateInBatches", f.tableName, err)
+		}
+		conflict = c
+	}
+
+	f.db.mu.Lock()
+	defer f.db.mu.Unlock()
+	t := f.db.table(f.tableName)
+	backup := t.clone()
+	var res gormx.Result
+	for _, m := range models {
+		if m == nil {
+			continue
+		}
+		n, err := f.insert(t, m, conflict)
+		if err != nil {
+			// 批量插入失败时整体回滚
+			*t = *backup
+			return gormx.Result{}, errors.New(errors.ErrCreateFailed, op, f.tableName, err)
+		}
+		res.RowsAffected += n
+		res.LastInsertID = internal.LastInsertID(ctx, f.schema, m)
+	}
+	return res, nil
+}
+
+func (f *fakeGormX[T, ID, PT]) GetByID(ctx context.Context, id ID) (PT, error) {
+	if model.IsZero(id) {
+		return nil, nil
+	}
+	return f.first("GetByID", f.keyConditions(id))
+}
+
+func (f *fakeGormX[T, ID, PT]) FindByIDs(ctx context.Context, ids []ID, opts ...options.OrderOption) ([]PT, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	f.db.mu.Lock()
+	defer f.db.mu.Unlock()
+	rows := f.rows(func(row PT) bool {
+		for _, id := range ids {
+			if f.matchAll(row, f.keyConditions(id)) {
+				return true
+			}
+		}
+		return false
+	})
+	return f.sortCopies(rows, opts...), nil
+}
+
+func (f *fakeGormX[T, ID, PT]) GetByStructFilter(ctx context.Context, filter PT) (PT, error) {
+	if filter == nil {
+		return nil, nil
+	}
+	return f.first("GetByStructFilter", f.structConditions(filter))
+}
+
+func (f *fakeGormX[T, ID, PT]) FindByStructFilter(ctx context.Context, filter PT, opts ...options.OrderOption) ([]PT, error) {
+	if filter == nil {
+		return nil, nil
+	}
+	return f.find(f.structConditions(filter), opts...), nil
+}
+
+func (f *fakeGormX[T, ID, PT]) GetByMapFilter(ctx context.Context, filter map[string]any) (PT, error) {
+	if len(filter) == 0 {
+		return nil, nil
+	}
+	conds, err := f.mapConditions(filter)
+	if err != nil {
+		return nil, errors.New(errors.ErrQueryFailed, "GetByMapFilter", f.tableName, err)
+	}
+	return f.first("GetByMapFilter", conds)
+}
+
+func (f *fakeGormX[T, ID, PT]) FindByMapFilter(ctx context.Context, filter map[string]any, opts ...options.OrderOption) ([]PT, error) {
+	if len(filter) == 0 {
+		return nil, nil
+	}
+	conds, err := f.mapConditions(filter)
+	if err != nil {
+		return nil, errors.New(errors.ErrQueryFailed, "FindByMapFilter", f.tableName, err)
+	}
+	return f.find(conds, opts...), nil
+}
+
+func (f *fakeGormX[T, ID, PT]) FindByPage(ctx context.Context, page, pageSize int, opts ...options.OrderOption) ([]PT, error) {
+	if page <= 0 || pageSize <= 0 {
+		return nil, nil
+	}
+	rows := f.find(nil, opts...)
+	offset := (page - 1) * pageSize
+	if offset >= len(rows) {
+		return []PT{}, nil
+	}
+	return rows[offset:min(offset+pageSize, len(rows))], nil
+}
+
+func (f *fakeGormX[T, ID, PT]) FindByCursor(ctx context.Context, cursor ID, limit int) ([]PT, ID, bool, error) {
+	if limit <= 0 || model.IsZero(cursor) {
+		return nil, cursor, false, nil
+	}
+
+	cursorValues := model.KeyValues(cursor)
+	f.db.mu.Lock()
+	rows := f.rows(func(row PT) bool {
+		return f.compareKey(row, cursorValues) > 0
+	})
+	ptrModels := f.sortCopies(rows)
+	f.db.mu.Unlock()
+
+	hasMore := len(ptrModels) > limit
+	if hasMore {
+		ptrModels = ptrModels[:limit]
+	}
+	newCursor := cursor
+	if len(ptrModels) > 0 {
+		newCursor = ptrModels[len(ptrModels)-1].GetID()
+	}
+	return ptrModels, newCursor, hasMore, nil
+}
+
+func (f *fakeGormX[T, ID, PT]) Update(ctx context.Context, updateData PT, opts ...options.MutationOption) (gormx.Result, error) {
+	if updateData == nil {
+		return gormx.Result{}, nil
+	}
+	if err := internal.ValidateNonZero(ctx, "Update", f.tableName, f.schema, updateData); err != nil {
+		return gormx.Result{}, err
+	}
+
+	conds := f.primaryConditions(updateData)
+	if len(conds) == 0 {
+		return gormx.Result{}, errors.New(errors.ErrUpdateFailed, "Update", f.tableName, gorm.ErrMissingWhereClause)
+	}
+	return f.checked("Update", opts, func() (gormx.Result, error) {
+		return gormx.Result{RowsAffected: f.updateStruct(conds, updateData)}, nil
+	})
+}
+
+func (f *fakeGormX[T, ID, PT]) UpdateFields(ctx context.Context, updateData PT, fields []string, opts ...options.MutationOption) (gormx.Result, error) {
+	if updateData == nil || len(fields) == 0 {
+		return gormx.Result{}, nil
+	}
+	schemaFields, err := internal.ResolveFields("UpdateFields", f.tableName, f.schema, fields)
+	if err != nil {
+		return gormx.Result{}, err
+	}
+	if err := internal.ValidateFields("UpdateFields", f.tableName, updateData, schemaFields); err != nil {
+		return gormx.Result{}, err
+	}
+
+	conds := f.primaryConditions(updateData)
+	if len(conds) == 0 {
+		return gormx.Result{}, errors.New(errors.ErrUpdateFailed, "UpdateFields", f.tableName, gorm.ErrMissingWhereClause)
+	}
+
+	return f.checked("UpdateFields", opts, func() (gormx.Result, error) {
+		// 与 gorm 一致, 即使未选中 UpdatedAt 也会刷新, 选中的字段包括零值都会写入
+		now := time.Now()
+		src := reflect.ValueOf(updateData)
+		f.touch(src, now, nil)
+		f.db.mu.Lock()
+		defer f.db.mu.Unlock()
+		var n int64
+		for _, row := range f.rows(func(row PT) bool { return f.matchAll(row, conds) }) {
+			dst := reflect.ValueOf(row)
+			for _, field := range schemaFields {
+				if field.PrimaryKey {
+					continue
+				}
+				field.ReflectValueOf(ctx, dst).Set(field.ReflectValueOf(ctx, src))
+			}
+			f.touch(dst, now, nil)
+			n++
+		}
+		return gormx.Result{RowsAffected: n}, nil
+	})
+}
+
+func (f *fakeGormX[T, ID, PT]) Save(ctx context.Context, m PT, opts ...options.MutationOption) (gormx.Result, error) {
+	if m == nil {
+		return gormx.Result{}, nil
+	}
+	if err := internal.Validate("Save", f.tableName, m); err != nil {
+		return gormx.Result{}, err
+	}
+
+	return f.checked("Save", opts, func() (gormx.Result, error) {
+		f.db.mu.Lock()
+		defer f.db.mu.Unlock()
+		t := f.db.table(f.tableName)
+		conds := f.primaryConditions(m)
+		if len(conds) == len(f.schema.PrimaryFields) {
+			if rows := f.rows(func(row PT) bool { return f.matchAll(row, conds) }); len(rows) > 0 {
+				// 与 gorm Save 一致, 整行替换(包括零值字段)
+				f.touch(reflect.ValueOf(m), time.Now(), nil)
+				for _, row := range rows {
+					*row = *m
+				}
+				return gormx.Result{RowsAffected: int64(len(rows))}, nil
+			}
+		}
+		n, err := f.insert(t, m, nil)
+		if err != nil {
+			return gormx.Result{}, errors.New(errors.ErrUpdateFailed, "Save", f.tableName, err)
+		}
+		return gormx.Result{RowsAffected: n, LastInsertID: internal.LastInsertID(ctx, f.schema, m)}, nil
+	})
+}
+
+func (f *fakeGormX[T, ID, PT]) PatchByID(ctx context.Context, id ID, patch []byte, patchable ...string) (PT, error) {
+	if model.IsZero(id) {
+		return nil, nil
+	}
+	parsed, err := internal.ParsePatch("PatchByID", f.tableName, f.schema, patch, patchable)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := f.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsed) == 0 {
+		return current, nil
+	}
+	if err := internal.ApplyPatch(ctx, "PatchByID", f.tableName, current, parsed); err != nil {
+		return nil, err
+	}
+	if _, err := f.UpdateFields(ctx, current, parsed.Columns()); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+func (f *fakeGormX[T, ID, PT]) UpdateInBatches(ctx context.Context, models []PT, fields []string, batchSize int, opts ...options.MutationOption) (gormx.Result, error) {
+	if batchSize <= 0 || len(models) == 0 || len(fields) == 0 {
+		return gormx.Result{}, nil
+	}
+	schemaFields, err := internal.ResolveFields("UpdateInBatches", f.tableName, f.schema, fields)
+	if err != nil {
+		return gormx.Result{}, err
+	}
+	if err := internal.ValidateFieldsBatch("UpdateInBatches", f.tableName, models, schemaFields); err != nil {
+		return gormx.Result{}, err
+	}
+	for i, m := range models {
+		if m == nil {
+			return gormx.Result{}, errors.New(errors.ErrUpdateFailed, "UpdateInBatches", f.tableName, fmt.Errorf("models[%d]: %s", i, errors.WarnInvalidModel))
+		}
+		if len(f.primaryConditions(m)) < len(f.keyFields) {
+			return gormx.Result{}, errors.New(errors.ErrUpdateFailed, "UpdateInBatches", f.tableName, fmt.Errorf("models[%d]: %w", i, gorm.ErrPrimaryKeyRequired))
+		}
+	}
+
+	return f.checked("UpdateInBatches", opts, func() (gormx.Result, error) {
+		now := time.Now()
+		f.db.mu.Lock()
+		defer f.db.mu.Unlock()
+		var rowsAffected int64
+		for _, m := range models {
+			src := reflect.ValueOf(m)
+			f.touch(src, now, nil)
+			for _, row := range f.rows(func(row PT) bool { return f.matchAll(row, f.primaryConditions(m)) }) {
+				dst := reflect.ValueOf(row)
+				for _, field := range schemaFields {
+					if field.PrimaryKey {
+						continue
+					}
+					field.ReflectValueOf(ctx, dst).Set(field.ReflectValueOf(ctx, src))
+				}
+				f.touch(dst, now, nil)
+				rowsAffected++
+			}
+		}
+		return gormx.Result{RowsAffected: rowsAffected}, nil
+	})
+}
+
+func (f *fakeGormX[T, ID, PT]) UpdateByStructFilter(ctx context.Context, filter PT, updateData PT, opts ...options.MutationOption) (gormx.Result, error) {
+	if updateData == nil || filter == nil {
+		return gormx.Result{}, nil
+	}
+
+	// 与 gorm 一致, updateData 中非零的主键也会作为条件
+	conds := append(f.structConditions(filter), f.primaryConditions(updateData)...)
+	if len(conds) == 0 {
+		return gormx.Result{}, errors.New(errors.ErrUpdateFailed, "UpdateByStructFilter", f.tableName, gorm.ErrMissingWhereClause)
+	}
+	return f.checked("UpdateByStructFilter", opts, func() (gormx.Result, error) {
+		return gormx.Result{RowsAffected: f.updateStruct(conds, updateData)}, nil
+	})
+}
+
+func (f *fakeGormX[T, ID, PT]) UpdateByMapFilter(ctx context.Context, filter map[string]any, updateData map[string]any, opts ...options.MutationOption) (gormx.Result, error) {
+	if len(updateData) == 0 || len(filter) == 0 {
+		return gormx.Result{}, nil
+	}
+
+	conds, err := f.mapConditions(filter)
+	if err != nil {
+		return gormx.Result{}, errors.New(errors.ErrUpdateFailed, "UpdateByMapFilter", f.tableName, err)
+	}
+	fields := make(map[*schema.Field]any, len(updateData))
+	for column, value := range updateData {
+		field := f.lookUpField(column)
+		if field == nil {
+			return gormx.Result{}, errors.New(errors.ErrUpdateFailed, "UpdateByMapFilter", f.tableName, fmt.Errorf("unknown column %q", column))
+		}
+		fields[field] = value
+	}
+
+	return f.checked("UpdateByMapFilter", opts, func() (gormx.Result, error) {
+		now := time.Now()
+		f.db.mu.Lock()
+		defer f.db.mu.Unlock()
+		var n int64
+		for _, row := range f.rows(func(row PT) bool { return f.matchAll(row, conds) }) {
+			rv := reflect.ValueOf(row)
+			for field, value := range fields {
+				if err := field.Set(ctx, rv, value); err != nil {
+					return gormx.Result{}, errors.New(errors.ErrUpdateFailed, "UpdateByMapFilter", f.tableName, err)
+				}
+			}
+			f.touch(rv, now, fields)
+			n++
+		}
+		return gormx.Result{RowsAffected: n}, nil
+	})
+}
+
+func (f *fakeGormX[T, ID, PT]) DeleteByID(ctx context.Context, id ID, opts ...options.MutationOption) (gormx.Result, error) {
+	if model.IsZero(id) {
+		return gormx.Result{}, nil
+	}
+	return f.checked("DeleteByID", opts, func() (gormx.Result, error) {
+		return gormx.Result{RowsAffected: f.delete(f.keyConditions(id))}, nil
+	})
+}
+
+func (f *fakeGormX[T, ID, PT]) DeleteByIDs(ctx context.Context, ids []ID, opts ...options.MutationOption) (gormx.Result, error) {
+	if len(ids) == 0 {
+		return gormx.Result{}, nil
+	}
+
+	return f.checked("DeleteByIDs", opts, func() (gormx.Result, error) {
+		f.db.mu.Lock()
+		defer f.db.mu.Unlock()
+		n := f.deleteRows(func(row PT) bool {
+			for _, id := range ids {
+				if f.matchAll(row, f.keyConditions(id)) {
+					return true
+				}
+			}
+			return false
+		})
+		return gormx.Result{RowsAffected: n}, nil
+	})
+}
+
+func (f *fakeGormX[T, ID, PT]) DeleteByStructFilter(ctx context.Context, filter PT, opts ...options.MutationOption) (gormx.Result, error) {
+	if filter == nil {
+		return gormx.Result{}, nil
+	}
+	conds := f.structConditions(filter)
+	if len(conds) == 0 {
+		return gormx.Result{}, errors.New(errors.ErrDeleteFailed, "DeleteByStructFilter", f.tableName, gorm.ErrMissingWhereClause)
+	}
+	return f.checked("DeleteByStructFilter", opts, func() (gormx.Result, error) {
+		return gormx.Result{RowsAffected: f.delete(conds)}, nil
+	})
+}
+
+func (f *fakeGormX[T, ID, PT]) DeleteByMapFilter(ctx context.Context, filter map[string]any, opts ...options.MutationOption) (gormx.Result, error) {
+	if len(filter) == 0 {
+		return gormx.Result{}, nil
+	}
+	conds, err := f.mapConditions(filter)
+	if err != nil {
+		return gormx.Result{}, errors.New(errors.ErrDeleteFailed, "DeleteByMapFilter", f.tableName, err)
+	}
+	return f.checked("DeleteByMapFilter", opts, func() (gormx.Result, error) {
+		return gormx.Result{RowsAffected: f.delete(conds)}, nil
+	})
+}
+
+// ===== 内部辅助方法 =====
+
+// checked 执行写操作并检查受影响行数, 与真实实现一致, 检查失败时恢复写操作前的表并返回检查错误
+func (f *fakeGormX[T, ID, PT]) checked(op string, opts []options.MutationOption, fn func() (gormx.Result, error)) (gormx.Result, error) {
+	if len(opts) == 0 {
+		return fn()
+	}
+	f.db.mu.Lock()
+	backup := f.db.table(f.tableName).clone()
+	f.db.mu.Unlock()
+
+	res, err := fn()
+	if err != nil {
+		return res, err
+	}
+	if err := options.NewMutationWithOptions(opts...).Check(op, f.tableName, res.RowsAffected); err != nil {
+		f.db.mu.Lock()
+		*f.db.table(f.tableName) = *backup
+		f.db.mu.Unlock()
+		return gormx.Result{}, err
+	}
+	return res, nil
+}
+
+func (f *fakeGormX[T, ID, PT]) conflictBuilder(opts ...options.ConflictOption) (*clause.OnConflict, error) {
+	// 与真实实现一致, 默认以主键作为冲突约束列
+	columns := make([]string, len(f.keyFields))
+	for i, field := range f.keyFields {
+		columns[i] = field.DBName
+	}
+	defaults := []options.ConflictOption{options.OnConstraintColumns(columns...)}
+	return options.NewConflictWithOptions(append(defaults, opts...)...).Build()
+}
+
+func (f *fakeGormX[T, ID, PT]) lookUpField(column string) *schema.Field {
+	field := f.schema.LookUpField(column)
+	if field == nil || field.DBName == "" {
+		return nil
+	}
+	return field
+}
+
+func (f *fakeGormX[T, ID, PT]) valueOf(row PT, field *schema.Field) any {
+	return field.ReflectValueOf(context.Background(), reflect.ValueOf(row)).Interface()
+}
+
+func (f *fakeGormX[T, ID, PT]) isZero(row PT, field *schema.Field) bool {
+	_, zero := field.ValueOf(context.Background(), reflect.ValueOf(row))
+	return zero
+}
+
+func (f *fakeGormX[T, ID, PT]) keyConditions(id ID) []condition {
+	values := model.KeyValues(id)
+	conds := make([]condition, len(f.keyFields))
+	for i, field := range f.keyFields {
+		conds[i] = condition{field: field, values: []any{values[i]}}
+	}
+	return conds
+}
+
+// primaryConditions 返回模型中非零主键字段的条件
+func (f *fakeGormX[T, ID, PT]) primaryConditions(m PT) []condition {
+	var conds []condition
+	for _, field := range f.keyFields {
+		if !f.isZero(m, field) {
+			conds = append(conds, condition{field: field, values: []any{f.valueOf(m, field)}})
+		}
+	}
+	return conds
+}
+
+// structConditions 与 gorm Where(struct) 一致, 只使用非零值字段
+func (f *fakeGormX[T, ID, PT]) structConditions(filter PT) []condition {
+	var conds []condition
+	for _, field := range f.schema.Fields {
+		if field.DBName == "" || f.isZero(filter, field) {
+			continue
+		}
+		conds = append(conds, condition{field: field, values: []any{f.valueOf(filter, field)}})
+	}
+	return conds
+}
+
+// mapConditions 与 gorm Where(map) 一致, 切片值视为 IN, nil 视为 IS NULL
+func (f *fakeGormX[T, ID, PT]) mapConditions(filter map[string]any) ([]condition, error) {
+	conds := make([]condition, 0, len(filter))
+	for key, value := range filter {
+		column, jsonPath, isPath := strings.Cut(key, model.JSONPathSeparator)
+		field := f.lookUpField(column)
+		if field == nil {
+			return nil, fmt.Errorf("unknown column %q", column)
+		}
+		var path []string
+		if isPath {
+			path = strings.Split(jsonPath, ".")
+		}
+		rv := reflect.ValueOf(value)
+		if value != nil && rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+			values := make([]any, rv.Len())
+			for i := range values {
+				values[i] = rv.Index(i).Interface()
+			}
+			conds = append(conds, condition{field: field, path: path, values: values})
+			continue
+		}
+		conds = append(conds, condition{field: field, path: path, values: []any{value}})
+	}
+	return conds, nil
+}
+
+func (f *fakeGormX[T, ID, PT]) matchAll(row PT, conds []condition) bool {
+	for _, cond := range conds {
+		value := f.valueOf(row, cond.field)
+		if len(cond.path) > 0 {
+			value = jsonPathValue(value, cond.path)
+		}
+		if !slices.ContainsFunc(cond.values, func(want any) bool { return equalValues(value, want) }) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *fakeGormX[T, ID, PT]) compareKey(row PT, values []any) int {
+	for i, field := range f.keyFields {
+		if c, _ := compareValues(f.valueOf(row, field), values[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func (f *fakeGormX[T, ID, PT]) deleted(row PT) bool {
+	return f.softDelete != nil && normalize(f.valueOf(row, f.softDelete)) != nil
+}
+
+// rows 返回未被软删除且满足 pred 的行(存储中的原始指针), 按主键升序, 调用方需持有 mu
+func (f *fakeGormX[T, ID, PT]) rows(pred func(row PT) bool) []PT {
+	t := f.db.table(f.tableName)
+	rows := make([]PT, 0, len(t.rows))
+	for _, r := range t.rows {
+		row := r.(PT)
+		if f.deleted(row) || (pred != nil && !pred(row)) {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return f.compareKey(rows[i], f.keyValuesOf(rows[j])) < 0
+	})
+	return rows
+}
+
+func (f *fakeGormX[T, ID, PT]) keyValuesOf(row PT) []any {
+	values := make([]any, len(f.keyFields))
+	for i, field := range f.keyFields {
+		values[i] = f.valueOf(row, field)
+	}
+	return values
+}
+
+// sortCopies 按 OrderOption 排序并返回副本
+func (f *fakeGormX[T, ID, PT]) sortCopies(rows []PT, opts ...options.OrderOption) []PT {
+	if orderBy := options.NewOrderWithOptions(opts...).Build(); orderBy != nil {
+		sort.SliceStable(rows, func(i, j int) bool {
+			for _, col := range orderBy.Columns {
+				field := f.lookUpField(col.Column.Name)
+				if field == nil {
+					continue
+				}
+				c, _ := compareValues(f.valueOf(rows[i], field), f.valueOf(rows[j], field))
+				if c != 0 {
+					return (c < 0) != col.Desc
+				}
+			}
+			return false
+		})
+	}
+
+	copies := make([]PT, len(rows))
+	for i, row := range rows {
+		copies[i] = copyRow(row).(PT)
+	}
+	return copies
+}
+
+func (f *fakeGormX[T, ID, PT]) find(conds []condition, opts ...options.OrderOption) []PT {
+	f.db.mu.Lock()
+	defer f.db.mu.Unlock()
+	return f.sortCopies(f.rows(func(row PT) bool { return f.matchAll(row, conds) }), opts...)
+}
+
+func (f *fakeGormX[T, ID, PT]) first(op string, conds []condition) (PT, error) {
+	rows := f.find(conds)
+	if len(rows) == 0 {
+		return nil, errors.New(errors.ErrQueryFailed, op, f.tableName, gorm.ErrRecordNotFound)
+	}
+	return rows[0], nil
+}
+
+// touch 为自动更新时间字段赋值, 已显式赋值的字段除外
+func (f *fakeGormX[T, ID, PT]) touch(rv reflect.Value, now time.Time, assigned map[*schema.Field]any) {
+	for _, field := range f.schema.Fields {
+		if field.AutoUpdateTime == 0 {
+			continue
+		}
+		if _, ok := assigned[field]; ok {
+			continue
+		}
+		_ = field.Set(context.Background(), rv, now)
+	}
+}
+
+// updateStruct 与 gorm Updates(struct) 一致, 只更新非零值的非主键字段
+func (f *fakeGormX[T, ID, PT]) updateStruct(conds []condition, updateData PT) int64 {
+	now := time.Now()
+	src := reflect.ValueOf(updateData)
+	f.touch(src, now, nil)
+
+	f.db.mu.Lock()
+	defer f.db.mu.Unlock()
+	var n int64
+	for _, row := range f.rows(func(row PT) bool { return f.matchAll(row, conds) }) {
+		dst := reflect.ValueOf(row)
+		for _, field := range f.schema.Fields {
+			if field.DBName == "" || field.PrimaryKey || !field.Updatable || f.isZero(updateData, field) {
+				continue
+			}
+			field.ReflectValueOf(context.Background(), dst).Set(field.ReflectValueOf(context.Background(), src))
+		}
+		n++
+	}
+	return n
+}
+
+func (f *fakeGormX[T, ID, PT]) delete(conds []condition) int64 {
+	f.db.mu.Lock()
+	defer f.db.mu.Unlock()
+	return f.deleteRows(func(row PT) bool { return f.matchAll(row, conds) })
+}
+
+// deleteRows 删除满足 pred 的行, 模型包含 gorm.DeletedAt 时执行软删除, 调用方需持有 mu
+func (f *fakeGormX[T, ID, PT]) deleteRows(pred func(row PT) bool) int64 {
+	t := f.db.table(f.tableName)
+	if f.softDelete != nil {
+		deletedAt := reflect.ValueOf(gorm.DeletedAt{Time: time.Now(), Valid: true})
+		rows := f.rows(pred)
+		for _, row := range rows {
+			f.softDelete.ReflectValueOf(context.Background(), reflect.ValueOf(row)).Set(deletedAt)
+		}
+		return int64(len(rows))
+	}
+
+	n := len(t.rows)
+	t.rows = slices.DeleteFunc(t.rows, func(r any) bool {
+		return pred(r.(PT))
+	})
+	return int64(n - len(t.rows))
+}
+
+// insert 插入一行, 处理自增主键, 自动时间与唯一约束冲突, 调用方需持有 mu
+func (f *fakeGormX[T, ID, PT]) insert(t *table, m PT, conflict *clause.OnConflict) (int64, error) {
+	ctx := context.Background()
+	rv := reflect.ValueOf(m)
+	now := time.Now()
+	for _, field := range f.schema.Fields {
+		if (field.AutoCreateTime > 0 || field.AutoUpdateTime > 0) && f.isZero(m, field) {
+			_ = field.Set(ctx, rv, now)
+		}
+	}
+
+	pk := f.schema.PrioritizedPrimaryField
+	assigned := false
+	if pk != nil && pk.AutoIncrement {
+		if f.isZero(m, pk) {
+			t.nextID++
+			if err := pk.Set(ctx, rv, t.nextID); err != nil {
+				return 0, err
+			}
+			assigned = true
+		} else if id, ok := normalize(f.valueOf(m, pk)).(int64); ok && id > t.nextID {
+			t.nextID = id
+		}
+	}
+	revert := func() {
+		if assigned {
+			pk.ReflectValueOf(ctx, rv).SetZero()
+			t.nextID--
+		}
+	}
+
+	existing, fields := f.findConflict(t, m)
+	if existing == nil {
+		t.rows = append(t.rows, copyRow(m))
+		return 1, nil
+	}
+	if conflict == nil || !handlesConflict(conflict, fields) {
+		revert()
+		return 0, fmt.Errorf("duplicate key value violates unique constraint (%s)", columnNames(fields))
+	}
+
+	revert()
+	var columns []string
+	switch {
+	case conflict.DoNothing:
+		return 0, nil
+	case conflict.UpdateAll:
+		for _, field := range f.schema.Fields {
+			if field.DBName != "" && !field.PrimaryKey && field.AutoCreateTime == 0 {
+				columns = append(columns, field.DBName)
+			}
+		}
+	default:
+		for _, assignment := range conflict.DoUpdates {
+			columns = append(columns, assignment.Column.Name)
+		}
+	}
+
+	dst := reflect.ValueOf(existing)
+	for _, column := range columns {
+		if field := f.lookUpField(column); field != nil {
+			field.ReflectValueOf(ctx, dst).Set(field.ReflectValueOf(ctx, rv))
+		}
+	}
+	// 与 RETURNING 一致, 将已存在行的主键写回模型
+	for _, field := range f.schema.PrimaryFields {
+		field.ReflectValueOf(ctx, rv).Set(field.ReflectValueOf(ctx, dst))
+	}
+	return 1, nil
+}
+
+// findConflict 查找与 m 违反同一唯一约束的已有行, NULL 值不参与唯一约束
+func (f *fakeGormX[T, ID, PT]) findConflict(t *table, m PT) (PT, []*schema.Field) {
+	for _, fields := range f.uniques {
+		values := make([]any, len(fields))
+		hasNull := false
+		for i, field := range fields {
+			values[i] = f.valueOf(m, field)
+			if normalize(values[i]) == nil {
+				hasNull = true
+				break
+			}
+		}
+		if hasNull {
+			continue
+		}
+
+		for _, r := range t.rows {
+			row := r.(PT)
+			match := true
+			for i, field := range fields {
+				if !equalValues(f.valueOf(row, field), values[i]) {
+					match = false
+					break
+				}
+			}
+			if match {
+				return row, fields
+			}
+		}
+	}
+	return nil, nil
+}
+
+// handlesConflict 判断 ON CONFLICT 的目标是否覆盖发生冲突的约束
+func handlesConflict(conflict *clause.OnConflict, fields []*schema.Field) bool {
+	if conflict.OnConstraint != "" {
+		return true
+	}
+	if len(conflict.Columns) != len(fields) {
+		return false
+	}
+	for _, field := range fields {
+		if !slices.ContainsFunc(conflict.Columns, func(col clause.Column) bool { return col.Name == field.DBName }) {
+			return false
+		}
+	}
+	return true
+}
+
+func columnNames(fields []*schema.Field) string {
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.DBName
+	}
+	return strings.Join(names, ", ")
+}
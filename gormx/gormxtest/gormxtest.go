@@ -0,0 +1,109 @@
+/*
+Package gormxtest 提供 gormx.GormX 与 gormx.GormXTx 的内存实现, 用于依赖 GormX 的服务的单元测试.
+
+内存实现借助 gorm 的 schema 解析模型字段, 尽量与真实实现保持一致:
+  - 结构体过滤只使用非零值字段, Map 过滤支持切片(IN)与 nil(IS NULL)
+  - Map 过滤支持 model.JSONPath 键, 按 JSON 原生类型比较(与 SQLite 一致, Postgres / MySQL 按文本比较)
+  - 支持 OrderOption 排序, 分页, 游标(含复合主键)
+  - 支持主键自增, CreatedAt/UpdatedAt 自动时间, gorm.DeletedAt 软删除
+  - 主键, unique 字段与 uniqueIndex 视为唯一约束, 支持 ConflictOption 的 DoNothing/UpdateColumns/UpdateAll
+  - GormXTx 在 fn 返回错误或 panic 时回滚到执行前的快照
+
+限制:
+  - GetDBWithContext 返回 nil, 直接使用 *gorm.DB 的代码无法被模拟
+  - 不执行 gorm 回调与插件, idgen.Plugin 不会分配 ID, 自增主键由内存表按顺序分配
+  - OnConstraint(name) 按主键约束处理
+  - 事务没有隔离性, 回滚会覆盖事务期间其他 goroutine 的写入
+
+Package gormxtest provides in-memory implementations of gormx.GormX and gormx.GormXTx
+for unit testing services that depend on GormX. Use RunContract to check that the fake
+and a real database behave the same.
+
+Example:
+
+	db := gormxtest.NewDB()
+	users := gormxtest.NewGormX[User, uint64, *User](db)
+	tx := gormxtest.NewGormXTx(db)
+	svc := NewUserService(users, tx)
+*/
+package gormxtest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/LouYuanbo1/go-webservice/gormx"
+	"github.com/LouYuanbo1/go-webservice/gormx/model"
+)
+
+// DB 是内存数据库, 同一个 DB 上创建的 GormX 与 GormXTx 共享数据
+type DB struct {
+	mu          sync.Mutex
+	tables      map[string]*table
+	schemaCache sync.Map
+}
+
+func NewDB() *DB {
+	return &DB{tables: make(map[string]*table)}
+}
+
+// table 返回指定表, 不存在时创建, 调用方需持有 mu
+func (db *DB) table(name string) *table {
+	t, ok := db.tables[name]
+	if !ok {
+		t = &table{}
+		db.tables[name] = t
+	}
+	return t
+}
+
+func (db *DB) snapshot() map[string]*table {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	snap := make(map[string]*table, len(db.tables))
+	for name, t := range db.tables {
+		snap[name] = t.clone()
+	}
+	return snap
+}
+
+func (db *DB) restore(snap map[string]*table) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.tables = snap
+}
+
+// Reset 清空全部数据
+func (db *DB) Reset() {
+	db.restore(make(map[string]*table))
+}
+
+type contextTxKey struct{}
+
+func NewGormX[T any, ID comparable, PT model.PointerModel[T, ID]](db *DB) gormx.GormX[T, ID, PT] {
+	return newFakeGormX[T, ID, PT](db)
+}
+
+func NewGormXTx(db *DB) gormx.GormXTx {
+	return &fakeTx{db: db}
+}
+
+type fakeTx struct {
+	db *DB
+}
+
+func (tx *fakeTx) Exec(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	snap := tx.db.snapshot()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.db.restore(snap)
+			panic(r)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, contextTxKey{}, tx.db)); err != nil {
+		tx.db.restore(snap)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package gormxtest
+
+import (
+	"cmp"
+	"database/sql/driver"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// table 保存一张表的全部行, 每一行是一个 *T 的副本
+type table struct {
+	rows   []any
+	nextID int64
+}
+
+// copyRow 复制一行数据(浅拷贝结构体), 避免调用方修改影响存储
+func copyRow(row any) any {
+	v := reflect.ValueOf(row).Elem()
+	cp := reflect.New(v.Type())
+	cp.Elem().Set(v)
+	return cp.Interface()
+}
+
+func (t *table) clone() *table {
+	rows := make([]any, len(t.rows))
+	for i, row := range t.rows {
+		rows[i] = copyRow(row)
+	}
+	return &table{rows: rows, nextID: t.nextID}
+}
+
+// normalize 将字段值归一化为可比较的基础类型:
+// 整数 -> int64 (超出范围的无符号数保留 uint64), 浮点 -> float64, []byte -> string,
+// 指针解引用, driver.Valuer 取其 Value()
+func normalize(v any) any {
+	for {
+		if v == nil {
+			return nil
+		}
+		if valuer, ok := v.(driver.Valuer); ok {
+			value, err := valuer.Value()
+			if err != nil {
+				return v
+			}
+			v = value
+			continue
+		}
+		if b, ok := v.([]byte); ok {
+			return string(b)
+		}
+
+		rv := reflect.ValueOf(v)
+		switch rv.Kind() {
+		case reflect.Pointer:
+			if rv.IsNil() {
+				return nil
+			}
+			v = rv.Elem().Interface()
+			continue
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return rv.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if u := rv.Uint(); u <= math.MaxInt64 {
+				return int64(u)
+			}
+			return rv.Uint()
+		case reflect.Float32, reflect.Float64:
+			return rv.Float()
+		case reflect.String:
+			return rv.String()
+		case reflect.Bool:
+			return rv.Bool()
+		}
+		return v
+	}
+}
+
+func toFloat(v any) (float64, bool) {
+	switch x := v.(type) {
+	case int64:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+// compareValues 比较两个字段值, NULL 视为最小值; 类型不可比较时返回 false
+func compareValues(a, b any) (int, bool) {
+	a, b = normalize(a), normalize(b)
+	switch {
+	case a == nil && b == nil:
+		return 0, true
+	case a == nil:
+		return -1, true
+	case b == nil:
+		return 1, true
+	}
+
+	switch x := a.(type) {
+	case int64:
+		if y, ok := b.(int64); ok {
+			return cmp.Compare(x, y), true
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return cmp.Compare(x, y), true
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0, true
+			case !x:
+				return -1, true
+			default:
+				return 1, true
+			}
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y), true
+		}
+	}
+
+	if x, ok := toFloat(a); ok {
+		if y, ok := toFloat(b); ok {
+			return cmp.Compare(x, y), true
+		}
+	}
+	if reflect.DeepEqual(a, b) {
+		return 0, true
+	}
+	return 0, false
+}
+
+func equalValues(a, b any) bool {
+	c, ok := compareValues(a, b)
+	return ok && c == 0
+}
+
+// jsonPathValue 解析 JSON 列的值并按路径取出内部字段, 纯数字的路径段视为数组下标, 路径不存在时返回 nil
+func jsonPathValue(v any, path []string) any {
+	raw, ok := normalize(v).(string)
+	if !ok {
+		return nil
+	}
+	var node any
+	if err := json.Unmarshal([]byte(raw), &node); err != nil {
+		return nil
+	}
+	for _, seg := range path {
+		switch x := node.(type) {
+		case map[string]any:
+			node = x[seg]
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(x) {
+				return nil
+			}
+			node = x[i]
+		default:
+			return nil
+		}
+	}
+	return node
+}
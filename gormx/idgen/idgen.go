@@ -0,0 +1,30 @@
+/*
+Package idgen 为模型在插入前生成分布式 ID, 使模型在写入数据库之前就能用于缓存与事件.
+
+  - Snowflake: 64 位整数 ID (41 位毫秒时间戳 + 10 位 worker ID + 12 位序列号), 适用于 uint64 主键.
+    worker ID 来自配置或 Redis 租约(redisx.AcquireLease), 小幅时钟回拨时等待, 超出阈值时返回错误.
+  - ULID / UUIDv7: 按时间有序的字符串 ID, 适用于 string 主键, 无需协调 worker ID.
+
+配合 Plugin 与 model.IDSetter 使用时, Create 和 CreateInBatches 会为 GetID() 为零值的模型自动分配 ID.
+
+Package idgen generates distributed IDs for models before insert.
+
+Example:
+
+	sf, err := idgen.NewSnowflakeWithLease(ctx, redisClient)
+	if err != nil {
+		return err
+	}
+	defer sf.Close(context.Background())
+	if err := db.Use(idgen.NewPlugin[uint64](sf)); err != nil {
+		return err
+	}
+*/
+package idgen
+
+import "context"
+
+// Generator 生成 ID, 实现必须并发安全
+type Generator[ID comparable] interface {
+	NextID(ctx context.Context) (ID, error)
+}
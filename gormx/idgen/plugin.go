@@ -0,0 +1,83 @@
+package idgen
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/LouYuanbo1/go-webservice/gormx/model"
+	"gorm.io/gorm"
+)
+
+/*
+Plugin 是 gorm 插件, 在 Create 回调之前为实现了 model.IDSetter[ID] 且 GetID() 为零值的模型分配 ID.
+GormX 的 Create 与 CreateInBatches 都经过该回调, 批量插入时切片中的每个模型都会被处理.
+不同 ID 类型的模型可以分别注册插件, 例如 uint64 使用 Snowflake, string 使用 ULID.
+
+注意: gorm 默认将整数主键视为自增列, 使用生成的 ID 时建议声明 `gorm:"primaryKey;autoIncrement:false"`.
+
+Plugin is a gorm plugin that assigns IDs before the create callback to models implementing
+model.IDSetter[ID] whose GetID() is zero.
+
+Example:
+
+	db.Use(idgen.NewPlugin[uint64](snowflake))
+	db.Use(idgen.NewPlugin[string](idgen.NewULID()))
+*/
+type Plugin[ID comparable] struct {
+	gen Generator[ID]
+}
+
+func NewPlugin[ID comparable](gen Generator[ID]) *Plugin[ID] {
+	return &Plugin[ID]{gen: gen}
+}
+
+func (p *Plugin[ID]) Name() string {
+	return fmt.Sprintf("gormx:idgen:%s", reflect.TypeFor[ID]())
+}
+
+func (p *Plugin[ID]) Initialize(db *gorm.DB) error {
+	// 在 BeforeCreate 钩子之前分配, 钩子中可以读取到 ID
+	return db.Callback().Create().Before("gorm:before_create").Register(p.Name(), p.assign)
+}
+
+func (p *Plugin[ID]) assign(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := range rv.Len() {
+			if err := p.assignOne(db, rv.Index(i)); err != nil {
+				db.AddError(err)
+				return
+			}
+		}
+	case reflect.Struct:
+		if err := p.assignOne(db, rv); err != nil {
+			db.AddError(err)
+		}
+	}
+}
+
+func (p *Plugin[ID]) assignOne(db *gorm.DB, rv reflect.Value) error {
+	rv = reflect.Indirect(rv)
+	if !rv.IsValid() || !rv.CanAddr() {
+		return nil
+	}
+	m, ok := rv.Addr().Interface().(interface {
+		model.Model[ID]
+		model.IDSetter[ID]
+	})
+	if !ok || !model.IsZero(m.GetID()) {
+		return nil
+	}
+
+	id, err := p.gen.NextID(db.Statement.Context)
+	if err != nil {
+		return err
+	}
+	m.SetID(id)
+	return nil
+}
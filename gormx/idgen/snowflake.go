@@ -0,0 +1,256 @@
+package idgen
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/gormx/config"
+	"github.com/LouYuanbo1/go-webservice/gormx/errors"
+	"github.com/LouYuanbo1/go-webservice/redisx"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	workerIDBits   = 10
+	sequenceBits   = 12
+	timestampShift = workerIDBits + sequenceBits
+	maxSequence    = 1<<sequenceBits - 1
+
+	// MaxWorkerID 是可用的最大 worker ID
+	MaxWorkerID = 1<<workerIDBits - 1
+)
+
+// DefaultEpoch 是默认纪元, 同一集群的所有实例必须使用相同的纪元
+var DefaultEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const (
+	defaultMaxClockBackward = 10 * time.Millisecond
+	defaultLeaseKeyPrefix   = "gormx:idgen:worker"
+	defaultLeaseTTL         = 30 * time.Second
+)
+
+type snowflakeOptions struct {
+	epoch            time.Time
+	maxClockBackward time.Duration
+	leaseKeyPrefix   string
+	leaseTTL         time.Duration
+}
+
+type SnowflakeOption func(*snowflakeOptions)
+
+// WithEpoch 设置纪元
+func WithEpoch(epoch time.Time) SnowflakeOption {
+	return func(o *snowflakeOptions) {
+		o.epoch = epoch
+	}
+}
+
+// WithMaxClockBackward 设置可等待的最大时钟回拨, 超过后 NextID 返回 ErrClockMovedBackwards
+func WithMaxClockBackward(d time.Duration) SnowflakeOption {
+	return func(o *snowflakeOptions) {
+		o.maxClockBackward = d
+	}
+}
+
+// WithLeaseKeyPrefix 设置 worker ID 租约的 key 前缀, 仅用于 NewSnowflakeWithLease
+func WithLeaseKeyPrefix(prefix string) SnowflakeOption {
+	return func(o *snowflakeOptions) {
+		o.leaseKeyPrefix = prefix
+	}
+}
+
+// WithLeaseTTL 设置 worker ID 租约的有效期, 仅用于 NewSnowflakeWithLease
+func WithLeaseTTL(ttl time.Duration) SnowflakeOption {
+	return func(o *snowflakeOptions) {
+		o.leaseTTL = ttl
+	}
+}
+
+func newSnowflakeOptions(opts ...SnowflakeOption) *snowflakeOptions {
+	o := &snowflakeOptions{
+		epoch:            DefaultEpoch,
+		maxClockBackward: defaultMaxClockBackward,
+		leaseKeyPrefix:   defaultLeaseKeyPrefix,
+		leaseTTL:         defaultLeaseTTL,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Snowflake 生成按时间递增的 64 位 ID, 实现 Generator[uint64]
+type Snowflake struct {
+	mu               sync.Mutex
+	epoch            time.Time
+	workerID         int64
+	maxClockBackward time.Duration
+	lastMillis       int64
+	sequence         int64
+	lease            redisx.Lease
+}
+
+// NewSnowflake 使用固定的 worker ID 创建生成器, 由调用方保证集群内 worker ID 唯一
+func NewSnowflake(workerID int64, opts ...SnowflakeOption) (*Snowflake, error) {
+	return newSnowflake(workerID, newSnowflakeOptions(opts...))
+}
+
+/*
+NewSnowflakeWithLease 通过 Redis 租约分配 worker ID 创建生成器.
+租约丢失(例如 Redis 长时间不可用)后 NextID 返回 ErrWorkerLeaseLost, 需要重新创建生成器.
+不再使用时调用 Close 释放 worker ID.
+*/
+func NewSnowflakeWithLease(ctx context.Context, client redis.UniversalClient, opts ...SnowflakeOption) (*Snowflake, error) {
+	o := newSnowflakeOptions(opts...)
+	lease, err := redisx.AcquireLease(ctx, client, o.leaseKeyPrefix, MaxWorkerID+1, o.leaseTTL)
+	if err != nil {
+		return nil, errors.New(errors.ErrInvalidWorkerID, "NewSnowflakeWithLease", "", err)
+	}
+	s, err := newSnowflake(lease.Slot(), o)
+	if err != nil {
+		_ = lease.Release(ctx)
+		return nil, err
+	}
+	s.lease = lease
+	return s, nil
+}
+
+// NewSnowflakeFromConfig 根据配置创建生成器, WorkerIDSource 为 "redis" 时 client 不能为 nil
+func NewSnowflakeFromConfig(ctx context.Context, cfg *config.IDGenConfig, client redis.UniversalClient) (*Snowflake, error) {
+	if cfg == nil {
+		return nil, errors.New(errors.ErrInvalidInitConfig, "NewSnowflakeFromConfig", "", fmt.Errorf("IDGenConfig cannot be nil"))
+	}
+
+	var opts []SnowflakeOption
+	if cfg.Epoch != "" {
+		epoch, err := time.Parse(time.RFC3339, cfg.Epoch)
+		if err != nil {
+			return nil, errors.New(errors.ErrInvalidInitConfig, "NewSnowflakeFromConfig", "", fmt.Errorf("parse epoch: %w", err))
+		}
+		opts = append(opts, WithEpoch(epoch))
+	}
+	if cfg.MaxClockBackward != "" {
+		d, err := time.ParseDuration(cfg.MaxClockBackward)
+		if err != nil {
+			return nil, errors.New(errors.ErrInvalidInitConfig, "NewSnowflakeFromConfig", "", fmt.Errorf("parse max_clock_backward: %w", err))
+		}
+		opts = append(opts, WithMaxClockBackward(d))
+	}
+	if cfg.LeaseKeyPrefix != "" {
+		opts = append(opts, WithLeaseKeyPrefix(cfg.LeaseKeyPrefix))
+	}
+	if cfg.LeaseTTL != "" {
+		d, err := time.ParseDuration(cfg.LeaseTTL)
+		if err != nil {
+			return nil, errors.New(errors.ErrInvalidInitConfig, "NewSnowflakeFromConfig", "", fmt.Errorf("parse lease_ttl: %w", err))
+		}
+		opts = append(opts, WithLeaseTTL(d))
+	}
+
+	switch cfg.WorkerIDSource {
+	case "", "config":
+		return NewSnowflake(cfg.WorkerID, opts...)
+	case "redis":
+		if client == nil {
+			return nil, errors.New(errors.ErrInvalidInitConfig, "NewSnowflakeFromConfig", "", fmt.Errorf("redis client is required when worker_id_source is redis"))
+		}
+		return NewSnowflakeWithLease(ctx, client, opts...)
+	default:
+		return nil, errors.New(errors.ErrInvalidInitConfig, "NewSnowflakeFromConfig", "", fmt.Errorf("unsupported worker_id_source %q", cfg.WorkerIDSource))
+	}
+}
+
+func newSnowflake(workerID int64, o *snowflakeOptions) (*Snowflake, error) {
+	if workerID < 0 || workerID > MaxWorkerID {
+		return nil, errors.New(errors.ErrInvalidWorkerID, "NewSnowflake", "", fmt.Errorf("worker id %d out of range [0, %d]", workerID, MaxWorkerID))
+	}
+	if o.epoch.After(time.Now()) {
+		return nil, errors.New(errors.ErrInvalidInitConfig, "NewSnowflake", "", fmt.Errorf("epoch %s is in the future", o.epoch))
+	}
+	return &Snowflake{
+		epoch:            o.epoch,
+		workerID:         workerID,
+		maxClockBackward: o.maxClockBackward,
+	}, nil
+}
+
+// WorkerID 返回当前使用的 worker ID
+func (s *Snowflake) WorkerID() int64 {
+	return s.workerID
+}
+
+// NextID 生成下一个 ID, 同一毫秒内序列号用尽时等待下一毫秒
+func (s *Snowflake) NextID(ctx context.Context) (uint64, error) {
+	if s.lease != nil {
+		select {
+		case <-s.lease.Lost():
+			return 0, errors.New(errors.ErrWorkerLeaseLost, "NextID", "", fmt.Errorf("worker id %d", s.workerID))
+		default:
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		now := s.millis()
+		if now < s.lastMillis {
+			backward := time.Duration(s.lastMillis-now) * time.Millisecond
+			if backward > s.maxClockBackward {
+				return 0, errors.New(errors.ErrClockMovedBackwards, "NextID", "", fmt.Errorf("clock moved backwards by %s", backward))
+			}
+			// 小幅回拨时等待时钟追上上一次的时间戳
+			if err := sleep(ctx, backward); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		if now == s.lastMillis {
+			if s.sequence == maxSequence {
+				// 当前毫秒的序列号已用尽
+				if err := sleep(ctx, 100*time.Microsecond); err != nil {
+					return 0, err
+				}
+				continue
+			}
+			s.sequence++
+		} else {
+			s.sequence = 0
+		}
+		s.lastMillis = now
+		return uint64(now)<<timestampShift | uint64(s.workerID)<<sequenceBits | uint64(s.sequence), nil
+	}
+}
+
+// Decompose 拆解 ID 为生成时间, worker ID 与序列号
+func (s *Snowflake) Decompose(id uint64) (time.Time, int64, int64) {
+	millis := int64(id >> timestampShift)
+	workerID := int64(id>>sequenceBits) & MaxWorkerID
+	sequence := int64(id) & maxSequence
+	return s.epoch.Add(time.Duration(millis) * time.Millisecond), workerID, sequence
+}
+
+// Close 释放 Redis 租约, 使用固定 worker ID 时无操作
+func (s *Snowflake) Close(ctx context.Context) error {
+	if s.lease == nil {
+		return nil
+	}
+	return s.lease.Release(ctx)
+}
+
+func (s *Snowflake) millis() int64 {
+	return time.Now().Sub(s.epoch).Milliseconds()
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,47 @@
+package idgen
+
+import (
+	"context"
+	"crypto/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+)
+
+// ULID 生成 26 位 Crockford Base32 编码的 ULID, 同一毫秒内单调递增, 实现 Generator[string]
+type ULID struct {
+	mu      sync.Mutex
+	entropy *ulid.MonotonicEntropy
+}
+
+func NewULID() *ULID {
+	return &ULID{entropy: ulid.Monotonic(rand.Reader, 0)}
+}
+
+func (g *ULID) NextID(ctx context.Context) (string, error) {
+	// MonotonicEntropy 不是并发安全的
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	id, err := ulid.New(ulid.Timestamp(time.Now()), g.entropy)
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// UUIDv7 生成 RFC 9562 UUIDv7 字符串, 按时间有序, 实现 Generator[string]
+type UUIDv7 struct{}
+
+func NewUUIDv7() *UUIDv7 {
+	return &UUIDv7{}
+}
+
+func (g *UUIDv7) NextID(ctx context.Context) (string, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
@@ -1,91 +1,48 @@
 package gormx
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"time"
 
 	"github.com/LouYuanbo1/go-webservice/gormx/config"
 	"github.com/LouYuanbo1/go-webservice/gormx/errors"
+	"github.com/LouYuanbo1/go-webservice/internal/retry"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-func InitGorm(config *config.DBConfig) (*gorm.DB, error) {
+/*
+InitGorm 创建数据库连接, 配置 Retry 时连接失败会按退避策略重试, 每次尝试都会记录日志.
+ctx 限制包括重试在内的总时长.
+
+InitGorm opens the database described by config, retrying with backoff according to config.Retry.
+*/
+func InitGorm(ctx context.Context, config *config.DBConfig) (*gorm.DB, error) {
 	if config == nil {
 		return nil, errors.ErrInvalidInitConfig
 	}
 
-	var gormDB *gorm.DB
-	var dsn string
-	var err error
+	policy, err := config.Retry.Policy()
+	if err != nil {
+		return nil, errors.New(errors.ErrInvalidInitConfig, "InitGorm", config.DBName, err)
+	}
 
-	// 构建时区参数（默认Local）
-	timeZone := config.TimeZone
-	if timeZone == "" {
-		timeZone = "Asia/Shanghai"
+	// 构建DSN连接字符串, 密码与参数均已转义
+	dsn, err := BuildDSN(config)
+	if err != nil {
+		return nil, err
 	}
 
-	// 构建DSN连接字符串
+	var dialector gorm.Dialector
 	switch config.Type {
 	case "postgres":
-		sslMode := config.Postgres.SSLMode
-		if sslMode == "" {
-			sslMode = "disable"
-		}
-		dsn = fmt.Sprintf(
-			"host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=%s",
-			config.Host,
-			config.User,
-			config.Password,
-			config.DBName,
-			config.Port,
-			sslMode,
-			timeZone,
-		)
-		// 初始化 GORM 数据库连接
-		gormDB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-			Logger: logger.Default.LogMode(logger.LogLevel(config.LogLevel)), // 设置日志模式为 Info（可选 Silent、Warn、Error）
-		})
-		if err != nil {
-			return nil, errors.NewWithDetails(
-				errors.ErrDBConnection,
-				"open postgres db",
-				config.DBName,
-				fmt.Sprintf("host=%s port=%d", config.Host, config.Port),
-				err,
-			)
-		}
+		dialector = postgres.Open(dsn)
 	case "mysql":
-		tls := config.MySQL.TLS
-		if tls == "" {
-			tls = "false"
-		}
-		dsn = fmt.Sprintf(
-			"%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=%s&tls=%s",
-			config.User,
-			config.Password,
-			config.Host,
-			config.Port,
-			config.DBName,
-			timeZone,
-			tls,
-		)
-		// 初始化 GORM 数据库连接
-		gormDB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
-			Logger: logger.Default.LogMode(logger.LogLevel(config.LogLevel)), // 设置日志模式为 Info（可选 Silent、Warn、Error）
-		})
-		if err != nil {
-			return nil, errors.NewWithDetails(
-				errors.ErrDBConnection,
-				"open mysql db",
-				config.DBName,
-				fmt.Sprintf("host=%s port=%d", config.Host, config.Port),
-				err,
-			)
-		}
+		dialector = mysql.Open(dsn)
 	default:
 		return nil, errors.NewWithDetails(
 			errors.ErrDBConnection,
@@ -96,10 +53,27 @@ func InitGorm(config *config.DBConfig) (*gorm.DB, error) {
 		)
 	}
 
+	// 初始化 GORM 数据库连接
+	op := fmt.Sprintf("open %s db", config.Type)
+	gormDB, err := openWithRetry(ctx, policy, op, dialector, config)
+	if err != nil {
+		return nil, errors.NewWithDetails(
+			errors.ErrDBConnection,
+			op,
+			config.DBName,
+			fmt.Sprintf("host=%s port=%d", config.Host, config.Port),
+			err,
+		)
+	}
+
+	// openWithRetry 已经取得过底层的 sql.DB, 此处不会失败. 之后的错误需要关闭连接池
+	sqlDB, _ := gormDB.DB()
+
 	// 读取文件内容
 	if config.SchemaFile != "" {
 		content, err := os.ReadFile(config.SchemaFile)
 		if err != nil {
+			_ = sqlDB.Close()
 			return nil, errors.NewWithDetails(
 				errors.ErrExecutionSQLScript,
 				"read schema file",
@@ -111,7 +85,8 @@ func InitGorm(config *config.DBConfig) (*gorm.DB, error) {
 
 		// 将读取到的内容转换为字符串后执行
 		sql := string(content)
-		if err := gormDB.Exec(sql).Error; err != nil {
+		if err := gormDB.WithContext(ctx).Exec(sql).Error; err != nil {
+			_ = sqlDB.Close()
 			return nil, errors.NewWithDetails(
 				errors.ErrExecutionSQLScript,
 				"execute schema file",
@@ -122,18 +97,6 @@ func InitGorm(config *config.DBConfig) (*gorm.DB, error) {
 		}
 	}
 
-	// 获取底层的 sql.DB 实例以配置连接池
-	sqlDB, err := gormDB.DB()
-	if err != nil {
-		return nil, errors.NewWithDetails(
-			errors.ErrDBConnection,
-			"get sql db",
-			config.DBName,
-			fmt.Sprintf("host=%s port=%d", config.Host, config.Port),
-			err,
-		)
-	}
-
 	// 配置连接池（带默认值逻辑）
 	if config.MaxOpenConns > 0 {
 		sqlDB.SetMaxOpenConns(config.MaxOpenConns)
@@ -154,17 +117,32 @@ func InitGorm(config *config.DBConfig) (*gorm.DB, error) {
 		sqlDB.SetConnMaxLifetime(5 * time.Minute) // 默认值
 	}
 
-	// 验证连接有效性
-	if err := sqlDB.Ping(); err != nil {
-		return nil, errors.NewWithDetails(
-			errors.ErrDBConnection,
-			"ping db",
-			config.DBName,
-			fmt.Sprintf("host=%s port=%d", config.Host, config.Port),
-			err,
-		)
-	}
-
 	fmt.Println("ConnectGormDB successfully. 成功连接到数据库。")
 	return gormDB, nil
 }
+
+// openWithRetry 打开连接并在 ctx 下 ping 数据库, 失败时关闭连接池并按策略重试
+func openWithRetry(ctx context.Context, policy retry.Policy, name string, dialector gorm.Dialector, config *config.DBConfig) (*gorm.DB, error) {
+	var gormDB *gorm.DB
+	err := retry.Do(ctx, name, policy, func(ctx context.Context) error {
+		db, err := gorm.Open(dialector, &gorm.Config{
+			Logger: logger.Default.LogMode(logger.LogLevel(config.LogLevel)), // 设置日志模式为 Info（可选 Silent、Warn、Error）
+			// 由下方的 PingContext 验证连接, 使 ping 受 ctx 控制
+			DisableAutomaticPing: true,
+		})
+		if err != nil {
+			return err
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		if err := sqlDB.PingContext(ctx); err != nil {
+			_ = sqlDB.Close()
+			return err
+		}
+		gormDB = db
+		return nil
+	})
+	return gormDB, err
+}
@@ -0,0 +1,258 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/gormx/errors"
+	"github.com/LouYuanbo1/go-webservice/gormx/options"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// maxBindVars 是单条语句使用的占位符上限, MySQL 与 Postgres 均限制为 65535, 预留一部分给其他条件
+const maxBindVars = 65000
+
+var deletedAtType = reflect.TypeFor[gorm.DeletedAt]()
+
+// chunkSize 返回每批的行数, 保证每条语句的占位符数量不超过 maxBindVars
+func chunkSize(batchSize, varsPerRow int) int {
+	limit := max(maxBindVars/max(varsPerRow, 1), 1)
+	if batchSize <= 0 || batchSize > limit {
+		return limit
+	}
+	return batchSize
+}
+
+/*
+UpdateInBatches 按主键批量更新每个模型 fields 指定的列(包括零值), 每批只执行一条语句:
+  - Postgres: UPDATE ... FROM (VALUES ...) 连接
+  - 其他方言: SET col = CASE WHEN pk = ? THEN ? ... ELSE col END
+
+未选中的 UpdatedAt 会被刷新并写回模型. 所有批次在同一事务中执行, 返回受影响的总行数.
+注意 MySQL 默认只统计值发生变化的行.
+*/
+func (gx *gormX[T, ID, PT]) UpdateInBatches(ctx context.Context, models []PT, fields []string, batchSize int, opts ...options.MutationOption) (Result, error) {
+	if batchSize <= 0 {
+		gx.logf("update in batches failed : %s", errors.WarnInvalidBatchSize)
+		return Result{}, nil
+	}
+	if len(models) == 0 {
+		gx.logf("skipped update in batches: %s", errors.WarnEmptyModelsSlice)
+		return Result{}, nil
+	}
+	if len(fields) == 0 {
+		gx.logf("update in batches failed : %s", errors.WarnEmptyFields)
+		return Result{}, nil
+	}
+
+	var model T
+	tableName := PT(&model).TableName()
+	s, err := gx.parseSchema()
+	if err != nil {
+		return Result{}, errors.New(errors.ErrUpdateFailed, "UpdateInBatches", tableName, err)
+	}
+	schemaFields, err := ResolveFields("UpdateInBatches", tableName, s, fields)
+	if err != nil {
+		gx.logf("update in batches failed. table: %s, error: %v", tableName, err)
+		return Result{}, err
+	}
+	// 只校验被更新的字段
+	if err := ValidateFieldsBatch("UpdateInBatches", tableName, models, schemaFields); err != nil {
+		gx.logf("update in batches failed. table: %s, error: %v", tableName, err)
+		return Result{}, err
+	}
+	keyFields, err := ResolveFields("UpdateInBatches", tableName, s, gx.keyColumns())
+	if err != nil {
+		return Result{}, errors.New(errors.ErrUpdateFailed, "UpdateInBatches", tableName, err)
+	}
+
+	// 主键只用于定位行, 不参与更新
+	schemaFields = slices.DeleteFunc(schemaFields, func(field *schema.Field) bool { return field.PrimaryKey })
+	var touched []*schema.Field
+	for _, field := range s.Fields {
+		if field.AutoUpdateTime > 0 && !slices.Contains(schemaFields, field) {
+			touched = append(touched, field)
+		}
+	}
+	schemaFields = append(schemaFields, touched...)
+	if len(schemaFields) == 0 {
+		gx.logf("update in batches failed : %s", errors.WarnEmptyFields)
+		return Result{}, nil
+	}
+
+	now := time.Now()
+	for i, m := range models {
+		if m == nil {
+			return Result{}, errors.New(errors.ErrUpdateFailed, "UpdateInBatches", tableName, fmt.Errorf("models[%d]: %s", i, errors.WarnInvalidModel))
+		}
+		rv := reflect.ValueOf(m)
+		for _, field := range keyFields {
+			if _, isZero := field.ValueOf(ctx, rv); isZero {
+				return Result{}, errors.New(errors.ErrUpdateFailed, "UpdateInBatches", tableName, fmt.Errorf("models[%d]: %w", i, gorm.ErrPrimaryKeyRequired))
+			}
+		}
+		for _, field := range touched {
+			if err := field.Set(ctx, rv, now); err != nil {
+				return Result{}, errors.New(errors.ErrUpdateFailed, "UpdateInBatches", tableName, err)
+			}
+		}
+	}
+
+	// 每行占用: 每列 (主键 + 值) 个占位符, 以及 WHERE 中的主键
+	size := chunkSize(batchSize, (len(keyFields)+1)*len(schemaFields)+len(keyFields))
+	mutation := options.NewMutationWithOptions(opts...)
+	var rowsAffected int64
+	err = gx.GetDBWithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for chunk := range slices.Chunk(models, size) {
+			var result *gorm.DB
+			if tx.Dialector.Name() == "postgres" {
+				sql, vars := gx.valuesUpdateBuilder(ctx, tx, s, keyFields, schemaFields, chunk)
+				result = tx.Exec(sql, vars...)
+			} else {
+				result = tx.Model(PT(&model)).
+					Where(gx.clauseKeyInBuilder(gx.idsOf(chunk))).
+					Updates(gx.caseUpdateBuilder(ctx, keyFields, schemaFields, chunk))
+			}
+			if result.Error != nil {
+				return result.Error
+			}
+			rowsAffected += result.RowsAffected
+		}
+		// 在事务内检查受影响行数, 不满足时回滚所有分块
+		return mutation.Check("UpdateInBatches", tableName, rowsAffected)
+	})
+	if err != nil {
+		// 行数检查失败时所有分块已回滚
+		if isRowsAffectedCheck(err) {
+			return Result{}, err
+		}
+		gx.logf("update in batches failed. table: %s, error: %v", tableName, err)
+		return Result{}, errors.New(
+			errors.ErrUpdateFailed,
+			"UpdateInBatches",
+			tableName,
+			err,
+		)
+	}
+	if rowsAffected == 0 {
+		gx.logf("update in batches failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+	}
+	return Result{RowsAffected: rowsAffected}, nil
+}
+
+func (gx *gormX[T, ID, PT]) idsOf(models []PT) []ID {
+	ids := make([]ID, len(models))
+	for i, m := range models {
+		ids[i] = m.GetID()
+	}
+	return ids
+}
+
+// caseUpdateBuilder 为每列构建 CASE WHEN pk = ? THEN ? ... ELSE col END 表达式
+func (gx *gormX[T, ID, PT]) caseUpdateBuilder(ctx context.Context, keyFields, fields []*schema.Field, models []PT) map[string]any {
+	updates := make(map[string]any, len(fields))
+	for _, field := range fields {
+		var sql strings.Builder
+		vars := make([]any, 0, len(models)*(len(keyFields)*2+1)+1)
+		sql.WriteString("CASE")
+		for _, m := range models {
+			rv := reflect.ValueOf(m)
+			sql.WriteString(" WHEN ")
+			for i, key := range keyFields {
+				if i > 0 {
+					sql.WriteString(" AND ")
+				}
+				sql.WriteString("? = ?")
+				value, _ := key.ValueOf(ctx, rv)
+				vars = append(vars, clause.Column{Name: key.DBName}, value)
+			}
+			sql.WriteString(" THEN ?")
+			value, _ := field.ValueOf(ctx, rv)
+			vars = append(vars, value)
+		}
+		sql.WriteString(" ELSE ? END")
+		vars = append(vars, clause.Column{Name: field.DBName})
+		updates[field.DBName] = gorm.Expr(sql.String(), vars...)
+	}
+	return updates
+}
+
+// valuesUpdateBuilder 构建 Postgres 的 UPDATE ... FROM (VALUES ...) 语句, 值显式转换为列类型
+func (gx *gormX[T, ID, PT]) valuesUpdateBuilder(ctx context.Context, tx *gorm.DB, s *schema.Schema, keyFields, fields []*schema.Field, models []PT) (string, []any) {
+	const target, source = "_gormx_t", "_gormx_v"
+	columns := append(slices.Clip(keyFields), fields...)
+	quote := tx.Statement.Quote
+
+	var sql strings.Builder
+	vars := make([]any, 0, len(models)*len(columns))
+	table := s.Table
+	if tx.Statement.Table != "" {
+		// 分表时使用 GetDBWithContext 指定的物理表
+		table = tx.Statement.Table
+	}
+	fmt.Fprintf(&sql, "UPDATE %s AS %s SET ", quote(table), quote(target))
+	for i, field := range fields {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		fmt.Fprintf(&sql, "%s = %s.%s", quote(field.DBName), quote(source), quote(field.DBName))
+	}
+	sql.WriteString(" FROM (VALUES ")
+	for i, m := range models {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		rv := reflect.ValueOf(m)
+		sql.WriteString("(")
+		for j, field := range columns {
+			if j > 0 {
+				sql.WriteString(", ")
+			}
+			fmt.Fprintf(&sql, "CAST(? AS %s)", castType(tx, field))
+			value, _ := field.ValueOf(ctx, rv)
+			vars = append(vars, value)
+		}
+		sql.WriteString(")")
+	}
+	fmt.Fprintf(&sql, ") AS %s (", quote(source))
+	for i, field := range columns {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		sql.WriteString(quote(field.DBName))
+	}
+	sql.WriteString(") WHERE ")
+	for i, key := range keyFields {
+		if i > 0 {
+			sql.WriteString(" AND ")
+		}
+		fmt.Fprintf(&sql, "%s.%s = %s.%s", quote(target), quote(key.DBName), quote(source), quote(key.DBName))
+	}
+	for _, field := range s.Fields {
+		// 与 gorm 软删除一致, 跳过已删除的行
+		if field.DBName != "" && field.FieldType == deletedAtType {
+			fmt.Fprintf(&sql, " AND %s.%s IS NULL", quote(target), quote(field.DBName))
+		}
+	}
+	return sql.String(), vars
+}
+
+// castType 返回列在方言中的类型, 自增类型 serial 只能用于建表, 转换时替换为对应整数类型
+func castType(tx *gorm.DB, field *schema.Field) string {
+	dataType := tx.Dialector.DataTypeOf(field)
+	switch strings.ToLower(dataType) {
+	case "smallserial":
+		return "smallint"
+	case "serial":
+		return "integer"
+	case "bigserial":
+		return "bigint"
+	}
+	return dataType
+}
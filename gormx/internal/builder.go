@@ -1,16 +1,86 @@
 package internal
 
 import (
+	"github.com/LouYuanbo1/go-webservice/gormx/model"
 	"github.com/LouYuanbo1/go-webservice/gormx/options"
 	"gorm.io/gorm/clause"
 )
 
 func (gx *gormX[T, ID, PT]) clauseOnConflictBuilder(opts ...options.ConflictOption) (*clause.OnConflict, error) {
-	conflict := options.NewConflictWithOptions(opts...)
+	// 默认以主键(含复合主键)作为冲突约束列, 用户传入的选项可覆盖
+	defaults := []options.ConflictOption{options.OnConstraintColumns(gx.keyColumns()...)}
+	conflict := options.NewConflictWithOptions(append(defaults, opts...)...)
 	return conflict.Build()
 }
 
-func (gx *gormX[T, ID, PT]) clauseOrderBuilder(opts ...options.OrderOption) *clause.OrderBy {
+// clauseOrderBuilder 构建排序子句, 未指定任何排序列时按主键升序.
+// 注意 gorm 的 Order() 只接受 clause.OrderBy 值类型, 传入指针会被静默忽略.
+func (gx *gormX[T, ID, PT]) clauseOrderBuilder(opts ...options.OrderOption) clause.OrderBy {
 	order := options.NewOrderWithOptions(opts...)
-	return order.Build()
-}
\ No newline at end of file
+	orderBy := order.Build()
+	if orderBy == nil {
+		return gx.clauseKeyOrderBuilder()
+	}
+	return *orderBy
+}
+
+// keyColumns 返回主键列名, 复合主键返回多列
+func (gx *gormX[T, ID, PT]) keyColumns() []string {
+	var m T
+	return model.KeyColumns[ID](PT(&m))
+}
+
+// clauseKeyEqBuilder 构建 pk = ? 条件, 复合主键构建 k1 = ? AND k2 = ?
+func (gx *gormX[T, ID, PT]) clauseKeyEqBuilder(id ID) clause.Expression {
+	columns := gx.keyColumns()
+	values := model.KeyValues(id)
+	exprs := make([]clause.Expression, len(columns))
+	for i, col := range columns {
+		exprs[i] = clause.Eq{Column: clause.Column{Name: col}, Value: values[i]}
+	}
+	return clause.And(exprs...)
+}
+
+// clauseKeyInBuilder 构建 pk IN (?) 条件, 复合主键构建 (k1 = ? AND k2 = ?) OR (...)
+func (gx *gormX[T, ID, PT]) clauseKeyInBuilder(ids []ID) clause.Expression {
+	if !model.IsComposite[ID]() {
+		values := make([]any, len(ids))
+		for i, id := range ids {
+			values[i] = id
+		}
+		return clause.IN{Column: clause.Column{Name: gx.keyColumns()[0]}, Values: values}
+	}
+
+	exprs := make([]clause.Expression, len(ids))
+	for i, id := range ids {
+		exprs[i] = gx.clauseKeyEqBuilder(id)
+	}
+	return clause.Or(exprs...)
+}
+
+// clauseKeyAfterBuilder 构建游标条件 pk > ?,
+// 复合主键按字典序展开为 k1 > ? OR (k1 = ? AND k2 > ?) OR ...
+func (gx *gormX[T, ID, PT]) clauseKeyAfterBuilder(cursor ID) clause.Expression {
+	columns := gx.keyColumns()
+	values := model.KeyValues(cursor)
+	exprs := make([]clause.Expression, len(columns))
+	for i := range columns {
+		conds := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			conds = append(conds, clause.Eq{Column: clause.Column{Name: columns[j]}, Value: values[j]})
+		}
+		conds = append(conds, clause.Gt{Column: clause.Column{Name: columns[i]}, Value: values[i]})
+		exprs[i] = clause.And(conds...)
+	}
+	return clause.Or(exprs...)
+}
+
+// clauseKeyOrderBuilder 按主键升序排序, 复合主键按列顺序依次排序
+func (gx *gormX[T, ID, PT]) clauseKeyOrderBuilder() clause.OrderBy {
+	columns := gx.keyColumns()
+	orderBy := clause.OrderBy{Columns: make([]clause.OrderByColumn, len(columns))}
+	for i, col := range columns {
+		orderBy.Columns[i] = clause.OrderByColumn{Column: clause.Column{Name: col}}
+	}
+	return orderBy
+}
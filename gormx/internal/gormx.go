@@ -2,8 +2,8 @@ package internal
 
 import (
 	"context"
-	"fmt"
 	"log"
+	"slices"
 
 	"github.com/LouYuanbo1/go-webservice/gormx/errors"
 	"github.com/LouYuanbo1/go-webservice/gormx/model"
@@ -13,18 +13,37 @@ import (
 
 type gormX[T any, ID comparable, PT model.PointerModel[T, ID]] struct {
 	db *gorm.DB
+	// 物理表名, 为空时使用模型的 TableName()
+	table string
+	// quiet 为 true 时不记录日志, 分表的各个分片由分表层统一记录
+	quiet bool
 }
 
 func NewGormX[T any, ID comparable, PT model.PointerModel[T, ID]](db *gorm.DB) *gormX[T, ID, PT] {
 	return &gormX[T, ID, PT]{db: db}
 }
 
+// NewTableGormX 创建作用于指定物理表且不记录日志的 gormX, 用于分表
+func NewTableGormX[T any, ID comparable, PT model.PointerModel[T, ID]](db *gorm.DB, table string) *gormX[T, ID, PT] {
+	return &gormX[T, ID, PT]{db: db, table: table, quiet: true}
+}
+
+func (gx *gormX[T, ID, PT]) logf(format string, v ...any) {
+	if !gx.quiet {
+		log.Printf(format, v...)
+	}
+}
+
 func (gx *gormX[T, ID, PT]) GetDBWithContext(ctx context.Context) *gorm.DB {
-	tx, ok := ctx.Value(contextTxKey{}).(*gorm.DB)
-	if !ok {
-		return gx.db.WithContext(ctx)
+	db := gx.db
+	if tx, ok := ctx.Value(contextTxKey{}).(*gorm.DB); ok {
+		db = tx
 	}
-	return tx.WithContext(ctx)
+	db = db.WithContext(ctx)
+	if gx.table != "" {
+		db = db.Table(gx.table)
+	}
+	return db
 }
 
 func (gx *gormX[T, ID, PT]) InTransaction(ctx context.Context) bool {
@@ -32,20 +51,58 @@ func (gx *gormX[T, ID, PT]) InTransaction(ctx context.Context) bool {
 	return ok
 }
 
-func (gx *gormX[T, ID, PT]) Create(ctx context.Context, model PT, opts ...options.ConflictOption) error {
+/*
+execChecked 执行写语句并返回受影响的行数.
+传入行数检查选项时语句在事务中执行(已在事务中时使用保存点), 检查失败时回滚并原样返回检查错误.
+*/
+func (gx *gormX[T, ID, PT]) execChecked(ctx context.Context, op, tableName string, opts []options.MutationOption, exec func(db *gorm.DB) *gorm.DB) (int64, error) {
+	if len(opts) == 0 {
+		result := exec(gx.GetDBWithContext(ctx))
+		return result.RowsAffected, result.Error
+	}
+
+	mutation := options.NewMutationWithOptions(opts...)
+	db := gx.db
+	if tx, ok := ctx.Value(contextTxKey{}).(*gorm.DB); ok {
+		db = tx
+	}
+	var rowsAffected int64
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := exec(gx.GetDBWithContext(context.WithValue(ctx, contextTxKey{}, tx)))
+		if result.Error != nil {
+			return result.Error
+		}
+		rowsAffected = result.RowsAffected
+		return mutation.Check(op, tableName, rowsAffected)
+	})
+	return rowsAffected, err
+}
+
+// isRowsAffectedCheck 判断错误是否来自行数检查, 这类错误不再包装为 ErrUpdateFailed/ErrDeleteFailed
+func isRowsAffectedCheck(err error) bool {
+	return errors.IsNoRowsAffected(err) || errors.IsRowsAffectedMismatch(err)
+}
+
+func (gx *gormX[T, ID, PT]) Create(ctx context.Context, model PT, opts ...options.ConflictOption) (Result, error) {
 	if model == nil {
-		log.Printf("create failed : %s", errors.WarnInvalidModel)
-		return nil
+		gx.logf("create failed : %s", errors.WarnInvalidModel)
+		return Result{}, nil
 	}
 
 	tableName := model.TableName()
+	// 写入前校验模型
+	if err := Validate("Create", tableName, model); err != nil {
+		gx.logf("create failed. table: %s, error: %v", tableName, err)
+		return Result{}, err
+	}
+
 	var result *gorm.DB
 	// 应用冲突选项
 	if len(opts) == 0 {
 		result = gx.GetDBWithContext(ctx).
 			Create(model)
 		if result.Error != nil {
-			return errors.New(
+			return Result{}, errors.New(
 				errors.ErrCreateFailed,
 				"Create",
 				tableName,
@@ -53,14 +110,14 @@ func (gx *gormX[T, ID, PT]) Create(ctx context.Context, model PT, opts ...option
 			)
 		}
 		if result.RowsAffected == 0 {
-			log.Printf("create failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+			gx.logf("create failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
 		}
-		return nil
+		return gx.createResult(ctx, result, model), nil
 	}
 
 	clauseConflict, err := gx.clauseOnConflictBuilder(opts...)
 	if err != nil {
-		return errors.New(
+		return Result{}, errors.New(
 			errors.ErrInvalidOnConflictClause,
 			"Create",
 			tableName,
@@ -69,10 +126,10 @@ func (gx *gormX[T, ID, PT]) Create(ctx context.Context, model PT, opts ...option
 	}
 
 	result = gx.GetDBWithContext(ctx).
-		Clauses(clauseConflict).
+		Clauses(*clauseConflict).
 		Create(model)
 	if result.Error != nil {
-		return errors.New(
+		return Result{}, errors.New(
 			errors.ErrCreateFailed,
 			"Create(Upsert)",
 			tableName,
@@ -80,32 +137,38 @@ func (gx *gormX[T, ID, PT]) Create(ctx context.Context, model PT, opts ...option
 		)
 	}
 	if result.RowsAffected == 0 {
-		log.Printf("create(upsert) failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+		gx.logf("create(upsert) failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
 	}
-	return nil
+	return gx.createResult(ctx, result, model), nil
 }
 
-func (gx *gormX[T, ID, PT]) CreateInBatches(ctx context.Context, models []PT, batchSize int, opts ...options.ConflictOption) error {
+func (gx *gormX[T, ID, PT]) CreateInBatches(ctx context.Context, models []PT, batchSize int, opts ...options.ConflictOption) (Result, error) {
 	// 参数校验
 	if batchSize <= 0 {
-		log.Printf("create in batches failed : %s", errors.WarnInvalidBatchSize)
-		return nil
+		gx.logf("create in batches failed : %s", errors.WarnInvalidBatchSize)
+		return Result{}, nil
 	}
 	if len(models) == 0 {
 		// 空切片属于合法操作（0 行插入），静默成功更符合批量操作语义
-		log.Printf("skipped create in batches: %s", errors.WarnEmptyModelsSlice)
-		return nil
+		gx.logf("skipped create in batches: %s", errors.WarnEmptyModelsSlice)
+		return Result{}, nil
 	}
 
 	tableName := models[0].TableName()
+	// 写入前校验模型
+	if err := ValidateBatch("CreateInBatches", tableName, models); err != nil {
+		gx.logf("create in batches failed. table: %s, error: %v", tableName, err)
+		return Result{}, err
+	}
+
 	var result *gorm.DB
 
 	if len(opts) == 0 {
 		result = gx.GetDBWithContext(ctx).
 			CreateInBatches(models, batchSize)
 		if result.Error != nil {
-			log.Printf("create in batches failed. table: %s, error: %v", tableName, result.Error)
-			return errors.New(
+			gx.logf("create in batches failed. table: %s, error: %v", tableName, result.Error)
+			return Result{}, errors.New(
 				errors.ErrCreateFailed,
 				"CreateInBatches",
 				tableName,
@@ -113,15 +176,15 @@ func (gx *gormX[T, ID, PT]) CreateInBatches(ctx context.Context, models []PT, ba
 			)
 		}
 		if result.RowsAffected == 0 {
-			log.Printf("create in batches failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+			gx.logf("create in batches failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
 		}
-		return nil
+		return gx.createResult(ctx, result, models...), nil
 	}
 
 	// 应用冲突选项
 	clauseConflict, err := gx.clauseOnConflictBuilder(opts...)
 	if err != nil {
-		return errors.New(
+		return Result{}, errors.New(
 			errors.ErrInvalidOnConflictClause,
 			"CreateInBatches",
 			tableName,
@@ -130,11 +193,11 @@ func (gx *gormX[T, ID, PT]) CreateInBatches(ctx context.Context, models []PT, ba
 	}
 
 	result = gx.GetDBWithContext(ctx).
-		Clauses(clauseConflict).
+		Clauses(*clauseConflict).
 		CreateInBatches(models, batchSize)
 	if result.Error != nil {
-		log.Printf("create(upsert) in batches failed. table: %s, error: %v", tableName, result.Error)
-		return errors.New(
+		gx.logf("create(upsert) in batches failed. table: %s, error: %v", tableName, result.Error)
+		return Result{}, errors.New(
 			errors.ErrCreateFailed,
 			"CreateInBatches(Upsert)",
 			tableName,
@@ -142,15 +205,15 @@ func (gx *gormX[T, ID, PT]) CreateInBatches(ctx context.Context, models []PT, ba
 		)
 	}
 	if result.RowsAffected == 0 {
-		log.Printf("create in batches failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+		gx.logf("create in batches failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
 	}
-	return nil
+	return gx.createResult(ctx, result, models...), nil
 }
 
 func (gx *gormX[T, ID, PT]) GetByID(ctx context.Context, id ID) (PT, error) {
 
 	if model.IsZero(id) {
-		log.Printf("get by id failed : %s", errors.WarnInvalidID)
+		gx.logf("get by id failed : %s", errors.WarnInvalidID)
 		return nil, nil
 	}
 
@@ -159,9 +222,10 @@ func (gx *gormX[T, ID, PT]) GetByID(ctx context.Context, id ID) (PT, error) {
 	tableName := ptr.TableName()
 
 	result := gx.GetDBWithContext(ctx).
-		First(ptr, id)
+		Where(gx.clauseKeyEqBuilder(id)).
+		First(ptr)
 	if result.Error != nil {
-		log.Printf("get by id failed. table: %s, error: %v", tableName, result.Error)
+		gx.logf("get by id failed. table: %s, error: %v", tableName, result.Error)
 		return nil, errors.New(
 			errors.ErrQueryFailed,
 			"GetByID",
@@ -170,14 +234,14 @@ func (gx *gormX[T, ID, PT]) GetByID(ctx context.Context, id ID) (PT, error) {
 		)
 	}
 	if result.RowsAffected == 0 {
-		log.Printf("get by id failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+		gx.logf("get by id failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
 	}
 	return ptr, nil
 }
 
 func (gx *gormX[T, ID, PT]) FindByIDs(ctx context.Context, ids []ID, opts ...options.OrderOption) ([]PT, error) {
 	if len(ids) == 0 {
-		log.Printf("find by ids failed : %s", errors.WarnEmptyIDsSlice)
+		gx.logf("find by ids failed : %s", errors.WarnEmptyIDsSlice)
 		return nil, nil
 	}
 
@@ -190,9 +254,10 @@ func (gx *gormX[T, ID, PT]) FindByIDs(ctx context.Context, ids []ID, opts ...opt
 	if len(opts) == 0 {
 
 		result = gx.GetDBWithContext(ctx).
-			Find(&ptrModels, ids)
+			Where(gx.clauseKeyInBuilder(ids)).
+			Find(&ptrModels)
 		if result.Error != nil {
-			log.Printf("find by ids failed. table: %s, error: %v", tableName, result.Error)
+			gx.logf("find by ids failed. table: %s, error: %v", tableName, result.Error)
 			return nil, errors.New(
 				errors.ErrQueryFailed,
 				"FindByIDs",
@@ -201,7 +266,7 @@ func (gx *gormX[T, ID, PT]) FindByIDs(ctx context.Context, ids []ID, opts ...opt
 			)
 		}
 		if result.RowsAffected == 0 {
-			log.Printf("find by ids failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+			gx.logf("find by ids failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
 		}
 
 		return ptrModels, nil
@@ -210,10 +275,11 @@ func (gx *gormX[T, ID, PT]) FindByIDs(ctx context.Context, ids []ID, opts ...opt
 	clauseOrder := gx.clauseOrderBuilder(opts...)
 
 	result = gx.GetDBWithContext(ctx).
+		Where(gx.clauseKeyInBuilder(ids)).
 		Order(clauseOrder).
-		Find(&ptrModels, ids)
+		Find(&ptrModels)
 	if result.Error != nil {
-		log.Printf("find by ids failed. table: %s, error: %v", tableName, result.Error)
+		gx.logf("find by ids failed. table: %s, error: %v", tableName, result.Error)
 		return nil, errors.New(
 			errors.ErrQueryFailed,
 			"FindByIDs(Order)",
@@ -222,7 +288,7 @@ func (gx *gormX[T, ID, PT]) FindByIDs(ctx context.Context, ids []ID, opts ...opt
 		)
 	}
 	if result.RowsAffected == 0 {
-		log.Printf("find by ids (order) failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+		gx.logf("find by ids (order) failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
 	}
 
 	return ptrModels, nil
@@ -230,7 +296,7 @@ func (gx *gormX[T, ID, PT]) FindByIDs(ctx context.Context, ids []ID, opts ...opt
 
 func (gx *gormX[T, ID, PT]) GetByStructFilter(ctx context.Context, filter PT) (PT, error) {
 	if filter == nil {
-		log.Printf("get by struct filter failed : %s", errors.WarnInvalidFilter)
+		gx.logf("get by struct filter failed : %s", errors.WarnInvalidFilter)
 		return nil, nil
 	}
 
@@ -242,7 +308,7 @@ func (gx *gormX[T, ID, PT]) GetByStructFilter(ctx context.Context, filter PT) (P
 		Where(filter).
 		First(ptrModel)
 	if result.Error != nil {
-		log.Printf("get by struct filter failed. table: %s, error: %v", tableName, result.Error)
+		gx.logf("get by struct filter failed. table: %s, error: %v", tableName, result.Error)
 		return nil, errors.New(
 			errors.ErrQueryFailed,
 			"GetByStructFilter",
@@ -251,14 +317,14 @@ func (gx *gormX[T, ID, PT]) GetByStructFilter(ctx context.Context, filter PT) (P
 		)
 	}
 	if result.RowsAffected == 0 {
-		log.Printf("get by struct filter failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+		gx.logf("get by struct filter failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
 	}
 	return ptrModel, nil
 }
 
 func (gx *gormX[T, ID, PT]) FindByStructFilter(ctx context.Context, filter PT, opts ...options.OrderOption) ([]PT, error) {
 	if filter == nil {
-		log.Printf("find by struct filter failed : %s", errors.WarnInvalidFilter)
+		gx.logf("find by struct filter failed : %s", errors.WarnInvalidFilter)
 		return nil, nil
 	}
 
@@ -272,7 +338,7 @@ func (gx *gormX[T, ID, PT]) FindByStructFilter(ctx context.Context, filter PT, o
 			Where(filter).
 			Find(&ptrModels)
 		if result.Error != nil {
-			log.Printf("find by struct filter failed. table: %s, error: %v", tableName, result.Error)
+			gx.logf("find by struct filter failed. table: %s, error: %v", tableName, result.Error)
 			return nil, errors.New(
 				errors.ErrQueryFailed,
 				"FindByStructFilter",
@@ -281,7 +347,7 @@ func (gx *gormX[T, ID, PT]) FindByStructFilter(ctx context.Context, filter PT, o
 			)
 		}
 		if result.RowsAffected == 0 {
-			log.Printf("find by struct filter failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+			gx.logf("find by struct filter failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
 		}
 
 		return ptrModels, nil
@@ -294,7 +360,7 @@ func (gx *gormX[T, ID, PT]) FindByStructFilter(ctx context.Context, filter PT, o
 		Order(clauseOrder).
 		Find(&ptrModels)
 	if result.Error != nil {
-		log.Printf("find by struct filter (order) failed. table: %s, error: %v", tableName, result.Error)
+		gx.logf("find by struct filter (order) failed. table: %s, error: %v", tableName, result.Error)
 		return nil, errors.New(
 			errors.ErrQueryFailed,
 			"FindByStructFilter(Order)",
@@ -303,7 +369,7 @@ func (gx *gormX[T, ID, PT]) FindByStructFilter(ctx context.Context, filter PT, o
 		)
 	}
 	if result.RowsAffected == 0 {
-		log.Printf("find by ids (order) failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+		gx.logf("find by ids (order) failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
 	}
 
 	return ptrModels, nil
@@ -311,11 +377,11 @@ func (gx *gormX[T, ID, PT]) FindByStructFilter(ctx context.Context, filter PT, o
 
 func (gx *gormX[T, ID, PT]) GetByMapFilter(ctx context.Context, filter map[string]any) (PT, error) {
 	if filter == nil {
-		log.Printf("get by map filter failed : %s", errors.WarnInvalidFilter)
+		gx.logf("get by map filter failed : %s", errors.WarnInvalidFilter)
 		return nil, nil
 	}
 	if len(filter) == 0 {
-		log.Printf("get by map filter failed : %s", errors.WarnInvalidFilter)
+		gx.logf("get by map filter failed : %s", errors.WarnInvalidFilter)
 		return nil, nil
 	}
 
@@ -324,10 +390,10 @@ func (gx *gormX[T, ID, PT]) GetByMapFilter(ctx context.Context, filter map[strin
 	tableName := ptrModel.TableName()
 
 	result := gx.GetDBWithContext(ctx).
-		Where(filter).
+		Scopes(whereMapFilter(filter)).
 		First(ptrModel)
 	if result.Error != nil {
-		log.Printf("get by map filter failed. table: %s, error: %v", tableName, result.Error)
+		gx.logf("get by map filter failed. table: %s, error: %v", tableName, result.Error)
 		return nil, errors.New(
 			errors.ErrQueryFailed,
 			"GetByMapFilter",
@@ -336,18 +402,18 @@ func (gx *gormX[T, ID, PT]) GetByMapFilter(ctx context.Context, filter map[strin
 		)
 	}
 	if result.RowsAffected == 0 {
-		log.Printf("get by map filter failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+		gx.logf("get by map filter failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
 	}
 	return ptrModel, nil
 }
 
 func (gx *gormX[T, ID, PT]) FindByMapFilter(ctx context.Context, filter map[string]any, opts ...options.OrderOption) ([]PT, error) {
 	if filter == nil {
-		log.Printf("find by map filter failed : %s", errors.WarnInvalidFilter)
+		gx.logf("find by map filter failed : %s", errors.WarnInvalidFilter)
 		return nil, nil
 	}
 	if len(filter) == 0 {
-		log.Printf("find by map filter failed : %s", errors.WarnInvalidFilter)
+		gx.logf("find by map filter failed : %s", errors.WarnInvalidFilter)
 		return nil, nil
 	}
 
@@ -360,10 +426,10 @@ func (gx *gormX[T, ID, PT]) FindByMapFilter(ctx context.Context, filter map[stri
 	if len(opts) == 0 {
 
 		result = gx.GetDBWithContext(ctx).
-			Where(filter).
+			Scopes(whereMapFilter(filter)).
 			Find(&ptrModels)
 		if result.Error != nil {
-			log.Printf("find by map filter failed. table: %s, error: %v", tableName, result.Error)
+			gx.logf("find by map filter failed. table: %s, error: %v", tableName, result.Error)
 			return nil, errors.New(
 				errors.ErrQueryFailed,
 				"FindByMapFilter",
@@ -372,7 +438,7 @@ func (gx *gormX[T, ID, PT]) FindByMapFilter(ctx context.Context, filter map[stri
 			)
 		}
 		if result.RowsAffected == 0 {
-			log.Printf("find by map filter failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+			gx.logf("find by map filter failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
 		}
 
 		return ptrModels, nil
@@ -381,11 +447,11 @@ func (gx *gormX[T, ID, PT]) FindByMapFilter(ctx context.Context, filter map[stri
 	clauseOrder := gx.clauseOrderBuilder(opts...)
 
 	result = gx.GetDBWithContext(ctx).
-		Where(filter).
+		Scopes(whereMapFilter(filter)).
 		Order(clauseOrder).
 		Find(&ptrModels)
 	if result.Error != nil {
-		log.Printf("find by map filter failed. table: %s, error: %v", tableName, result.Error)
+		gx.logf("find by map filter failed. table: %s, error: %v", tableName, result.Error)
 		return nil, errors.New(
 			errors.ErrQueryFailed,
 			"FindByMapFilter(Order)",
@@ -394,7 +460,7 @@ func (gx *gormX[T, ID, PT]) FindByMapFilter(ctx context.Context, filter map[stri
 		)
 	}
 	if result.RowsAffected == 0 {
-		log.Printf("find by map filter (order) failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+		gx.logf("find by map filter (order) failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
 	}
 
 	return ptrModels, nil
@@ -402,13 +468,12 @@ func (gx *gormX[T, ID, PT]) FindByMapFilter(ctx context.Context, filter map[stri
 
 func (gx *gormX[T, ID, PT]) FindByPage(ctx context.Context, page, pageSize int, opts ...options.OrderOption) ([]PT, error) {
 	if page <= 0 || pageSize <= 0 {
-		log.Printf("find by page %d, pageSize %d failed : %s", page, pageSize, errors.WarnInvalidPageParams)
+		gx.logf("find by page %d, pageSize %d failed : %s", page, pageSize, errors.WarnInvalidPageParams)
 		return nil, nil
 	}
 
 	var model T
 	ptrModel := PT(&model)
-	primaryKey := ptrModel.PrimaryKey()
 	tableName := ptrModel.TableName()
 	ptrModels := make([]PT, 0, pageSize)
 	var result *gorm.DB
@@ -416,12 +481,12 @@ func (gx *gormX[T, ID, PT]) FindByPage(ctx context.Context, page, pageSize int,
 	if len(opts) == 0 {
 
 		result = gx.GetDBWithContext(ctx).
-			Order(fmt.Sprintf("%s ASC", primaryKey)).
+			Order(gx.clauseKeyOrderBuilder()).
 			Offset((page - 1) * pageSize).
 			Limit(pageSize).
 			Find(&ptrModels)
 		if result.Error != nil {
-			log.Printf("find by page %d, pageSize %d failed. table: %s, error: %v", page, pageSize, tableName, result.Error)
+			gx.logf("find by page %d, pageSize %d failed. table: %s, error: %v", page, pageSize, tableName, result.Error)
 			return nil, errors.New(
 				errors.ErrQueryFailed,
 				"FindByPage",
@@ -430,7 +495,7 @@ func (gx *gormX[T, ID, PT]) FindByPage(ctx context.Context, page, pageSize int,
 			)
 		}
 		if result.RowsAffected == 0 {
-			log.Printf("find by page %d, pageSize %d failed. table: %s, %s", page, pageSize, tableName, errors.WarnNoRowsAffected)
+			gx.logf("find by page %d, pageSize %d failed. table: %s, %s", page, pageSize, tableName, errors.WarnNoRowsAffected)
 		}
 
 		return ptrModels, nil
@@ -444,7 +509,7 @@ func (gx *gormX[T, ID, PT]) FindByPage(ctx context.Context, page, pageSize int,
 		Limit(pageSize).
 		Find(&ptrModels)
 	if result.Error != nil {
-		log.Printf("find by page %d, pageSize %d (order) failed. table: %s, error: %v", page, pageSize, tableName, result.Error)
+		gx.logf("find by page %d, pageSize %d (order) failed. table: %s, error: %v", page, pageSize, tableName, result.Error)
 		return nil, errors.New(
 			errors.ErrQueryFailed,
 			"FindByPage",
@@ -453,7 +518,7 @@ func (gx *gormX[T, ID, PT]) FindByPage(ctx context.Context, page, pageSize int,
 		)
 	}
 	if result.RowsAffected == 0 {
-		log.Printf("find by page %d, pageSize %d (order) failed. table: %s, %s", page, pageSize, tableName, errors.WarnNoRowsAffected)
+		gx.logf("find by page %d, pageSize %d (order) failed. table: %s, %s", page, pageSize, tableName, errors.WarnNoRowsAffected)
 	}
 
 	return ptrModels, nil
@@ -461,28 +526,27 @@ func (gx *gormX[T, ID, PT]) FindByPage(ctx context.Context, page, pageSize int,
 
 func (gx *gormX[T, ID, PT]) FindByCursor(ctx context.Context, cursor ID, limit int) ([]PT, ID, bool, error) {
 	if limit <= 0 {
-		log.Printf("find by cursor failed : %s", errors.WarnInvalidLimit)
+		gx.logf("find by cursor failed : %s", errors.WarnInvalidLimit)
 		return nil, cursor, false, nil
 	}
 
 	if model.IsZero(cursor) {
-		log.Printf("find by cursor failed : %s", errors.WarnInvalidID)
+		gx.logf("find by cursor failed : %s", errors.WarnInvalidID)
 		return nil, cursor, false, nil
 	}
 
 	var model T
 	ptrModel := PT(&model)
-	primaryKey := ptrModel.PrimaryKey()
 	tableName := ptrModel.TableName()
 	ptrModels := make([]PT, 0, limit)
 
 	result := gx.GetDBWithContext(ctx).
-		Where(fmt.Sprintf("%s > ?", primaryKey), cursor).
-		Order(fmt.Sprintf("%s ASC", primaryKey)).
+		Where(gx.clauseKeyAfterBuilder(cursor)).
+		Order(gx.clauseKeyOrderBuilder()).
 		Limit(limit + 1).
 		Find(&ptrModels)
 	if result.Error != nil {
-		log.Printf("find by cursor %v, limit %d failed. table: %s, error: %v", cursor, limit, tableName, result.Error)
+		gx.logf("find by cursor %v, limit %d failed. table: %s, error: %v", cursor, limit, tableName, result.Error)
 		return nil, cursor, false, errors.New(
 			errors.ErrQueryFailed,
 			"FindByCursor",
@@ -491,7 +555,7 @@ func (gx *gormX[T, ID, PT]) FindByCursor(ctx context.Context, cursor ID, limit i
 		)
 	}
 	if result.RowsAffected == 0 {
-		log.Printf("find by cursor %v, limit %d failed. table: %s, %s", cursor, limit, tableName, errors.WarnNoRowsAffected)
+		gx.logf("find by cursor %v, limit %d failed. table: %s, %s", cursor, limit, tableName, errors.WarnNoRowsAffected)
 	}
 	hasMore := len(ptrModels) > limit
 	if hasMore {
@@ -505,211 +569,403 @@ func (gx *gormX[T, ID, PT]) FindByCursor(ctx context.Context, cursor ID, limit i
 	return ptrModels, newCursor, hasMore, nil
 }
 
-func (gx *gormX[T, ID, PT]) Update(ctx context.Context, updateData PT) error {
+func (gx *gormX[T, ID, PT]) Update(ctx context.Context, updateData PT, opts ...options.MutationOption) (Result, error) {
 	if updateData == nil {
-		log.Printf("update failed : %s", errors.WarnInvalidUpdateData)
-		return nil
+		gx.logf("update failed : %s", errors.WarnInvalidUpdateData)
+		return Result{}, nil
 	}
 
 	tableName := updateData.TableName()
+	s, err := gx.parseSchema()
+	if err != nil {
+		return Result{}, errors.New(errors.ErrUpdateFailed, "Update", tableName, err)
+	}
+	// Updates(struct) 只写入非零值字段, 因此只校验这些字段
+	if err := ValidateNonZero(ctx, "Update", tableName, s, updateData); err != nil {
+		gx.logf("update failed. table: %s, error: %v", tableName, err)
+		return Result{}, err
+	}
 
-	result := gx.GetDBWithContext(ctx).
-		Updates(updateData)
-	if result.Error != nil {
-		log.Printf("update failed. table: %s, error: %v", tableName, result.Error)
-		return errors.New(
+	rowsAffected, err := gx.execChecked(ctx, "Update", tableName, opts, func(db *gorm.DB) *gorm.DB {
+		return db.Updates(updateData)
+	})
+	if err != nil {
+		if isRowsAffectedCheck(err) {
+			return Result{}, err
+		}
+		gx.logf("update failed. table: %s, error: %v", tableName, err)
+		return Result{}, errors.New(
 			errors.ErrUpdateFailed,
 			"Update",
 			tableName,
-			result.Error,
+			err,
 		)
 	}
-	if result.RowsAffected == 0 {
-		log.Printf("update failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+	if rowsAffected == 0 {
+		gx.logf("update failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+	}
+	return Result{RowsAffected: rowsAffected}, nil
+}
+
+/*
+UpdateFields 只更新 fields 指定的列(列名或字段名), 零值同样会被写入.
+Update 使用 gorm Updates(struct), 会忽略 0, false, "" 等零值字段, 需要写入零值时请使用该方法.
+*/
+func (gx *gormX[T, ID, PT]) UpdateFields(ctx context.Context, updateData PT, fields []string, opts ...options.MutationOption) (Result, error) {
+	if updateData == nil {
+		gx.logf("update fields failed : %s", errors.WarnInvalidUpdateData)
+		return Result{}, nil
+	}
+	if len(fields) == 0 {
+		gx.logf("update fields failed : %s", errors.WarnEmptyFields)
+		return Result{}, nil
+	}
+
+	tableName := updateData.TableName()
+	s, err := gx.parseSchema()
+	if err != nil {
+		return Result{}, errors.New(errors.ErrUpdateFailed, "UpdateFields", tableName, err)
+	}
+	schemaFields, err := ResolveFields("UpdateFields", tableName, s, fields)
+	if err != nil {
+		gx.logf("update fields failed. table: %s, error: %v", tableName, err)
+		return Result{}, err
+	}
+	// 只校验被更新的字段
+	if err := ValidateFields("UpdateFields", tableName, updateData, schemaFields); err != nil {
+		gx.logf("update fields failed. table: %s, error: %v", tableName, err)
+		return Result{}, err
+	}
+
+	rowsAffected, err := gx.execChecked(ctx, "UpdateFields", tableName, opts, func(db *gorm.DB) *gorm.DB {
+		return db.
+			Model(updateData).
+			Select(fields).
+			Updates(updateData)
+	})
+	if err != nil {
+		if isRowsAffectedCheck(err) {
+			return Result{}, err
+		}
+		gx.logf("update fields %v failed. table: %s, error: %v", fields, tableName, err)
+		return Result{}, errors.New(
+			errors.ErrUpdateFailed,
+			"UpdateFields",
+			tableName,
+			err,
+		)
+	}
+	if rowsAffected == 0 {
+		gx.logf("update fields failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+	}
+	return Result{RowsAffected: rowsAffected}, nil
+}
+
+/*
+Save 以完整模型替换数据库中的行, 包括零值字段.
+主键为零值时插入新行; 主键对应的行不存在时同样插入(与 gorm Save 一致).
+*/
+func (gx *gormX[T, ID, PT]) Save(ctx context.Context, model PT, opts ...options.MutationOption) (Result, error) {
+	if model == nil {
+		gx.logf("save failed : %s", errors.WarnInvalidModel)
+		return Result{}, nil
+	}
+
+	tableName := model.TableName()
+	if err := Validate("Save", tableName, model); err != nil {
+		gx.logf("save failed. table: %s, error: %v", tableName, err)
+		return Result{}, err
+	}
+
+	rowsAffected, err := gx.execChecked(ctx, "Save", tableName, opts, func(db *gorm.DB) *gorm.DB {
+		return db.Save(model)
+	})
+	if err != nil {
+		if isRowsAffectedCheck(err) {
+			return Result{}, err
+		}
+		gx.logf("save failed. table: %s, error: %v", tableName, err)
+		return Result{}, errors.New(
+			errors.ErrUpdateFailed,
+			"Save",
+			tableName,
+			err,
+		)
+	}
+	if rowsAffected == 0 {
+		gx.logf("save failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
 	}
-	return nil
+	return Result{RowsAffected: rowsAffected}, nil
 }
 
-func (gx *gormX[T, ID, PT]) UpdateByStructFilter(ctx context.Context, filter PT, updateData PT) error {
+/*
+PatchByID 将 JSON Merge Patch (RFC 7396) 应用到 id 对应的行并返回更新后的模型.
+补丁的键按 json 标签名(或列名)匹配字段, 只允许修改 patchable 白名单中的列, null 将字段置为零值.
+被修改的列通过 UpdateFields 写入, 因此零值同样生效. 如需保证读取与更新的原子性, 请在 GormXTx 中调用.
+
+PatchByID applies a JSON Merge Patch to the row with the given id, restricted to the patchable columns.
+*/
+func (gx *gormX[T, ID, PT]) PatchByID(ctx context.Context, id ID, patch []byte, patchable ...string) (PT, error) {
+	if model.IsZero(id) {
+		gx.logf("patch by id failed : %s", errors.WarnInvalidID)
+		return nil, nil
+	}
+
+	var m T
+	tableName := PT(&m).TableName()
+	s, err := gx.parseSchema()
+	if err != nil {
+		return nil, errors.New(errors.ErrUpdateFailed, "PatchByID", tableName, err)
+	}
+	// 先解析补丁, 无效的补丁不访问数据库
+	parsed, err := ParsePatch("PatchByID", tableName, s, patch, patchable)
+	if err != nil {
+		gx.logf("patch by id %v failed. table: %s, error: %v", id, tableName, err)
+		return nil, err
+	}
+
+	current, err := gx.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsed) == 0 {
+		return current, nil
+	}
+	if err := ApplyPatch(ctx, "PatchByID", tableName, current, parsed); err != nil {
+		gx.logf("patch by id %v failed. table: %s, error: %v", id, tableName, err)
+		return nil, err
+	}
+	if _, err := gx.UpdateFields(ctx, current, parsed.Columns()); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+func (gx *gormX[T, ID, PT]) UpdateByStructFilter(ctx context.Context, filter PT, updateData PT, opts ...options.MutationOption) (Result, error) {
 	if updateData == nil {
-		log.Printf("update by struct filter failed : %s", errors.WarnInvalidUpdateData)
-		return nil
+		gx.logf("update by struct filter failed : %s", errors.WarnInvalidUpdateData)
+		return Result{}, nil
 	}
 	if filter == nil {
-		log.Printf("update by struct filter failed : %s", errors.WarnInvalidFilter)
-		return nil
+		gx.logf("update by struct filter failed : %s", errors.WarnInvalidFilter)
+		return Result{}, nil
 	}
 
 	tableName := updateData.TableName()
 
-	result := gx.GetDBWithContext(ctx).
-		Where(filter).
-		Updates(updateData)
-	if result.Error != nil {
-		log.Printf("update by struct filter %v failed. table: %s error: %v", filter, tableName, result.Error)
-		return errors.New(
+	rowsAffected, err := gx.execChecked(ctx, "UpdateByStructFilter", tableName, opts, func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where(filter).
+			Updates(updateData)
+	})
+	if err != nil {
+		if isRowsAffectedCheck(err) {
+			return Result{}, err
+		}
+		gx.logf("update by struct filter %v failed. table: %s error: %v", filter, tableName, err)
+		return Result{}, errors.New(
 			errors.ErrUpdateFailed,
 			"UpdateByStructFilter",
 			tableName,
-			result.Error,
+			err,
 		)
 	}
-	if result.RowsAffected == 0 {
-		log.Printf("update by struct filter failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+	if rowsAffected == 0 {
+		gx.logf("update by struct filter failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
 	}
-	return nil
+	return Result{RowsAffected: rowsAffected}, nil
 }
 
-func (gx *gormX[T, ID, PT]) UpdateByMapFilter(ctx context.Context, filter map[string]any, updateData map[string]any) error {
+func (gx *gormX[T, ID, PT]) UpdateByMapFilter(ctx context.Context, filter map[string]any, updateData map[string]any, opts ...options.MutationOption) (Result, error) {
 	if updateData == nil {
-		log.Printf("update by map filter failed : %s", errors.WarnInvalidUpdateData)
-		return nil
+		gx.logf("update by map filter failed : %s", errors.WarnInvalidUpdateData)
+		return Result{}, nil
 	}
 	if len(updateData) == 0 {
-		log.Printf("update by map filter failed : %s", errors.WarnInvalidUpdateData)
-		return nil
+		gx.logf("update by map filter failed : %s", errors.WarnInvalidUpdateData)
+		return Result{}, nil
 	}
 	if filter == nil {
-		log.Printf("update by map filter failed : %s", errors.WarnInvalidFilter)
-		return nil
+		gx.logf("update by map filter failed : %s", errors.WarnInvalidFilter)
+		return Result{}, nil
 	}
 	if len(filter) == 0 {
-		log.Printf("update by map filter failed : %s", errors.WarnInvalidFilter)
-		return nil
+		gx.logf("update by map filter failed : %s", errors.WarnInvalidFilter)
+		return Result{}, nil
 	}
 
 	var model T
 	ptr := PT(&model)
 	tableName := ptr.TableName()
 
-	result := gx.GetDBWithContext(ctx).
-		Where(filter).
-		Updates(updateData)
-	if result.Error != nil {
-		log.Printf("update by map filter %v failed. table: %s error: %v", filter, tableName, result.Error)
-		return errors.New(
+	rowsAffected, err := gx.execChecked(ctx, "UpdateByMapFilter", tableName, opts, func(db *gorm.DB) *gorm.DB {
+		return db.
+			Model(ptr).
+			Scopes(whereMapFilter(filter)).
+			Updates(updateData)
+	})
+	if err != nil {
+		if isRowsAffectedCheck(err) {
+			return Result{}, err
+		}
+		gx.logf("update by map filter %v failed. table: %s error: %v", filter, tableName, err)
+		return Result{}, errors.New(
 			errors.ErrUpdateFailed,
 			"UpdateByMapFilter",
 			tableName,
-			result.Error,
+			err,
 		)
 	}
-	if result.RowsAffected == 0 {
-		log.Printf("update by map filter failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+	if rowsAffected == 0 {
+		gx.logf("update by map filter failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
 	}
-	return nil
+	return Result{RowsAffected: rowsAffected}, nil
 }
 
-func (gx *gormX[T, ID, PT]) DeleteByID(ctx context.Context, id ID) error {
+func (gx *gormX[T, ID, PT]) DeleteByID(ctx context.Context, id ID, opts ...options.MutationOption) (Result, error) {
 	if model.IsZero(id) {
-		log.Printf("delete by id failed : %s", errors.WarnInvalidID)
-		return nil
+		gx.logf("delete by id failed : %s", errors.WarnInvalidID)
+		return Result{}, nil
 	}
 
 	var model T
 	ptr := PT(&model)
 	tableName := ptr.TableName()
 
-	result := gx.GetDBWithContext(ctx).
-		Delete(ptr, id)
-	if result.Error != nil {
-		log.Printf("delete by id %v failed. table: %s, error: %v", id, tableName, result.Error)
-		return errors.New(
+	rowsAffected, err := gx.execChecked(ctx, "DeleteByID", tableName, opts, func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where(gx.clauseKeyEqBuilder(id)).
+			Delete(ptr)
+	})
+	if err != nil {
+		if isRowsAffectedCheck(err) {
+			return Result{}, err
+		}
+		gx.logf("delete by id %v failed. table: %s, error: %v", id, tableName, err)
+		return Result{}, errors.New(
 			errors.ErrDeleteFailed,
 			"DeleteByID",
 			tableName,
-			result.Error,
+			err,
 		)
 	}
-	if result.RowsAffected == 0 {
-		log.Printf("delete by id failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+	if rowsAffected == 0 {
+		gx.logf("delete by id failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
 	}
-	return nil
+	return Result{RowsAffected: rowsAffected}, nil
 }
 
-func (gx *gormX[T, ID, PT]) DeleteByIDs(ctx context.Context, ids []ID) error {
+// DeleteByIDs 按主键批量删除, ID 列表过长时分块执行以避免超出占位符上限, 所有分块在同一事务中执行
+func (gx *gormX[T, ID, PT]) DeleteByIDs(ctx context.Context, ids []ID, opts ...options.MutationOption) (Result, error) {
 	if len(ids) == 0 {
-		log.Printf("delete by ids failed : %s", errors.WarnEmptyIDsSlice)
-		return nil
+		gx.logf("delete by ids failed : %s", errors.WarnEmptyIDsSlice)
+		return Result{}, nil
 	}
 
 	var model T
 	ptr := PT(&model)
 	tableName := ptr.TableName()
 
-	result := gx.GetDBWithContext(ctx).
-		Delete(ptr, ids)
-	if result.Error != nil {
-		log.Printf("delete by ids %v failed. table: %s error: %v", ids, tableName, result.Error)
-		return errors.New(
+	mutation := options.NewMutationWithOptions(opts...)
+	var rowsAffected int64
+	err := gx.GetDBWithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for chunk := range slices.Chunk(ids, chunkSize(0, len(gx.keyColumns()))) {
+			result := tx.
+				Where(gx.clauseKeyInBuilder(chunk)).
+				Delete(ptr)
+			if result.Error != nil {
+				return result.Error
+			}
+			rowsAffected += result.RowsAffected
+		}
+		// 在事务内检查受影响行数, 不满足时回滚所有分块
+		return mutation.Check("DeleteByIDs", tableName, rowsAffected)
+	})
+	if err != nil {
+		// 行数检查失败时所有分块已回滚
+		if isRowsAffectedCheck(err) {
+			return Result{}, err
+		}
+		gx.logf("delete by ids failed. table: %s error: %v", tableName, err)
+		return Result{}, errors.New(
 			errors.ErrDeleteFailed,
 			"DeleteByIDs",
 			tableName,
-			result.Error,
+			err,
 		)
 	}
-	if result.RowsAffected == 0 {
-		log.Printf("delete by ids failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+	if rowsAffected == 0 {
+		gx.logf("delete by ids failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
 	}
-	return nil
+	return Result{RowsAffected: rowsAffected}, nil
 }
 
-func (gx *gormX[T, ID, PT]) DeleteByStructFilter(ctx context.Context, filter PT) error {
+func (gx *gormX[T, ID, PT]) DeleteByStructFilter(ctx context.Context, filter PT, opts ...options.MutationOption) (Result, error) {
 	if filter == nil {
-		log.Printf("delete by struct filter failed : %s", errors.WarnInvalidFilter)
-		return nil
+		gx.logf("delete by struct filter failed : %s", errors.WarnInvalidFilter)
+		return Result{}, nil
 	}
 
 	var model T
 	ptr := PT(&model)
 	tableName := ptr.TableName()
 
-	result := gx.GetDBWithContext(ctx).
-		Where(filter).
-		Delete(ptr)
-	if result.Error != nil {
-		log.Printf("delete by struct filter %v failed. table: %s error: %v", filter, tableName, result.Error)
-		return errors.New(
+	rowsAffected, err := gx.execChecked(ctx, "DeleteByStructFilter", tableName, opts, func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where(filter).
+			Delete(ptr)
+	})
+	if err != nil {
+		if isRowsAffectedCheck(err) {
+			return Result{}, err
+		}
+		gx.logf("delete by struct filter %v failed. table: %s error: %v", filter, tableName, err)
+		return Result{}, errors.New(
 			errors.ErrDeleteFailed,
 			"DeleteByStructFilter",
 			tableName,
-			result.Error,
+			err,
 		)
 	}
-	if result.RowsAffected == 0 {
-		log.Printf("delete by struct filter failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+	if rowsAffected == 0 {
+		gx.logf("delete by struct filter failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
 	}
-	return nil
+	return Result{RowsAffected: rowsAffected}, nil
 }
 
-func (gx *gormX[T, ID, PT]) DeleteByMapFilter(ctx context.Context, filter map[string]any) error {
+func (gx *gormX[T, ID, PT]) DeleteByMapFilter(ctx context.Context, filter map[string]any, opts ...options.MutationOption) (Result, error) {
 	if filter == nil {
-		log.Printf("delete by map filter failed : %s", errors.WarnInvalidFilter)
-		return nil
+		gx.logf("delete by map filter failed : %s", errors.WarnInvalidFilter)
+		return Result{}, nil
 	}
 	if len(filter) == 0 {
-		log.Printf("delete by map filter failed : %s", errors.WarnInvalidFilter)
-		return nil
+		gx.logf("delete by map filter failed : %s", errors.WarnInvalidFilter)
+		return Result{}, nil
 	}
 
 	var model T
 	ptr := PT(&model)
 	tableName := ptr.TableName()
 
-	result := gx.GetDBWithContext(ctx).
-		Where(filter).
-		Delete(ptr)
-	if result.Error != nil {
-		log.Printf("delete by map filter %v failed. table: %s, error: %v", filter, tableName, result.Error)
-		return errors.New(
+	rowsAffected, err := gx.execChecked(ctx, "DeleteByMapFilter", tableName, opts, func(db *gorm.DB) *gorm.DB {
+		return db.
+			Scopes(whereMapFilter(filter)).
+			Delete(ptr)
+	})
+	if err != nil {
+		if isRowsAffectedCheck(err) {
+			return Result{}, err
+		}
+		gx.logf("delete by map filter %v failed. table: %s, error: %v", filter, tableName, err)
+		return Result{}, errors.New(
 			errors.ErrDeleteFailed,
 			"DeleteByMapFilter",
 			tableName,
-			result.Error,
+			err,
 		)
 	}
-	if result.RowsAffected == 0 {
-		log.Printf("delete by map filter failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
+	if rowsAffected == 0 {
+		gx.logf("delete by map filter failed. table: %s, %s", tableName, errors.WarnNoRowsAffected)
 	}
-	return nil
+	return Result{RowsAffected: rowsAffected}, nil
 }
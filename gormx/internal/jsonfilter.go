@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/LouYuanbo1/go-webservice/gormx/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+/*
+whereMapFilter 与 Where(filter) 一致, 额外支持 "column->a.b" 形式的键查询 JSON 列内部的字段(见 model.JSONPath):
+  - Postgres: column -> 'a' ->> 'b' = 'value', 按文本比较
+  - MySQL: JSON_UNQUOTE(JSON_EXTRACT(column, '$."a"."b"')) = 'value', 按文本比较
+  - SQLite: json_extract(column, '$."a"."b"') = value
+  - 纯数字的路径段视为数组下标, 切片值视为 IN, nil 视为 IS NULL
+*/
+func whereMapFilter(filter map[string]any) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		plain := make(map[string]any, len(filter))
+		// 按键排序, 保证生成的 SQL 稳定
+		for _, key := range slices.Sorted(maps.Keys(filter)) {
+			value := filter[key]
+			column, path, ok := strings.Cut(key, model.JSONPathSeparator)
+			if !ok {
+				plain[key] = value
+				continue
+			}
+			db = db.Where(clauseJSONPathBuilder(db.Dialector.Name(), column, strings.Split(path, "."), value))
+		}
+		if len(plain) > 0 {
+			db = db.Where(plain)
+		}
+		return db
+	}
+}
+
+func clauseJSONPathBuilder(dialect, column string, path []string, value any) clause.Expression {
+	var sql strings.Builder
+	vars := []any{clause.Column{Name: column}}
+	// Postgres 与 MySQL 取出的是文本, SQLite 的 json_extract 返回原生类型
+	textual := true
+	switch dialect {
+	case "postgres":
+		sql.WriteString("?")
+		for i, seg := range path {
+			op := " -> "
+			if i == len(path)-1 {
+				op = " ->> "
+			}
+			if index, err := strconv.Atoi(seg); err == nil {
+				sql.WriteString(op + "?::int")
+				vars = append(vars, index)
+			} else {
+				sql.WriteString(op + "?::text")
+				vars = append(vars, seg)
+			}
+		}
+	case "mysql":
+		sql.WriteString("JSON_UNQUOTE(JSON_EXTRACT(?, ?))")
+		vars = append(vars, jsonPathExpr(path))
+	default:
+		sql.WriteString("json_extract(?, ?)")
+		vars = append(vars, jsonPathExpr(path))
+		textual = false
+	}
+
+	if value == nil {
+		sql.WriteString(" IS NULL")
+		return clause.Expr{SQL: sql.String(), Vars: vars}
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		values := make([]any, rv.Len())
+		for i := range values {
+			values[i] = jsonCompareValue(rv.Index(i).Interface(), textual)
+		}
+		if len(values) == 0 {
+			sql.WriteString(" IN (NULL)")
+			return clause.Expr{SQL: sql.String(), Vars: vars}
+		}
+		sql.WriteString(" IN ?")
+		return clause.Expr{SQL: sql.String(), Vars: append(vars, values)}
+	}
+	sql.WriteString(" = ?")
+	return clause.Expr{SQL: sql.String(), Vars: append(vars, jsonCompareValue(value, textual))}
+}
+
+// jsonPathExpr 构建 MySQL 与 SQLite 的路径表达式, 例如 $."a"[0]."b"
+func jsonPathExpr(path []string) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, seg := range path {
+		if _, err := strconv.Atoi(seg); err == nil {
+			b.WriteString("[" + seg + "]")
+			continue
+		}
+		b.WriteString(`."` + strings.ReplaceAll(seg, `"`, `\"`) + `"`)
+	}
+	return b.String()
+}
+
+// jsonCompareValue 在按文本比较时将值转为其 JSON 文本, 字符串保持原样
+func jsonCompareValue(value any, textual bool) any {
+	if !textual {
+		return value
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.Trim(string(raw), `"`)
+}
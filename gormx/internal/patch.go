@@ -0,0 +1,161 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/LouYuanbo1/go-webservice/gormx/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Patch 是解析并通过白名单检查的 JSON Merge Patch, 字段 -> 原始 JSON 值
+type Patch map[*schema.Field]json.RawMessage
+
+// Columns 返回补丁涉及的列名, 按列名排序
+func (p Patch) Columns() []string {
+	columns := make([]string, 0, len(p))
+	for field := range p {
+		columns = append(columns, field.DBName)
+	}
+	slices.Sort(columns)
+	return columns
+}
+
+func (p Patch) Fields() []*schema.Field {
+	fields := make([]*schema.Field, 0, len(p))
+	for field := range p {
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// parseSchema 使用 db 的命名策略与缓存解析模型
+func (gx *gormX[T, ID, PT]) parseSchema() (*schema.Schema, error) {
+	var m T
+	stmt := &gorm.Statement{DB: gx.db}
+	if err := stmt.Parse(PT(&m)); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
+
+// ResolveFields 将列名或字段名解析为 schema 字段
+func ResolveFields(op, tableName string, s *schema.Schema, names []string) ([]*schema.Field, error) {
+	fields := make([]*schema.Field, 0, len(names))
+	for _, name := range names {
+		field := s.LookUpField(name)
+		if field == nil || field.DBName == "" {
+			return nil, errors.New(errors.ErrUnknownField, op, tableName, fmt.Errorf("unknown field %q", name))
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// ValidateFields 只校验指定字段的 `validate` 标签, model.Validator 针对整个模型, 此处不调用
+func ValidateFields(op, tableName string, m any, fields []*schema.Field) error {
+	fieldErrs, err := validatePartial(m, fields)
+	if err != nil {
+		return errors.New(errors.ErrValidation, op, tableName, err)
+	}
+	if len(fieldErrs) > 0 {
+		return errors.New(errors.ErrValidation, op, tableName, fieldErrs)
+	}
+	return nil
+}
+
+/*
+ValidateNonZero 只校验 m 中非零值字段的 `validate` 标签, 与 gorm Updates(struct) 实际写入的字段一致,
+未更新的零值字段上的 required 等标签不会拒绝部分更新.
+*/
+func ValidateNonZero(ctx context.Context, op, tableName string, s *schema.Schema, m any) error {
+	rv := reflect.ValueOf(m)
+	fields := make([]*schema.Field, 0, len(s.Fields))
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		if _, isZero := field.ValueOf(ctx, rv); !isZero {
+			fields = append(fields, field)
+		}
+	}
+	return ValidateFields(op, tableName, m, fields)
+}
+
+func jsonName(field *schema.Field) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
+
+/*
+ParsePatch 解析 JSON Merge Patch (RFC 7396), 键按 json 标签名匹配字段, 也接受列名.
+只有 patchable 白名单中的列(或字段名)可以被修改, 否则返回 ErrFieldNotPatchable.
+*/
+func ParsePatch(op, tableName string, s *schema.Schema, patch []byte, patchable []string) (Patch, error) {
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &payload); err != nil {
+		return nil, errors.New(errors.ErrInvalidPatch, op, tableName, err)
+	}
+	if payload == nil {
+		return nil, errors.New(errors.ErrInvalidPatch, op, tableName, fmt.Errorf("patch must be a JSON object"))
+	}
+
+	allowed, err := ResolveFields(op, tableName, s, patchable)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(Patch, len(payload))
+	var denied []string
+	for key, raw := range payload {
+		var field *schema.Field
+		for _, f := range s.Fields {
+			if f.DBName != "" && (jsonName(f) == key || f.DBName == key) {
+				field = f
+				break
+			}
+		}
+		if field == nil {
+			return nil, errors.New(errors.ErrUnknownField, op, tableName, fmt.Errorf("unknown field %q", key))
+		}
+		if !slices.Contains(allowed, field) {
+			denied = append(denied, key)
+			continue
+		}
+		result[field] = raw
+	}
+	if len(denied) > 0 {
+		slices.Sort(denied)
+		return nil, errors.New(errors.ErrFieldNotPatchable, op, tableName,
+			fmt.Errorf("fields %s are not patchable", strings.Join(denied, ", ")))
+	}
+	return result, nil
+}
+
+// ApplyPatch 将补丁写入模型, null 将字段置为零值, 其余值整体替换字段(嵌套对象不做递归合并)
+func ApplyPatch(ctx context.Context, op, tableName string, m any, patch Patch) error {
+	rv := reflect.ValueOf(m)
+	for field, raw := range patch {
+		value := field.ReflectValueOf(ctx, rv)
+		value.SetZero()
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			continue
+		}
+		if err := json.Unmarshal(raw, value.Addr().Interface()); err != nil {
+			return errors.New(errors.ErrInvalidPatch, op, tableName, fmt.Errorf("field %s: %w", field.Name, err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package internal
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/LouYuanbo1/go-webservice/gormx/errors"
+	"gorm.io/gorm"
+)
+
+type registry struct {
+	mu     sync.RWMutex
+	dbs    map[string]*gorm.DB
+	closed bool
+}
+
+func NewRegistry() *registry {
+	return &registry{dbs: make(map[string]*gorm.DB)}
+}
+
+func (r *registry) Register(name string, db *gorm.DB) error {
+	if name == "" || db == nil {
+		return errors.New(errors.ErrInvalidInitConfig, "Register", name, fmt.Errorf("name and db cannot be empty"))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return errors.New(errors.ErrRegistryClosed, "Register", name, nil)
+	}
+	if _, ok := r.dbs[name]; ok {
+		return errors.New(errors.ErrInvalidInitConfig, "Register", name, fmt.Errorf("database %q already registered", name))
+	}
+	r.dbs[name] = db
+	return nil
+}
+
+func (r *registry) Get(name string) (*gorm.DB, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return nil, errors.New(errors.ErrRegistryClosed, "Get", name, nil)
+	}
+	db, ok := r.dbs[name]
+	if !ok {
+		return nil, errors.New(errors.ErrUnknownDatabase, "Get", name, fmt.Errorf("database %q not registered", name))
+	}
+	return db, nil
+}
+
+func (r *registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.dbs))
+	for name := range r.dbs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// HealthCheck 并发 ping 所有连接, 返回每个连接的结果, 健康的连接对应 nil
+func (r *registry) HealthCheck(ctx context.Context) map[string]error {
+	r.mu.RLock()
+	dbs := make(map[string]*gorm.DB, len(r.dbs))
+	for name, db := range r.dbs {
+		dbs[name] = db
+	}
+	closed := r.closed
+	r.mu.RUnlock()
+
+	results := make(map[string]error, len(dbs))
+	if closed {
+		return results
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, db := range dbs {
+		wg.Go(func() {
+			err := ping(ctx, db)
+			if err != nil {
+				err = errors.New(errors.ErrDBConnection, "HealthCheck", name, err)
+			}
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+	return results
+}
+
+func ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+/*
+Close 拒绝新的 Get 并关闭所有连接池. sql.DB.Close 会等待已开始的查询结束,
+ctx 到期时 Close 返回 ctx.Err(), 未关闭完成的连接池在后台继续关闭.
+*/
+func (r *registry) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	dbs := r.dbs
+	r.dbs = make(map[string]*gorm.DB)
+	r.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		var mu sync.Mutex
+		var errs []error
+		var wg sync.WaitGroup
+		for name, db := range dbs {
+			wg.Go(func() {
+				sqlDB, err := db.DB()
+				if err == nil {
+					// 不再保留空闲连接, 使用中的连接归还后立即关闭
+					sqlDB.SetMaxIdleConns(0)
+					err = sqlDB.Close()
+				}
+				if err != nil {
+					mu.Lock()
+					errs = append(errs, errors.New(errors.ErrDBConnection, "Close", name, err))
+					mu.Unlock()
+				}
+			})
+		}
+		wg.Wait()
+		done <- stderrors.Join(errs...)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
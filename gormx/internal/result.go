@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"context"
+	"reflect"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Result 写操作结果
+type Result struct {
+	// 受影响的行数 RowsAffected (MySQL 默认只统计值发生变化的行)
+	RowsAffected int64
+	// 最后插入行的自增主键 LastInsertID, 主键非自增整数或非插入操作时为 0
+	LastInsertID int64
+}
+
+// createResult 构建插入操作的结果, LastInsertID 取最后一个插入模型的自增主键
+func (gx *gormX[T, ID, PT]) createResult(ctx context.Context, result *gorm.DB, models ...PT) Result {
+	res := Result{RowsAffected: result.RowsAffected}
+	s, err := gx.parseSchema()
+	if err != nil {
+		return res
+	}
+	for _, m := range slices.Backward(models) {
+		if m != nil {
+			res.LastInsertID = LastInsertID(ctx, s, m)
+			break
+		}
+	}
+	return res
+}
+
+// LastInsertID 读取模型的自增主键, gorm 在插入后会将数据库生成的主键写回模型
+func LastInsertID(ctx context.Context, s *schema.Schema, m any) int64 {
+	pk := s.PrioritizedPrimaryField
+	if pk == nil || !pk.AutoIncrement {
+		return 0
+	}
+	value := pk.ReflectValueOf(ctx, reflect.ValueOf(m))
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(value.Uint())
+	}
+	return 0
+}
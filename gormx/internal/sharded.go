@@ -0,0 +1,956 @@
+package internal
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"log"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/gormx/errors"
+	"github.com/LouYuanbo1/go-webservice/gormx/model"
+	"github.com/LouYuanbo1/go-webservice/gormx/options"
+	"github.com/LouYuanbo1/go-webservice/gormx/sharding"
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+/*
+shardedGormX 将操作路由到分片键所在的物理表:
+  - 能从 ID, 模型, 结构体过滤器或 map 过滤器中取得分片键时, 只访问对应的分片
+  - 取不到分片键时扇出到全部分片并合并结果, 配置 sharding.WithRejectFanOut 时返回 ErrShardKeyRequired
+  - Create, CreateInBatches, Save 与 UpdateInBatches 按模型的分片键路由, 零值是否有效由策略决定
+
+扇出读在事务外并发执行, 在事务内按分片顺序执行. 跨分片写在默认数据库上使用同一个事务,
+涉及其他数据库时按分片顺序执行, 不保证原子性. 事务中不能访问默认数据库以外的分片.
+*/
+type shardedGormX[T any, ID comparable, PT model.PointerModel[T, ID]] struct {
+	db       *gorm.DB
+	opts     *sharding.Options
+	strategy sharding.Strategy
+	key      *schema.Field
+	// 主键中分片键的位置, -1 表示主键不包含分片键
+	keyIndex  int
+	tableName string
+
+	mu      sync.RWMutex
+	targets []sharding.Target
+	shards  map[sharding.Target]*gormX[T, ID, PT]
+}
+
+func NewShardedGormX[T any, ID comparable, PT model.PointerModel[T, ID]](db *gorm.DB, opts ...sharding.Option) (*shardedGormX[T, ID, PT], error) {
+	var m T
+	tableName := PT(&m).TableName()
+	sharded, ok := any(PT(&m)).(model.Sharded)
+	if !ok {
+		return nil, errors.New(errors.ErrInvalidInitConfig, "NewShardedGormX", tableName, fmt.Errorf("%T does not implement model.Sharded", PT(&m)))
+	}
+	if db == nil {
+		return nil, errors.New(errors.ErrInvalidInitConfig, "NewShardedGormX", tableName, fmt.Errorf("db cannot be nil"))
+	}
+
+	sx := &shardedGormX[T, ID, PT]{
+		db:        db,
+		opts:      sharding.NewOptions(opts...),
+		strategy:  sharded.ShardStrategy(),
+		keyIndex:  -1,
+		tableName: tableName,
+		shards:    make(map[sharding.Target]*gormX[T, ID, PT]),
+	}
+	if sx.strategy == nil {
+		return nil, errors.New(errors.ErrInvalidInitConfig, "NewShardedGormX", tableName, fmt.Errorf("shard strategy cannot be nil"))
+	}
+
+	s, err := NewGormX[T, ID, PT](db).parseSchema()
+	if err != nil {
+		return nil, errors.New(errors.ErrInvalidInitConfig, "NewShardedGormX", tableName, err)
+	}
+	sx.key = s.LookUpField(sharded.ShardKey())
+	if sx.key == nil || sx.key.DBName == "" {
+		return nil, errors.New(errors.ErrInvalidInitConfig, "NewShardedGormX", tableName, fmt.Errorf("unknown shard key %q", sharded.ShardKey()))
+	}
+	sx.keyIndex = slices.Index(model.KeyColumns[ID](PT(&m)), sx.key.DBName)
+
+	for _, target := range sx.strategy.Targets() {
+		if _, ok := sx.shards[target]; ok {
+			continue
+		}
+		if _, err := sx.shard(target); err != nil {
+			return nil, err
+		}
+	}
+	return sx, nil
+}
+
+// shard 返回分片对应的 gormX, 策略返回 Targets() 以外的分片时按需创建
+func (sx *shardedGormX[T, ID, PT]) shard(target sharding.Target) (*gormX[T, ID, PT], error) {
+	sx.mu.RLock()
+	gx, ok := sx.shards[target]
+	sx.mu.RUnlock()
+	if ok {
+		return gx, nil
+	}
+
+	db := sx.db
+	if target.Database != "" {
+		if db, ok = sx.opts.Databases[target.Database]; !ok {
+			return nil, errors.New(errors.ErrShardRouting, "Shard", sx.tableName, fmt.Errorf("unknown database %q", target.Database))
+		}
+	}
+
+	sx.mu.Lock()
+	defer sx.mu.Unlock()
+	if gx, ok := sx.shards[target]; ok {
+		return gx, nil
+	}
+	gx = NewTableGormX[T, ID, PT](db, target.Table)
+	sx.shards[target] = gx
+	sx.targets = append(sx.targets, target)
+	return gx, nil
+}
+
+// shardOf 返回可在 ctx 中使用的分片, 事务只能访问默认数据库上的分片
+func (sx *shardedGormX[T, ID, PT]) shardOf(ctx context.Context, op string, target sharding.Target) (*gormX[T, ID, PT], error) {
+	gx, err := sx.shard(target)
+	if err != nil {
+		return nil, err
+	}
+	if gx.db != sx.db && sx.InTransaction(ctx) {
+		return nil, errors.New(errors.ErrCrossShardTransaction, op, sx.tableName, fmt.Errorf("shard %s", target))
+	}
+	return gx, nil
+}
+
+func (sx *shardedGormX[T, ID, PT]) route(op string, value any) (sharding.Target, error) {
+	target, err := sx.strategy.Shard(value)
+	if err != nil {
+		return sharding.Target{}, errors.New(errors.ErrShardRouting, op, sx.tableName, err)
+	}
+	return target, nil
+}
+
+// fanOut 返回全部分片, 配置了 WithRejectFanOut 时返回 ErrShardKeyRequired
+func (sx *shardedGormX[T, ID, PT]) fanOut(op string) ([]sharding.Target, error) {
+	if sx.opts.RejectFanOut {
+		return nil, errors.New(errors.ErrShardKeyRequired, op, sx.tableName, fmt.Errorf("shard key %q not found and fan-out is rejected", sx.key.DBName))
+	}
+	sx.mu.RLock()
+	defer sx.mu.RUnlock()
+	return slices.Clone(sx.targets), nil
+}
+
+// keyOfID 从主键中取出分片键, 主键不包含分片键时返回 false
+func (sx *shardedGormX[T, ID, PT]) keyOfID(id ID) (any, bool) {
+	if sx.keyIndex < 0 {
+		return nil, false
+	}
+	return model.KeyValues(id)[sx.keyIndex], true
+}
+
+/*
+keyOfModel 取出过滤器或部分更新中的分片键, 零值时返回 false.
+与 gorm 的结构体条件一致, 零值视为未设置.
+*/
+func (sx *shardedGormX[T, ID, PT]) keyOfModel(ctx context.Context, m PT) (any, bool) {
+	value, isZero := sx.key.ValueOf(ctx, reflect.ValueOf(m))
+	return value, !isZero
+}
+
+// routeModel 按模型的分片键路由写入的行, 零值同样交给策略判断是否有效
+func (sx *shardedGormX[T, ID, PT]) routeModel(ctx context.Context, op string, m PT) (sharding.Target, error) {
+	value, _ := sx.key.ValueOf(ctx, reflect.ValueOf(m))
+	return sx.route(op, value)
+}
+
+func (sx *shardedGormX[T, ID, PT]) targetsOfIDs(op string, ids []ID) (map[sharding.Target][]ID, error) {
+	grouped := make(map[sharding.Target][]ID)
+	if sx.keyIndex < 0 {
+		targets, err := sx.fanOut(op)
+		if err != nil {
+			return nil, err
+		}
+		for _, target := range targets {
+			grouped[target] = ids
+		}
+		return grouped, nil
+	}
+	for _, id := range ids {
+		value, _ := sx.keyOfID(id)
+		target, err := sx.route(op, value)
+		if err != nil {
+			return nil, err
+		}
+		grouped[target] = append(grouped[target], id)
+	}
+	return grouped, nil
+}
+
+func (sx *shardedGormX[T, ID, PT]) idsByShard(grouped map[sharding.Target][]ID) (map[*gormX[T, ID, PT]][]ID, error) {
+	byShard := make(map[*gormX[T, ID, PT]][]ID, len(grouped))
+	for target, ids := range grouped {
+		gx, err := sx.shard(target)
+		if err != nil {
+			return nil, err
+		}
+		byShard[gx] = ids
+	}
+	return byShard, nil
+}
+
+func (sx *shardedGormX[T, ID, PT]) targetsOfModel(ctx context.Context, op string, m PT) ([]sharding.Target, error) {
+	value, ok := sx.keyOfModel(ctx, m)
+	if !ok {
+		return sx.fanOut(op)
+	}
+	target, err := sx.route(op, value)
+	if err != nil {
+		return nil, err
+	}
+	return []sharding.Target{target}, nil
+}
+
+// targetsOfMap 路由 map 过滤器, 分片键的值为切片时(IN 查询)路由到每个元素所在的分片
+func (sx *shardedGormX[T, ID, PT]) targetsOfMap(op string, filter map[string]any) ([]sharding.Target, error) {
+	value, ok := filter[sx.key.DBName]
+	if !ok {
+		value, ok = filter[sx.key.Name]
+	}
+	if !ok {
+		return sx.fanOut(op)
+	}
+
+	rv := reflect.ValueOf(value)
+	if (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Type().Elem().Kind() == reflect.Uint8 {
+		target, err := sx.route(op, value)
+		if err != nil {
+			return nil, err
+		}
+		return []sharding.Target{target}, nil
+	}
+
+	var targets []sharding.Target
+	for i := range rv.Len() {
+		target, err := sx.route(op, rv.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(targets, target) {
+			targets = append(targets, target)
+		}
+	}
+	return targets, nil
+}
+
+/*
+logError 记录分表操作的错误, 行数检查错误由调用方处理, 不记录.
+分片上的 gormX 不记录日志, 扇出时单个分片没有命中是正常情况, 由分表层在合并结果后统一记录一次.
+*/
+func (sx *shardedGormX[T, ID, PT]) logError(op string, err error) {
+	if err != nil && !isRowsAffectedCheck(err) {
+		log.Printf("sharded %s failed. table: %s, error: %v", op, sx.tableName, err)
+	}
+}
+
+// collect 在各分片上执行读操作并合并结果, 出错或全部分片都没有结果时记录一次日志
+func (sx *shardedGormX[T, ID, PT]) collect(ctx context.Context, op string, targets []sharding.Target, fn func(ctx context.Context, gx *gormX[T, ID, PT]) ([]PT, error)) ([]PT, error) {
+	models, err := sx.gather(ctx, op, targets, fn)
+	if err != nil {
+		sx.logError(op, err)
+		return nil, err
+	}
+	if len(models) == 0 {
+		log.Printf("sharded %s failed. table: %s, %s", op, sx.tableName, errors.WarnNoRowsAffected)
+	}
+	return models, nil
+}
+
+// gather 在各分片上执行读操作并合并结果, 事务内按顺序执行
+func (sx *shardedGormX[T, ID, PT]) gather(ctx context.Context, op string, targets []sharding.Target, fn func(ctx context.Context, gx *gormX[T, ID, PT]) ([]PT, error)) ([]PT, error) {
+	shards := make([]*gormX[T, ID, PT], len(targets))
+	for i, target := range targets {
+		gx, err := sx.shardOf(ctx, op, target)
+		if err != nil {
+			return nil, err
+		}
+		shards[i] = gx
+	}
+
+	results := make([][]PT, len(shards))
+	if len(shards) == 1 || sx.InTransaction(ctx) {
+		for i, gx := range shards {
+			models, err := fn(ctx, gx)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = models
+		}
+		return slices.Concat(results...), nil
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	if sx.opts.MaxConcurrency > 0 {
+		g.SetLimit(sx.opts.MaxConcurrency)
+	}
+	for i, gx := range shards {
+		g.Go(func() error {
+			models, err := fn(gctx, gx)
+			results[i] = models
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return slices.Concat(results...), nil
+}
+
+/*
+write 在各分片上执行写操作并累加受影响行数.
+全部分片都在默认数据库上时, 跨分片或需要检查行数的写操作在同一事务中执行(已在事务中时使用保存点),
+行数检查失败时回滚全部分片. 涉及其他数据库时按分片顺序执行, 检查失败时无法回滚.
+*/
+func (sx *shardedGormX[T, ID, PT]) write(ctx context.Context, op string, targets []sharding.Target, mutation []options.MutationOption, fn func(ctx context.Context, gx *gormX[T, ID, PT]) (Result, error)) (Result, error) {
+	shards := make([]*gormX[T, ID, PT], len(targets))
+	local := true
+	for i, target := range targets {
+		gx, err := sx.shardOf(ctx, op, target)
+		if err != nil {
+			return Result{}, err
+		}
+		shards[i] = gx
+		local = local && gx.db == sx.db
+	}
+
+	var total Result
+	exec := func(ctx context.Context) error {
+		total = Result{}
+		for _, gx := range shards {
+			res, err := fn(ctx, gx)
+			if err != nil {
+				return err
+			}
+			total.RowsAffected += res.RowsAffected
+			if res.LastInsertID != 0 {
+				total.LastInsertID = res.LastInsertID
+			}
+		}
+		return options.NewMutationWithOptions(mutation...).Check(op, sx.tableName, total.RowsAffected)
+	}
+
+	var err error
+	if local && (len(shards) > 1 || len(mutation) > 0) {
+		db := sx.db
+		if tx, ok := ctx.Value(contextTxKey{}).(*gorm.DB); ok {
+			db = tx
+		}
+		err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return exec(context.WithValue(ctx, contextTxKey{}, tx))
+		})
+	} else {
+		err = exec(ctx)
+	}
+	if err != nil {
+		sx.logError(op, err)
+		return Result{}, err
+	}
+	if total.RowsAffected == 0 {
+		log.Printf("sharded %s failed. table: %s, %s", op, sx.tableName, errors.WarnNoRowsAffected)
+	}
+	return total, nil
+}
+
+// sortModels 按排序选项合并排序, 未指定排序列时按主键升序
+func (sx *shardedGormX[T, ID, PT]) sortModels(ctx context.Context, models []PT, opts ...options.OrderOption) {
+	var m T
+	orderBy := options.NewOrderWithOptions(opts...).Build()
+	if orderBy == nil {
+		keyOrder := NewGormX[T, ID, PT](sx.db).clauseKeyOrderBuilder()
+		orderBy = &keyOrder
+	}
+
+	s, err := NewGormX[T, ID, PT](sx.db).parseSchema()
+	if err != nil {
+		log.Printf("sort sharded results failed. table: %s, error: %v", PT(&m).TableName(), err)
+		return
+	}
+	type sortField struct {
+		field *schema.Field
+		desc  bool
+	}
+	fields := make([]sortField, 0, len(orderBy.Columns))
+	for _, col := range orderBy.Columns {
+		field := s.LookUpField(col.Column.Name)
+		if field == nil {
+			// 表达式或未知列无法在内存中排序, 保持各分片内的顺序
+			log.Printf("sort sharded results: unknown column %q. table: %s", col.Column.Name, PT(&m).TableName())
+			continue
+		}
+		fields = append(fields, sortField{field: field, desc: col.Desc})
+	}
+
+	slices.SortStableFunc(models, func(a, b PT) int {
+		for _, f := range fields {
+			c := compareValues(f.field.ReflectValueOf(ctx, reflect.ValueOf(a)), f.field.ReflectValueOf(ctx, reflect.ValueOf(b)))
+			if f.desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// compareValues 比较两个字段值, nil 指针排在最前, 不支持的类型按字符串比较
+func compareValues(a, b reflect.Value) int {
+	if a.Kind() == reflect.Pointer {
+		switch {
+		case a.IsNil() && b.IsNil():
+			return 0
+		case a.IsNil():
+			return -1
+		case b.IsNil():
+			return 1
+		}
+		return compareValues(a.Elem(), b.Elem())
+	}
+
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmpOrdered(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmpOrdered(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmpOrdered(a.Float(), b.Float())
+	case reflect.String:
+		return strings.Compare(a.String(), b.String())
+	case reflect.Bool:
+		return cmpOrdered(boolToInt(a.Bool()), boolToInt(b.Bool()))
+	}
+	if a.Type() == timeType {
+		return a.Interface().(time.Time).Compare(b.Interface().(time.Time))
+	}
+	return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+}
+
+func cmpOrdered[V int64 | uint64 | float64](a, b V) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// isRecordNotFound 判断 GetByID 等方法返回的错误是否为记录不存在
+func isRecordNotFound(err error) bool {
+	var e *errors.Error
+	return stderrors.As(err, &e) && stderrors.Is(e.Cause, gorm.ErrRecordNotFound)
+}
+
+// getFirst 扇出单行查询, 跳过不存在记录的分片, 多个分片命中时按主键取第一个
+func (sx *shardedGormX[T, ID, PT]) getFirst(ctx context.Context, op string, targets []sharding.Target, fn func(ctx context.Context, gx *gormX[T, ID, PT]) (PT, error)) (PT, error) {
+	var notFound error
+	var mu sync.Mutex
+	models, err := sx.gather(ctx, op, targets, func(ctx context.Context, gx *gormX[T, ID, PT]) ([]PT, error) {
+		m, err := fn(ctx, gx)
+		if err != nil {
+			if isRecordNotFound(err) {
+				mu.Lock()
+				notFound = err
+				mu.Unlock()
+				return nil, nil
+			}
+			return nil, err
+		}
+		if m == nil {
+			return nil, nil
+		}
+		return []PT{m}, nil
+	})
+	if err != nil {
+		sx.logError(op, err)
+		return nil, err
+	}
+	if len(models) == 0 {
+		sx.logError(op, notFound)
+		return nil, notFound
+	}
+	sx.sortModels(ctx, models)
+	return models[0], nil
+}
+
+// GetDBWithContext 返回默认数据库的连接(或 ctx 中的事务), 不指定物理表
+func (sx *shardedGormX[T, ID, PT]) GetDBWithContext(ctx context.Context) *gorm.DB {
+	return NewGormX[T, ID, PT](sx.db).GetDBWithContext(ctx)
+}
+
+func (sx *shardedGormX[T, ID, PT]) InTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(contextTxKey{}).(*gorm.DB)
+	return ok
+}
+
+func (sx *shardedGormX[T, ID, PT]) Create(ctx context.Context, model PT, opts ...options.ConflictOption) (Result, error) {
+	if model == nil {
+		log.Printf("create failed : %s", errors.WarnInvalidModel)
+		return Result{}, nil
+	}
+	target, err := sx.routeModel(ctx, "Create", model)
+	if err != nil {
+		return Result{}, err
+	}
+	gx, err := sx.shardOf(ctx, "Create", target)
+	if err != nil {
+		return Result{}, err
+	}
+	res, err := gx.Create(ctx, model, opts...)
+	sx.logError("Create", err)
+	return res, err
+}
+
+func (sx *shardedGormX[T, ID, PT]) CreateInBatches(ctx context.Context, models []PT, batchSize int, opts ...options.ConflictOption) (Result, error) {
+	if len(models) == 0 {
+		log.Printf("create in batches failed : %s", errors.WarnEmptyModelsSlice)
+		return Result{}, nil
+	}
+	if batchSize <= 0 {
+		log.Printf("create in batches failed : %s", errors.WarnInvalidBatchSize)
+		return Result{}, nil
+	}
+
+	grouped, targets, err := sx.groupModels(ctx, "CreateInBatches", models)
+	if err != nil {
+		return Result{}, err
+	}
+	return sx.write(ctx, "CreateInBatches", targets, nil, func(ctx context.Context, gx *gormX[T, ID, PT]) (Result, error) {
+		return gx.CreateInBatches(ctx, grouped[gx], batchSize, opts...)
+	})
+}
+
+// groupModels 按分片键将模型分组
+func (sx *shardedGormX[T, ID, PT]) groupModels(ctx context.Context, op string, models []PT) (map[*gormX[T, ID, PT]][]PT, []sharding.Target, error) {
+	grouped := make(map[*gormX[T, ID, PT]][]PT)
+	var targets []sharding.Target
+	for i, m := range models {
+		if m == nil {
+			return nil, nil, errors.New(errors.ErrShardKeyRequired, op, sx.tableName, fmt.Errorf("models[%d]: %s", i, errors.WarnInvalidModel))
+		}
+		target, err := sx.routeModel(ctx, op, m)
+		if err != nil {
+			return nil, nil, err
+		}
+		gx, err := sx.shardOf(ctx, op, target)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, ok := grouped[gx]; !ok {
+			targets = append(targets, target)
+		}
+		grouped[gx] = append(grouped[gx], m)
+	}
+	return grouped, targets, nil
+}
+
+func (sx *shardedGormX[T, ID, PT]) GetByID(ctx context.Context, id ID) (PT, error) {
+	if model.IsZero(id) {
+		log.Printf("get by id failed : %s", errors.WarnInvalidID)
+		return nil, nil
+	}
+	grouped, err := sx.targetsOfIDs("GetByID", []ID{id})
+	if err != nil {
+		return nil, err
+	}
+	return sx.getFirst(ctx, "GetByID", mapsKeys(grouped), func(ctx context.Context, gx *gormX[T, ID, PT]) (PT, error) {
+		return gx.GetByID(ctx, id)
+	})
+}
+
+func (sx *shardedGormX[T, ID, PT]) FindByIDs(ctx context.Context, ids []ID, opts ...options.OrderOption) ([]PT, error) {
+	if len(ids) == 0 {
+		log.Printf("find by ids failed : %s", errors.WarnEmptyIDsSlice)
+		return nil, nil
+	}
+	grouped, err := sx.targetsOfIDs("FindByIDs", ids)
+	if err != nil {
+		return nil, err
+	}
+	byShard, err := sx.idsByShard(grouped)
+	if err != nil {
+		return nil, err
+	}
+	targets := mapsKeys(grouped)
+	models, err := sx.collect(ctx, "FindByIDs", targets, func(ctx context.Context, gx *gormX[T, ID, PT]) ([]PT, error) {
+		return gx.FindByIDs(ctx, byShard[gx], opts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) > 1 {
+		sx.sortModels(ctx, models, opts...)
+	}
+	return models, nil
+}
+
+func (sx *shardedGormX[T, ID, PT]) GetByStructFilter(ctx context.Context, filter PT) (PT, error) {
+	if filter == nil {
+		log.Printf("get by struct filter failed : %s", errors.WarnInvalidFilter)
+		return nil, nil
+	}
+	targets, err := sx.targetsOfModel(ctx, "GetByStructFilter", filter)
+	if err != nil {
+		return nil, err
+	}
+	return sx.getFirst(ctx, "GetByStructFilter", targets, func(ctx context.Context, gx *gormX[T, ID, PT]) (PT, error) {
+		return gx.GetByStructFilter(ctx, filter)
+	})
+}
+
+func (sx *shardedGormX[T, ID, PT]) FindByStructFilter(ctx context.Context, filter PT, opts ...options.OrderOption) ([]PT, error) {
+	if filter == nil {
+		log.Printf("find by struct filter failed : %s", errors.WarnInvalidFilter)
+		return nil, nil
+	}
+	targets, err := sx.targetsOfModel(ctx, "FindByStructFilter", filter)
+	if err != nil {
+		return nil, err
+	}
+	models, err := sx.collect(ctx, "FindByStructFilter", targets, func(ctx context.Context, gx *gormX[T, ID, PT]) ([]PT, error) {
+		return gx.FindByStructFilter(ctx, filter, opts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) > 1 {
+		sx.sortModels(ctx, models, opts...)
+	}
+	return models, nil
+}
+
+func (sx *shardedGormX[T, ID, PT]) GetByMapFilter(ctx context.Context, filter map[string]any) (PT, error) {
+	if len(filter) == 0 {
+		log.Printf("get by map filter failed : %s", errors.WarnInvalidFilter)
+		return nil, nil
+	}
+	targets, err := sx.targetsOfMap("GetByMapFilter", filter)
+	if err != nil {
+		return nil, err
+	}
+	return sx.getFirst(ctx, "GetByMapFilter", targets, func(ctx context.Context, gx *gormX[T, ID, PT]) (PT, error) {
+		return gx.GetByMapFilter(ctx, filter)
+	})
+}
+
+func (sx *shardedGormX[T, ID, PT]) FindByMapFilter(ctx context.Context, filter map[string]any, opts ...options.OrderOption) ([]PT, error) {
+	if len(filter) == 0 {
+		log.Printf("find by map filter failed : %s", errors.WarnInvalidFilter)
+		return nil, nil
+	}
+	targets, err := sx.targetsOfMap("FindByMapFilter", filter)
+	if err != nil {
+		return nil, err
+	}
+	models, err := sx.collect(ctx, "FindByMapFilter", targets, func(ctx context.Context, gx *gormX[T, ID, PT]) ([]PT, error) {
+		return gx.FindByMapFilter(ctx, filter, opts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) > 1 {
+		sx.sortModels(ctx, models, opts...)
+	}
+	return models, nil
+}
+
+// FindByPage 从每个分片读取前 page*pageSize 行, 合并排序后截取目标页, 页码越大代价越高
+func (sx *shardedGormX[T, ID, PT]) FindByPage(ctx context.Context, page, pageSize int, opts ...options.OrderOption) ([]PT, error) {
+	if page <= 0 || pageSize <= 0 {
+		log.Printf("find by page %d, pageSize %d failed : %s", page, pageSize, errors.WarnInvalidPageParams)
+		return nil, nil
+	}
+	targets, err := sx.fanOut("FindByPage")
+	if err != nil {
+		return nil, err
+	}
+	models, err := sx.collect(ctx, "FindByPage", targets, func(ctx context.Context, gx *gormX[T, ID, PT]) ([]PT, error) {
+		return gx.FindByPage(ctx, 1, page*pageSize, opts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sx.sortModels(ctx, models, opts...)
+	offset := (page - 1) * pageSize
+	if offset >= len(models) {
+		return make([]PT, 0), nil
+	}
+	return models[offset:min(offset+pageSize, len(models))], nil
+}
+
+func (sx *shardedGormX[T, ID, PT]) FindByCursor(ctx context.Context, cursor ID, limit int) ([]PT, ID, bool, error) {
+	if limit <= 0 {
+		log.Printf("find by cursor failed : %s", errors.WarnInvalidLimit)
+		return nil, cursor, false, nil
+	}
+	if model.IsZero(cursor) {
+		log.Printf("find by cursor failed : %s", errors.WarnInvalidID)
+		return nil, cursor, false, nil
+	}
+	targets, err := sx.fanOut("FindByCursor")
+	if err != nil {
+		return nil, cursor, false, err
+	}
+
+	var shardHasMore bool
+	var mu sync.Mutex
+	models, err := sx.collect(ctx, "FindByCursor", targets, func(ctx context.Context, gx *gormX[T, ID, PT]) ([]PT, error) {
+		models, _, hasMore, err := gx.FindByCursor(ctx, cursor, limit)
+		mu.Lock()
+		shardHasMore = shardHasMore || hasMore
+		mu.Unlock()
+		return models, err
+	})
+	if err != nil {
+		return nil, cursor, false, err
+	}
+	sx.sortModels(ctx, models)
+	hasMore := shardHasMore || len(models) > limit
+	if len(models) > limit {
+		models = models[:limit]
+	}
+	newCursor := cursor
+	if len(models) > 0 {
+		newCursor = models[len(models)-1].GetID()
+	}
+	return models, newCursor, hasMore, nil
+}
+
+func (sx *shardedGormX[T, ID, PT]) Update(ctx context.Context, updateData PT, opts ...options.MutationOption) (Result, error) {
+	if updateData == nil {
+		log.Printf("update failed : %s", errors.WarnInvalidUpdateData)
+		return Result{}, nil
+	}
+	targets, err := sx.targetsOfModel(ctx, "Update", updateData)
+	if err != nil {
+		return Result{}, err
+	}
+	return sx.write(ctx, "Update", targets, opts, func(ctx context.Context, gx *gormX[T, ID, PT]) (Result, error) {
+		return gx.Update(ctx, updateData)
+	})
+}
+
+func (sx *shardedGormX[T, ID, PT]) UpdateFields(ctx context.Context, updateData PT, fields []string, opts ...options.MutationOption) (Result, error) {
+	if updateData == nil {
+		log.Printf("update fields failed : %s", errors.WarnInvalidUpdateData)
+		return Result{}, nil
+	}
+	targets, err := sx.targetsOfModel(ctx, "UpdateFields", updateData)
+	if err != nil {
+		return Result{}, err
+	}
+	return sx.write(ctx, "UpdateFields", targets, opts, func(ctx context.Context, gx *gormX[T, ID, PT]) (Result, error) {
+		return gx.UpdateFields(ctx, updateData, fields)
+	})
+}
+
+func (sx *shardedGormX[T, ID, PT]) Save(ctx context.Context, model PT, opts ...options.MutationOption) (Result, error) {
+	if model == nil {
+		log.Printf("save failed : %s", errors.WarnInvalidModel)
+		return Result{}, nil
+	}
+	// Save 可能插入新行, 按模型的分片键路由
+	target, err := sx.routeModel(ctx, "Save", model)
+	if err != nil {
+		return Result{}, err
+	}
+	gx, err := sx.shardOf(ctx, "Save", target)
+	if err != nil {
+		return Result{}, err
+	}
+	res, err := gx.Save(ctx, model, opts...)
+	sx.logError("Save", err)
+	return res, err
+}
+
+func (sx *shardedGormX[T, ID, PT]) PatchByID(ctx context.Context, id ID, patch []byte, patchable ...string) (PT, error) {
+	if model.IsZero(id) {
+		log.Printf("patch by id failed : %s", errors.WarnInvalidID)
+		return nil, nil
+	}
+	for _, name := range patchable {
+		if name == sx.key.DBName || name == sx.key.Name {
+			return nil, errors.New(errors.ErrShardKeyImmutable, "PatchByID", sx.tableName, fmt.Errorf("shard key %q cannot be patchable", sx.key.DBName))
+		}
+	}
+
+	grouped, err := sx.targetsOfIDs("PatchByID", []ID{id})
+	if err != nil {
+		return nil, err
+	}
+	targets := mapsKeys(grouped)
+	if len(targets) > 1 {
+		// 主键不包含分片键, 先找到行所在的分片
+		current, err := sx.getFirst(ctx, "PatchByID", targets, func(ctx context.Context, gx *gormX[T, ID, PT]) (PT, error) {
+			return gx.GetByID(ctx, id)
+		})
+		if err != nil {
+			return nil, err
+		}
+		target, err := sx.routeModel(ctx, "PatchByID", current)
+		if err != nil {
+			return nil, err
+		}
+		targets = []sharding.Target{target}
+	}
+	gx, err := sx.shardOf(ctx, "PatchByID", targets[0])
+	if err != nil {
+		return nil, err
+	}
+	patched, err := gx.PatchByID(ctx, id, patch, patchable...)
+	sx.logError("PatchByID", err)
+	return patched, err
+}
+
+func (sx *shardedGormX[T, ID, PT]) UpdateInBatches(ctx context.Context, models []PT, fields []string, batchSize int, opts ...options.MutationOption) (Result, error) {
+	if len(models) == 0 {
+		log.Printf("update in batches failed : %s", errors.WarnEmptyModelsSlice)
+		return Result{}, nil
+	}
+	grouped, targets, err := sx.groupModels(ctx, "UpdateInBatches", models)
+	if err != nil {
+		return Result{}, err
+	}
+	return sx.write(ctx, "UpdateInBatches", targets, opts, func(ctx context.Context, gx *gormX[T, ID, PT]) (Result, error) {
+		return gx.UpdateInBatches(ctx, grouped[gx], fields, batchSize)
+	})
+}
+
+func (sx *shardedGormX[T, ID, PT]) UpdateByStructFilter(ctx context.Context, filter PT, updateData PT, opts ...options.MutationOption) (Result, error) {
+	if updateData == nil {
+		log.Printf("update by struct filter failed : %s", errors.WarnInvalidUpdateData)
+		return Result{}, nil
+	}
+	if filter == nil {
+		log.Printf("update by struct filter failed : %s", errors.WarnInvalidFilter)
+		return Result{}, nil
+	}
+	if _, ok := sx.keyOfModel(ctx, updateData); ok {
+		return Result{}, errors.New(errors.ErrShardKeyImmutable, "UpdateByStructFilter", sx.tableName, fmt.Errorf("update data sets shard key %q", sx.key.DBName))
+	}
+	targets, err := sx.targetsOfModel(ctx, "UpdateByStructFilter", filter)
+	if err != nil {
+		return Result{}, err
+	}
+	return sx.write(ctx, "UpdateByStructFilter", targets, opts, func(ctx context.Context, gx *gormX[T, ID, PT]) (Result, error) {
+		return gx.UpdateByStructFilter(ctx, filter, updateData)
+	})
+}
+
+func (sx *shardedGormX[T, ID, PT]) UpdateByMapFilter(ctx context.Context, filter map[string]any, updateData map[string]any, opts ...options.MutationOption) (Result, error) {
+	if len(updateData) == 0 {
+		log.Printf("update by map filter failed : %s", errors.WarnInvalidUpdateData)
+		return Result{}, nil
+	}
+	if len(filter) == 0 {
+		log.Printf("update by map filter failed : %s", errors.WarnInvalidFilter)
+		return Result{}, nil
+	}
+	_, byColumn := updateData[sx.key.DBName]
+	_, byName := updateData[sx.key.Name]
+	if byColumn || byName {
+		return Result{}, errors.New(errors.ErrShardKeyImmutable, "UpdateByMapFilter", sx.tableName, fmt.Errorf("update data sets shard key %q", sx.key.DBName))
+	}
+	targets, err := sx.targetsOfMap("UpdateByMapFilter", filter)
+	if err != nil {
+		return Result{}, err
+	}
+	return sx.write(ctx, "UpdateByMapFilter", targets, opts, func(ctx context.Context, gx *gormX[T, ID, PT]) (Result, error) {
+		return gx.UpdateByMapFilter(ctx, filter, updateData)
+	})
+}
+
+func (sx *shardedGormX[T, ID, PT]) DeleteByID(ctx context.Context, id ID, opts ...options.MutationOption) (Result, error) {
+	if model.IsZero(id) {
+		log.Printf("delete by id failed : %s", errors.WarnInvalidID)
+		return Result{}, nil
+	}
+	grouped, err := sx.targetsOfIDs("DeleteByID", []ID{id})
+	if err != nil {
+		return Result{}, err
+	}
+	return sx.write(ctx, "DeleteByID", mapsKeys(grouped), opts, func(ctx context.Context, gx *gormX[T, ID, PT]) (Result, error) {
+		return gx.DeleteByID(ctx, id)
+	})
+}
+
+func (sx *shardedGormX[T, ID, PT]) DeleteByIDs(ctx context.Context, ids []ID, opts ...options.MutationOption) (Result, error) {
+	if len(ids) == 0 {
+		log.Printf("delete by ids failed : %s", errors.WarnEmptyIDsSlice)
+		return Result{}, nil
+	}
+	grouped, err := sx.targetsOfIDs("DeleteByIDs", ids)
+	if err != nil {
+		return Result{}, err
+	}
+	byShard, err := sx.idsByShard(grouped)
+	if err != nil {
+		return Result{}, err
+	}
+	return sx.write(ctx, "DeleteByIDs", mapsKeys(grouped), opts, func(ctx context.Context, gx *gormX[T, ID, PT]) (Result, error) {
+		return gx.DeleteByIDs(ctx, byShard[gx])
+	})
+}
+
+func (sx *shardedGormX[T, ID, PT]) DeleteByStructFilter(ctx context.Context, filter PT, opts ...options.MutationOption) (Result, error) {
+	if filter == nil {
+		log.Printf("delete by struct filter failed : %s", errors.WarnInvalidFilter)
+		return Result{}, nil
+	}
+	targets, err := sx.targetsOfModel(ctx, "DeleteByStructFilter", filter)
+	if err != nil {
+		return Result{}, err
+	}
+	return sx.write(ctx, "DeleteByStructFilter", targets, opts, func(ctx context.Context, gx *gormX[T, ID, PT]) (Result, error) {
+		return gx.DeleteByStructFilter(ctx, filter)
+	})
+}
+
+func (sx *shardedGormX[T, ID, PT]) DeleteByMapFilter(ctx context.Context, filter map[string]any, opts ...options.MutationOption) (Result, error) {
+	if len(filter) == 0 {
+		log.Printf("delete by map filter failed : %s", errors.WarnInvalidFilter)
+		return Result{}, nil
+	}
+	targets, err := sx.targetsOfMap("DeleteByMapFilter", filter)
+	if err != nil {
+		return Result{}, err
+	}
+	return sx.write(ctx, "DeleteByMapFilter", targets, opts, func(ctx context.Context, gx *gormX[T, ID, PT]) (Result, error) {
+		return gx.DeleteByMapFilter(ctx, filter)
+	})
+}
+
+// mapsKeys 返回分组后的分片, 保持稳定的顺序
+func mapsKeys[V any](grouped map[sharding.Target]V) []sharding.Target {
+	targets := make([]sharding.Target, 0, len(grouped))
+	for target := range grouped {
+		targets = append(targets, target)
+	}
+	slices.SortFunc(targets, func(a, b sharding.Target) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	return targets
+}
@@ -0,0 +1,138 @@
+package internal
+
+import (
+	stderrors "errors"
+	"fmt"
+
+	"github.com/LouYuanbo1/go-webservice/gormx/errors"
+	"github.com/LouYuanbo1/go-webservice/gormx/model"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm/schema"
+)
+
+// validator.Validate 并发安全且会缓存结构体信息, 全局共享一个实例
+var structValidator = validator.New(validator.WithRequiredStructEnabled())
+
+// validateModel 执行 `validate` 标签校验和 model.Validator 校验, 返回收集到的字段级错误
+func validateModel(m any) (errors.FieldErrors, error) {
+	var fieldErrs errors.FieldErrors
+
+	if err := structValidator.Struct(m); err != nil {
+		tagErrs, err := toFieldErrors(err)
+		if err != nil {
+			return nil, err
+		}
+		fieldErrs = append(fieldErrs, tagErrs...)
+	}
+
+	if v, ok := m.(model.Validator); ok {
+		if err := v.Validate(); err != nil {
+			var customErrs errors.FieldErrors
+			if stderrors.As(err, &customErrs) {
+				fieldErrs = append(fieldErrs, customErrs...)
+			} else {
+				fieldErrs = append(fieldErrs, errors.FieldError{Message: err.Error()})
+			}
+		}
+	}
+
+	return fieldErrs, nil
+}
+
+// toFieldErrors 将 validator.ValidationErrors 转换为 errors.FieldErrors
+func toFieldErrors(err error) (errors.FieldErrors, error) {
+	var validationErrs validator.ValidationErrors
+	if !stderrors.As(err, &validationErrs) {
+		// InvalidValidationError 等非字段错误, 说明模型本身不可校验
+		return nil, err
+	}
+	fieldErrs := make(errors.FieldErrors, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fieldErrs = append(fieldErrs, errors.FieldError{
+			Field:   fe.StructField(),
+			Tag:     fe.Tag(),
+			Param:   fe.Param(),
+			Message: validationMessage(fe),
+		})
+	}
+	return fieldErrs, nil
+}
+
+func validationMessage(fe validator.FieldError) string {
+	if fe.Param() != "" {
+		return fmt.Sprintf("failed on the '%s=%s' tag", fe.Tag(), fe.Param())
+	}
+	return fmt.Sprintf("failed on the '%s' tag", fe.Tag())
+}
+
+// Validate 校验单个模型, 失败时返回包含 errors.FieldErrors 的 ErrValidation 错误
+func Validate(op, tableName string, m any) error {
+	fieldErrs, err := validateModel(m)
+	if err != nil {
+		return errors.New(errors.ErrValidation, op, tableName, err)
+	}
+	if len(fieldErrs) > 0 {
+		return errors.New(errors.ErrValidation, op, tableName, fieldErrs)
+	}
+	return nil
+}
+
+// ValidateBatch 校验一批模型, 字段名以 "[i]." 前缀标明所在下标
+func ValidateBatch[T any, PT interface{ *T }](op, tableName string, models []PT) error {
+	var fieldErrs errors.FieldErrors
+	for i, m := range models {
+		if m == nil {
+			continue
+		}
+		errs, err := validateModel(m)
+		if err != nil {
+			return errors.New(errors.ErrValidation, op, tableName, err)
+		}
+		fieldErrs = append(fieldErrs, indexFieldErrors(i, errs)...)
+	}
+	if len(fieldErrs) > 0 {
+		return errors.New(errors.ErrValidation, op, tableName, fieldErrs)
+	}
+	return nil
+}
+
+// ValidateFieldsBatch 只校验一批模型中指定字段的 `validate` 标签, 字段名带 "[i]." 前缀
+func ValidateFieldsBatch[T any, PT interface{ *T }](op, tableName string, models []PT, fields []*schema.Field) error {
+	var fieldErrs errors.FieldErrors
+	for i, m := range models {
+		if m == nil {
+			continue
+		}
+		errs, err := validatePartial(m, fields)
+		if err != nil {
+			return errors.New(errors.ErrValidation, op, tableName, err)
+		}
+		fieldErrs = append(fieldErrs, indexFieldErrors(i, errs)...)
+	}
+	if len(fieldErrs) > 0 {
+		return errors.New(errors.ErrValidation, op, tableName, fieldErrs)
+	}
+	return nil
+}
+
+func validatePartial(m any, fields []*schema.Field) (errors.FieldErrors, error) {
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		names = append(names, field.Name)
+	}
+	if err := structValidator.StructPartial(m, names...); err != nil {
+		return toFieldErrors(err)
+	}
+	return nil, nil
+}
+
+func indexFieldErrors(i int, errs errors.FieldErrors) errors.FieldErrors {
+	for j := range errs {
+		if errs[j].Field != "" {
+			errs[j].Field = fmt.Sprintf("[%d].%s", i, errs[j].Field)
+		} else {
+			errs[j].Field = fmt.Sprintf("[%d]", i)
+		}
+	}
+	return errs
+}
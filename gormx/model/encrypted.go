@@ -0,0 +1,216 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Cipher 是 Encrypted 使用的加密器, cryptutil.KeyRing 实现了该接口
+type Cipher interface {
+	Seal(plaintext []byte) ([]byte, error)
+	Open(ciphertext []byte) ([]byte, error)
+	BlindIndex(plaintext []byte) (string, error)
+}
+
+var (
+	defaultCipher atomic.Pointer[Cipher]
+
+	ErrCipherNotSet = errors.New("model: cipher is not set, call model.SetCipher first")
+)
+
+// SetCipher 设置 Encrypted 与 BlindIndexOf 使用的加密器, 通常在启动时调用一次
+func SetCipher(c Cipher) {
+	defaultCipher.Store(&c)
+}
+
+func currentCipher() (Cipher, error) {
+	c := defaultCipher.Load()
+	if c == nil || *c == nil {
+		return nil, ErrCipherNotSet
+	}
+	return *c, nil
+}
+
+/*
+Encrypted 在写入时加密, 读取时解密, 列中保存 base64 编码的密文(TEXT).
+值先按 JSON 编码再加密, 因此 T 可以是任意可 JSON 序列化的类型.
+JSON, gob 与 msgpack 编码同样输出密文, 缓存或传输模型时不会泄露明文.
+同一个值每次加密得到不同的密文, 不能直接按密文查询, 需要等值查询时额外保存盲索引列(见 BlindIndexOf).
+
+Encrypted encrypts T with the cipher set by SetCipher on write and decrypts on scan.
+
+Example:
+
+	keyRing, err := cryptutil.NewKeyRing(cfg.KeyRing)
+	if err != nil {
+		return err
+	}
+	model.SetCipher(keyRing)
+
+	type User struct {
+		ID         uint64
+		Phone      model.Encrypted[string]
+		PhoneIndex string `gorm:"size:64;index"`
+	}
+
+	func (u *User) BeforeSave(tx *gorm.DB) (err error) {
+		u.PhoneIndex, err = u.Phone.BlindIndex()
+		return err
+	}
+
+	index, err := model.BlindIndexOf("13800000000")
+	user, err := gx.GetByMapFilter(ctx, map[string]any{"phone_index": index})
+*/
+type Encrypted[T any] struct {
+	Data T
+}
+
+func NewEncrypted[T any](data T) Encrypted[T] {
+	return Encrypted[T]{Data: data}
+}
+
+// Scan 实现 sql.Scanner, 解密 base64 密文, NULL 与空字符串得到 T 的零值
+func (e *Encrypted[T]) Scan(src any) error {
+	var encoded string
+	switch v := src.(type) {
+	case nil:
+	case []byte:
+		encoded = string(v)
+	case string:
+		encoded = v
+	default:
+		return fmt.Errorf("model.Encrypted: cannot scan %T", src)
+	}
+	if encoded == "" {
+		var zero T
+		e.Data = zero
+		return nil
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("model.Encrypted: %w", err)
+	}
+	return e.open(ciphertext)
+}
+
+// Value 实现 driver.Valuer, 返回 base64 编码的密文
+func (e Encrypted[T]) Value() (driver.Value, error) {
+	ciphertext, err := e.seal()
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// BlindIndex 返回 Data 的盲索引, 等同于 BlindIndexOf(e.Data)
+func (e Encrypted[T]) BlindIndex() (string, error) {
+	return BlindIndexOf(e.Data)
+}
+
+/*
+MarshalJSON 输出 base64 编码的密文(与列中保存的内容相同)而不是明文,
+模型被 RedisX 缓存, 发布或写入队列时 Data 同样保持加密, 明文只能通过 Data 读取.
+*/
+func (e Encrypted[T]) MarshalJSON() ([]byte, error) {
+	encoded, err := e.Value()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON 解密 MarshalJSON 输出的密文, null 得到 T 的零值
+func (e *Encrypted[T]) UnmarshalJSON(raw []byte) error {
+	var encoded *string
+	if err := json.Unmarshal(raw, &encoded); err != nil {
+		return fmt.Errorf("model.Encrypted: %w", err)
+	}
+	if encoded == nil {
+		return e.Scan(nil)
+	}
+	return e.Scan(*encoded)
+}
+
+// MarshalBinary 输出密文, 供 gob 与 msgpack 编码使用, 与 MarshalJSON 一样不输出明文
+func (e Encrypted[T]) MarshalBinary() ([]byte, error) {
+	return e.seal()
+}
+
+// UnmarshalBinary 解密 MarshalBinary 输出的密文
+func (e *Encrypted[T]) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		var zero T
+		e.Data = zero
+		return nil
+	}
+	return e.open(data)
+}
+
+// seal 按 JSON 编码 Data 后加密
+func (e Encrypted[T]) seal() ([]byte, error) {
+	c, err := currentCipher()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := json.Marshal(e.Data)
+	if err != nil {
+		return nil, fmt.Errorf("model.Encrypted: %w", err)
+	}
+	ciphertext, err := c.Seal(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("model.Encrypted: %w", err)
+	}
+	return ciphertext, nil
+}
+
+// open 解密并按 JSON 解码到 Data
+func (e *Encrypted[T]) open(ciphertext []byte) error {
+	c, err := currentCipher()
+	if err != nil {
+		return err
+	}
+	plaintext, err := c.Open(ciphertext)
+	if err != nil {
+		return fmt.Errorf("model.Encrypted: %w", err)
+	}
+	var data T
+	if err := json.Unmarshal(plaintext, &data); err != nil {
+		return fmt.Errorf("model.Encrypted: %w", err)
+	}
+	e.Data = data
+	return nil
+}
+
+func (Encrypted[T]) GormDataType() string {
+	return "text"
+}
+
+// GormDBDataType 密文长度不固定, 所有方言都使用 TEXT
+func (Encrypted[T]) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	return "TEXT"
+}
+
+/*
+BlindIndexOf 返回值的盲索引(按 JSON 编码后计算 HMAC), 与 Encrypted[T] 中相同的值得到相同的结果,
+用于写入盲索引列以及在过滤器中按盲索引列等值查询.
+
+BlindIndexOf returns the HMAC blind index of v for equality lookups on encrypted columns.
+*/
+func BlindIndexOf[T any](v T) (string, error) {
+	c, err := currentCipher()
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("model.BlindIndexOf: %w", err)
+	}
+	return c.BlindIndex(plaintext)
+}
@@ -0,0 +1,174 @@
+package model_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/LouYuanbo1/go-webservice/cryptutil"
+	"github.com/LouYuanbo1/go-webservice/cryptutil/config"
+	"github.com/LouYuanbo1/go-webservice/gormx/model"
+)
+
+type profile struct {
+	Phone model.Encrypted[string]
+	Tags  model.Encrypted[[]string]
+}
+
+func setCipher(t *testing.T) cryptutil.KeyRing {
+	t.Helper()
+	key := func(b byte) string { return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32)) }
+	kr, err := cryptutil.NewKeyRing(config.KeyRingConfig{
+		PrimaryKeyID:  "k1",
+		Keys:          []config.KeyConfig{{ID: "k1", Key: key(1)}},
+		BlindIndexKey: config.KeyConfig{Key: key(9)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	model.SetCipher(kr)
+	t.Cleanup(func() { model.SetCipher(nil) })
+	return kr
+}
+
+func TestEncryptedValueScan(t *testing.T) {
+	setCipher(t)
+	value, err := model.NewEncrypted("13800000000").Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, ok := value.(string)
+	if !ok || strings.Contains(encoded, "13800000000") {
+		t.Fatalf("value = %#v, want base64 ciphertext", value)
+	}
+
+	for _, src := range []any{encoded, []byte(encoded)} {
+		var got model.Encrypted[string]
+		if err := got.Scan(src); err != nil {
+			t.Fatal(err)
+		}
+		if got.Data != "13800000000" {
+			t.Errorf("scan %T = %q", src, got.Data)
+		}
+	}
+
+	// NULL 与空字符串得到零值, 覆盖之前的数据
+	for _, src := range []any{nil, "", []byte{}} {
+		got := model.NewEncrypted("stale")
+		if err := got.Scan(src); err != nil {
+			t.Fatalf("scan %#v: %v", src, err)
+		}
+		if got.Data != "" {
+			t.Errorf("scan %#v = %q, want zero value", src, got.Data)
+		}
+	}
+
+	var got model.Encrypted[string]
+	if err := got.Scan("not base64!"); err == nil {
+		t.Error("expected an error for invalid base64")
+	}
+	if err := got.Scan(42); err == nil {
+		t.Error("expected an error for an unsupported type")
+	}
+	tampered := []byte(encoded)
+	tampered[len(tampered)-3] ^= 1
+	if err := got.Scan(string(tampered)); !errors.Is(err, cryptutil.ErrInvalidCiphertext) {
+		t.Errorf("expected ErrInvalidCiphertext, got %v", err)
+	}
+}
+
+func TestEncryptedMarshalJSON(t *testing.T) {
+	setCipher(t)
+	in := profile{Phone: model.NewEncrypted("13800000000"), Tags: model.NewEncrypted([]string{"vip"})}
+	raw, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "13800000000") || strings.Contains(string(raw), "vip") {
+		t.Fatalf("json contains plaintext: %s", raw)
+	}
+	var out profile
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Phone.Data != "13800000000" || len(out.Tags.Data) != 1 || out.Tags.Data[0] != "vip" {
+		t.Errorf("round trip = %+v", out)
+	}
+
+	out.Phone = model.NewEncrypted("stale")
+	if err := json.Unmarshal([]byte(`{"Phone":null}`), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Phone.Data != "" {
+		t.Errorf("null = %q, want zero value", out.Phone.Data)
+	}
+}
+
+func TestEncryptedMarshalBinary(t *testing.T) {
+	kr := setCipher(t)
+	ciphertext, err := model.NewEncrypted("13800000000").MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := kr.KeyID(ciphertext); err != nil || id != "k1" {
+		t.Fatalf("MarshalBinary is not a ciphertext: key id %q, %v", id, err)
+	}
+
+	var buf bytes.Buffer
+	in := profile{Phone: model.NewEncrypted("13800000000"), Tags: model.NewEncrypted([]string{"vip"})}
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("13800000000")) {
+		t.Fatal("gob output contains plaintext")
+	}
+	var out profile
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Phone.Data != "13800000000" || len(out.Tags.Data) != 1 {
+		t.Errorf("gob round trip = %+v", out)
+	}
+
+	empty := model.NewEncrypted("stale")
+	if err := empty.UnmarshalBinary(nil); err != nil || empty.Data != "" {
+		t.Errorf("unmarshal empty = %q, %v", empty.Data, err)
+	}
+}
+
+func TestBlindIndexOf(t *testing.T) {
+	setCipher(t)
+	a, err := model.BlindIndexOf("13800000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := model.BlindIndexOf("13800000000")
+	fromField, _ := model.NewEncrypted("13800000000").BlindIndex()
+	other, _ := model.BlindIndexOf("13900000000")
+	if a != again || a != fromField {
+		t.Errorf("blind index is not stable: %s, %s, %s", a, again, fromField)
+	}
+	if a == other {
+		t.Error("different values share a blind index")
+	}
+	// 按 JSON 编码计算, 字符串 "1" 与数字 1 不同
+	str, _ := model.BlindIndexOf("1")
+	num, _ := model.BlindIndexOf(1)
+	if str == num {
+		t.Error("string and number share a blind index")
+	}
+}
+
+func TestCipherNotSet(t *testing.T) {
+	model.SetCipher(nil)
+	if _, err := model.NewEncrypted("x").Value(); !errors.Is(err, model.ErrCipherNotSet) {
+		t.Errorf("value: expected ErrCipherNotSet, got %v", err)
+	}
+	if _, err := model.BlindIndexOf("x"); !errors.Is(err, model.ErrCipherNotSet) {
+		t.Errorf("blind index: expected ErrCipherNotSet, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+/*
+JSON 将任意类型 T 以 JSON 形式存储在一列中, 实现了 sql.Scanner, driver.Valuer, json.Marshaler 与 gorm 的数据类型接口.
+列类型在 Postgres 中为 JSONB, MySQL 中为 JSON, SQLite 中为 TEXT.
+JSON 序列化时与 T 相同, 不会多一层 {"Data": ...}.
+
+JSON stores any T as a JSON column: JSONB on Postgres, JSON on MySQL and TEXT on SQLite.
+
+Example:
+
+	type Address struct {
+		City   string `json:"city"`
+		Street string `json:"street"`
+	}
+
+	type User struct {
+		ID      uint64
+		Address model.JSON[Address]
+	}
+
+	user := &User{Address: model.NewJSON(Address{City: "Shanghai"})}
+	city := user.Address.Data.City
+
+查询 JSON 内部的字段时, 在 Map 过滤器中使用 JSONPath 构建键:
+
+	users, err := gx.FindByMapFilter(ctx, map[string]any{
+		model.JSONPath("address", "city"): "Shanghai",
+	})
+*/
+type JSON[T any] struct {
+	Data T
+}
+
+func NewJSON[T any](data T) JSON[T] {
+	return JSON[T]{Data: data}
+}
+
+// Scan 实现 sql.Scanner, NULL 与空值得到 T 的零值
+func (j *JSON[T]) Scan(src any) error {
+	var data T
+	var raw []byte
+	switch v := src.(type) {
+	case nil:
+		j.Data = data
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("model.JSON: cannot scan %T", src)
+	}
+	if len(raw) == 0 {
+		j.Data = data
+		return nil
+	}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return fmt.Errorf("model.JSON: %w", err)
+	}
+	j.Data = data
+	return nil
+}
+
+// Value 实现 driver.Valuer, 返回 JSON 文本
+func (j JSON[T]) Value() (driver.Value, error) {
+	raw, err := json.Marshal(j.Data)
+	if err != nil {
+		return nil, fmt.Errorf("model.JSON: %w", err)
+	}
+	return string(raw), nil
+}
+
+func (j JSON[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.Data)
+}
+
+func (j *JSON[T]) UnmarshalJSON(raw []byte) error {
+	return json.Unmarshal(raw, &j.Data)
+}
+
+// GormDataType 返回通用数据类型
+func (JSON[T]) GormDataType() string {
+	return "json"
+}
+
+// GormDBDataType 返回方言对应的列类型
+func (JSON[T]) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return "JSONB"
+	case "mysql":
+		return "JSON"
+	default:
+		return "TEXT"
+	}
+}
+
+// JSONPathSeparator 分隔 Map 过滤器键中的列名与 JSON 路径, 例如 "address->city"
+const JSONPathSeparator = "->"
+
+/*
+JSONPath 构建查询 JSON 列内部字段的 Map 过滤器键, 路径中的每一段为对象的键或数组下标,
+例如 JSONPath("address", "tags", "0") 得到 "address->tags.0". 路径段中不能包含 ".".
+
+JSONPath builds a map filter key that queries a path inside a JSON column.
+*/
+func JSONPath(column string, path ...string) string {
+	return column + JSONPathSeparator + strings.Join(path, ".")
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+/*
+复合主键支持
+
+当 ID 是一个结构体时(time.Time 除外), GormX 将其视为复合主键:
+结构体的每个导出字段对应一个主键列, 列名取自 `gorm:"column:xxx"` 标签, 未指定时按 gorm 默认命名策略转为 snake_case.
+此时 PrimaryKey() 仅用于展示(建议返回 "user_id,role_id"), GetByID, FindByIDs, DeleteByID(s),
+FindByCursor 和 Upsert 会根据 ID 结构体自动构建多列条件.
+
+When ID is a struct (except time.Time), GormX treats it as a composite primary key:
+every exported field of the struct maps to one key column, named by the `gorm:"column:xxx"` tag
+or by gorm's default snake_case naming strategy.
+
+Example:
+
+	type UserRoleID struct {
+		UserID uint64
+		RoleID uint64
+	}
+
+	type UserRole struct {
+		UserID    uint64 `gorm:"primaryKey"`
+		RoleID    uint64 `gorm:"primaryKey"`
+		CreatedAt time.Time
+	}
+
+	func (m *UserRole) TableName() string  { return "user_roles" }
+	func (m *UserRole) PrimaryKey() string { return "user_id,role_id" }
+	func (m *UserRole) GetID() UserRoleID  { return UserRoleID{UserID: m.UserID, RoleID: m.RoleID} }
+*/
+
+type keyField struct {
+	index  int
+	column string
+}
+
+// 按 ID 类型缓存解析结果 reflect.Type -> []keyField
+var keyFieldsCache sync.Map
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// IsComposite 判断 ID 是否为复合主键(结构体类型)
+func IsComposite[ID comparable]() bool {
+	t := reflect.TypeFor[ID]()
+	return t.Kind() == reflect.Struct && t != timeType
+}
+
+// KeyColumns 返回模型的主键列名, 复合主键按 ID 结构体字段顺序返回
+func KeyColumns[ID comparable](m Model[ID]) []string {
+	if !IsComposite[ID]() {
+		return []string{m.PrimaryKey()}
+	}
+	fields := compositeKeyFields(reflect.TypeFor[ID]())
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.column
+	}
+	return columns
+}
+
+// KeyValues 返回主键值, 顺序与 KeyColumns 一致
+func KeyValues[ID comparable](id ID) []any {
+	if !IsComposite[ID]() {
+		return []any{id}
+	}
+	v := reflect.ValueOf(id)
+	fields := compositeKeyFields(v.Type())
+	values := make([]any, len(fields))
+	for i, f := range fields {
+		values[i] = v.Field(f.index).Interface()
+	}
+	return values
+}
+
+func compositeKeyFields(t reflect.Type) []keyField {
+	if cached, ok := keyFieldsCache.Load(t); ok {
+		return cached.([]keyField)
+	}
+
+	naming := schema.NamingStrategy{}
+	fields := make([]keyField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		settings := schema.ParseTagSetting(sf.Tag.Get("gorm"), ";")
+		if settings["-"] == "-" {
+			continue
+		}
+		column := settings["COLUMN"]
+		if column == "" {
+			column = naming.ColumnName("", sf.Name)
+		}
+		fields = append(fields, keyField{index: i, column: column})
+	}
+
+	keyFieldsCache.Store(t, fields)
+	return fields
+}
@@ -1,9 +1,12 @@
 package model
 
+import "github.com/LouYuanbo1/go-webservice/gormx/sharding"
+
 /*
 GetID() returns the primary key value of the model.
 GetPrimaryKey() returns the primary key name of the model.
 TableName() returns the table name of the model.
+For composite primary keys, ID is a struct of key fields, see key.go.
 
 Example:
 
@@ -56,3 +59,65 @@ func IsZero[ID comparable](id ID) bool {
 	var zero ID
 	return id == zero
 }
+
+/*
+Validator 是模型可选实现的校验接口.
+GormX 会在 Create, CreateInBatches 和 Save 之前先执行 `validate` 标签校验, 再调用 Validate().
+Update, UpdateFields 与 UpdateInBatches 只写入部分字段, 只校验被写入字段的 `validate` 标签, 不调用 Validate().
+Validate() 可以直接返回 errors.FieldErrors 以报告字段级错误, 其他错误会被包装为一条无字段名的 FieldError.
+
+Validator is an optional interface for models.
+GormX runs the `validate` struct tags first and then calls Validate() before Create, CreateInBatches and Save.
+Partial updates only check the tags of the written fields.
+Validate() may return errors.FieldErrors to report field-level errors; any other error is wrapped as a FieldError without a field name.
+
+Example:
+
+	func (u *User) Validate() error {
+		if u.Name == "admin" {
+			return errors.FieldErrors{{Field: "Name", Message: "reserved name"}}
+		}
+		return nil
+	}
+*/
+type Validator interface {
+	Validate() error
+}
+
+/*
+IDSetter 是模型可选实现的接口, 配合 idgen.Plugin 使用.
+Create 和 CreateInBatches 插入前, 插件会为 GetID() 为零值的模型生成 ID 并调用 SetID().
+
+IDSetter is an optional interface for models used together with idgen.Plugin,
+which assigns generated IDs to models whose GetID() is zero before Create and CreateInBatches.
+
+Example:
+
+	func (u *User) SetID(id uint64) {
+		u.ID = id
+	}
+*/
+type IDSetter[ID comparable] interface {
+	SetID(id ID)
+}
+
+/*
+Sharded 是模型可选实现的分表接口, 配合 gormx.NewShardedGormX 使用.
+ShardKey() 返回分片键的列名, ShardStrategy() 返回将分片键的值映射到物理表的策略.
+分片键在写入后不能修改, 否则数据会留在旧的分片中.
+写入的行按分片键的值路由, 零值(如 0)同样交给策略判断是否有效; 结构体过滤器与 Update 中的零值视为未设置, 扇出到全部分片.
+
+Sharded is an optional interface for models stored in multiple physical tables.
+ShardKey() returns the shard key column and ShardStrategy() maps its value to a physical table.
+
+Example:
+
+	var orderSharding = sharding.HashMod(16, "orders_%02d")
+
+	func (o *Order) ShardKey() string                 { return "user_id" }
+	func (o *Order) ShardStrategy() sharding.Strategy { return orderSharding }
+*/
+type Sharded interface {
+	ShardKey() string
+	ShardStrategy() sharding.Strategy
+}
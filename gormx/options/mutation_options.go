@@ -0,0 +1,80 @@
+package options
+
+import (
+	"fmt"
+
+	"github.com/LouYuanbo1/go-webservice/gormx/errors"
+)
+
+// Mutation 写操作(更新/删除)的受影响行数检查配置
+type Mutation struct {
+	requireRows  bool
+	expectRows   bool
+	expectedRows int64
+}
+
+// NewMutation 创建新的检查配置, 默认不检查受影响行数
+func NewMutation() *Mutation {
+	return &Mutation{}
+}
+
+// 链式调用方法
+// RequireRowsAffected 受影响行数为 0 时返回 ErrNoRowsAffected
+func (m *Mutation) RequireRowsAffected() *Mutation {
+	m.requireRows = true
+	return m
+}
+
+// ExpectRowsAffected 受影响行数不等于 n 时返回 ErrRowsAffectedMismatch
+func (m *Mutation) ExpectRowsAffected(n int64) *Mutation {
+	m.expectRows = true
+	m.expectedRows = n
+	return m
+}
+
+// Check 检查受影响行数. GormX 在事务中执行写操作并调用 Check, 检查失败时回滚
+func (m *Mutation) Check(op, table string, rowsAffected int64) error {
+	if m.expectRows && rowsAffected != m.expectedRows {
+		return errors.New(
+			errors.ErrRowsAffectedMismatch,
+			op,
+			table,
+			fmt.Errorf("expected %d rows affected, got %d", m.expectedRows, rowsAffected),
+		)
+	}
+	if m.requireRows && rowsAffected == 0 {
+		return errors.New(
+			errors.ErrNoRowsAffected,
+			op,
+			table,
+			fmt.Errorf("expected at least one row affected"),
+		)
+	}
+	return nil
+}
+
+// 函数式选项模式
+type MutationOption func(*Mutation)
+
+// RequireRowsAffectedOption 函数式选项 - 受影响行数为 0 时返回错误
+func RequireRowsAffectedOption() MutationOption {
+	return func(m *Mutation) {
+		m.RequireRowsAffected()
+	}
+}
+
+// ExpectRowsAffectedOption 函数式选项 - 受影响行数不等于 n 时返回错误
+func ExpectRowsAffectedOption(n int64) MutationOption {
+	return func(m *Mutation) {
+		m.ExpectRowsAffected(n)
+	}
+}
+
+// NewMutationWithOptions 使用函数式选项创建检查配置
+func NewMutationWithOptions(opts ...MutationOption) *Mutation {
+	m := NewMutation()
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
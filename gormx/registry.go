@@ -0,0 +1,79 @@
+package gormx
+
+import (
+	"context"
+	"slices"
+
+	"github.com/LouYuanbo1/go-webservice/gormx/config"
+	"github.com/LouYuanbo1/go-webservice/gormx/internal"
+	"github.com/LouYuanbo1/go-webservice/gormx/model"
+	"gorm.io/gorm"
+)
+
+/*
+Registry 按名称管理多个数据库连接, 例如 "main" 与 "analytics".
+
+Registry manages named database connections.
+*/
+type Registry interface {
+	// Register 注册外部创建的连接, 名称重复时返回错误
+	Register(name string, db *gorm.DB) error
+	// Get 按名称获取连接, 未注册时返回 ErrUnknownDatabase, 关闭后返回 ErrRegistryClosed
+	Get(name string) (*gorm.DB, error)
+	// Names 返回已注册的连接名, 按字典序排列
+	Names() []string
+	// HealthCheck 并发 ping 所有连接, 返回 连接名 -> 错误, 健康的连接对应 nil
+	HealthCheck(ctx context.Context) map[string]error
+	// Close 拒绝新的 Get 并等待所有连接池关闭, ctx 到期时返回 ctx.Err()
+	Close(ctx context.Context) error
+}
+
+/*
+NewRegistry 根据配置创建所有连接(ctx 限制包括重试在内的总时长), 任意一个连接失败时关闭已创建的连接并返回错误.
+
+NewRegistry opens a connection for every entry of cfgs.
+
+Example:
+
+	var cfg struct {
+		Databases config.DatabasesConfig `mapstructure:"databases"`
+	}
+	registry, err := gormx.NewRegistry(ctx, cfg.Databases)
+	if err != nil {
+		return err
+	}
+	defer registry.Close(context.Background())
+
+	users, err := gormx.NewGormXFromRegistry[User, uint64](registry, "main")
+*/
+func NewRegistry(ctx context.Context, cfgs config.DatabasesConfig) (Registry, error) {
+	r := internal.NewRegistry()
+	// 按名称顺序创建, 保证日志与错误可复现
+	names := make([]string, 0, len(cfgs))
+	for name := range cfgs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		cfg := cfgs[name]
+		db, err := InitGorm(ctx, &cfg)
+		if err == nil {
+			err = r.Register(name, db)
+		}
+		if err != nil {
+			_ = r.Close(context.Background())
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// NewGormXFromRegistry 创建绑定到 registry 中名为 name 的连接的 GormX
+func NewGormXFromRegistry[T any, ID comparable, PT model.PointerModel[T, ID]](registry Registry, name string) (GormX[T, ID, PT], error) {
+	db, err := registry.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewGormX[T, ID, PT](db), nil
+}
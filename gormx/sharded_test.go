@@ -0,0 +1,298 @@
+package gormx_test
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/LouYuanbo1/go-webservice/gormx"
+	"github.com/LouYuanbo1/go-webservice/gormx/errors"
+	"github.com/LouYuanbo1/go-webservice/gormx/options"
+	"github.com/LouYuanbo1/go-webservice/gormx/sharding"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var orderSharding = sharding.HashMod(4, "orders_%02d")
+
+// shardedOrder 按 user_id 分到 orders_00 ~ orders_03, 主键不包含分片键
+type shardedOrder struct {
+	ID     uint64 `gorm:"primaryKey;autoIncrement:false"`
+	UserID uint64 `gorm:"not null;index"`
+	Amount int    `gorm:"not null"`
+	Status string `gorm:"size:16;not null"`
+}
+
+func (o *shardedOrder) TableName() string                { return "orders" }
+func (o *shardedOrder) PrimaryKey() string               { return "id" }
+func (o *shardedOrder) GetID() uint64                    { return o.ID }
+func (o *shardedOrder) ShardKey() string                 { return "user_id" }
+func (o *shardedOrder) ShardStrategy() sharding.Strategy { return orderSharding }
+
+func openShardedDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	for _, target := range orderSharding.Targets() {
+		if err := db.Table(target.Table).AutoMigrate(&shardedOrder{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func newShardedOrders(t *testing.T, db *gorm.DB, opts ...sharding.Option) gormx.GormX[shardedOrder, uint64, *shardedOrder] {
+	t.Helper()
+	gx, err := gormx.NewShardedGormX[shardedOrder, uint64](db, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gx
+}
+
+// seedOrders 为 user 0 ~ 7 各写入一个订单, ID 为 user+1, 金额为 (user+1)*10, 偶数用户已支付
+func seedOrders(t *testing.T, gx gormx.GormX[shardedOrder, uint64, *shardedOrder]) []*shardedOrder {
+	t.Helper()
+	var orders []*shardedOrder
+	for user := range uint64(8) {
+		status := "new"
+		if user%2 == 0 {
+			status = "paid"
+		}
+		orders = append(orders, &shardedOrder{ID: user + 1, UserID: user, Amount: int(user+1) * 10, Status: status})
+	}
+	if _, err := gx.CreateInBatches(context.Background(), orders, 3); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	return orders
+}
+
+func orderIDs(orders []*shardedOrder) []uint64 {
+	ids := make([]uint64, len(orders))
+	for i, o := range orders {
+		ids[i] = o.ID
+	}
+	return ids
+}
+
+func countRows(t *testing.T, db *gorm.DB, table string) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Table(table).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestShardedRoutesByShardKey(t *testing.T) {
+	ctx := context.Background()
+	db := openShardedDB(t)
+	gx := newShardedOrders(t, db)
+
+	// 0 是 HashMod 的有效分片键
+	if _, err := gx.Create(ctx, &shardedOrder{ID: 100, UserID: 0, Amount: 1, Status: "new"}); err != nil {
+		t.Fatalf("create with zero shard key: %v", err)
+	}
+	seedOrders(t, gx)
+	for i := range 4 {
+		table := fmt.Sprintf("orders_%02d", i)
+		want := int64(2)
+		if i == 0 {
+			want = 3
+		}
+		if got := countRows(t, db, table); got != want {
+			t.Errorf("%s has %d rows, want %d", table, got, want)
+		}
+	}
+
+	got, err := gx.FindByMapFilter(ctx, map[string]any{"user_id": uint64(5)})
+	if err != nil || len(got) != 1 || got[0].ID != 6 {
+		t.Fatalf("find by shard key = %+v, %v", got, err)
+	}
+	got, err = gx.FindByMapFilter(ctx, map[string]any{"user_id": []uint64{1, 5, 2}}, options.WithDescOption("amount"))
+	if err != nil || !slices.Equal(orderIDs(got), []uint64{6, 3, 2}) {
+		t.Fatalf("find by shard key IN = %v, %v", orderIDs(got), err)
+	}
+	order, err := gx.GetByID(ctx, 7)
+	if err != nil || order.UserID != 6 {
+		t.Fatalf("get by id fan-out = %+v, %v", order, err)
+	}
+	if _, err := gx.GetByID(ctx, 999); !errors.IsQueryFailed(err) {
+		t.Fatalf("get missing id = %v, want record not found", err)
+	}
+}
+
+func TestShardedFanOutMergesAndOrders(t *testing.T) {
+	ctx := context.Background()
+	db := openShardedDB(t)
+	gx := newShardedOrders(t, db)
+	seedOrders(t, gx)
+
+	page, err := gx.FindByPage(ctx, 1, 3)
+	if err != nil || !slices.Equal(orderIDs(page), []uint64{1, 2, 3}) {
+		t.Fatalf("page 1 by primary key = %v, %v", orderIDs(page), err)
+	}
+	page, err = gx.FindByPage(ctx, 2, 3, options.WithDescOption("amount"))
+	if err != nil || !slices.Equal(orderIDs(page), []uint64{5, 4, 3}) {
+		t.Fatalf("page 2 by amount desc = %v, %v", orderIDs(page), err)
+	}
+	page, err = gx.FindByPage(ctx, 3, 3)
+	if err != nil || !slices.Equal(orderIDs(page), []uint64{7, 8}) {
+		t.Fatalf("last page = %v, %v", orderIDs(page), err)
+	}
+	page, err = gx.FindByPage(ctx, 4, 3)
+	if err != nil || len(page) != 0 {
+		t.Fatalf("page past the end = %v, %v", orderIDs(page), err)
+	}
+
+	paid, err := gx.FindByMapFilter(ctx, map[string]any{"status": "paid"}, options.WithDescOption("amount"))
+	if err != nil || !slices.Equal(orderIDs(paid), []uint64{7, 5, 3, 1}) {
+		t.Fatalf("fan-out find by map filter = %v, %v", orderIDs(paid), err)
+	}
+	paid, err = gx.FindByMapFilter(ctx, map[string]any{"status": "paid"})
+	if err != nil || !slices.Equal(orderIDs(paid), []uint64{1, 3, 5, 7}) {
+		t.Fatalf("fan-out find by map filter by primary key = %v, %v", orderIDs(paid), err)
+	}
+}
+
+func TestShardedRejectFanOut(t *testing.T) {
+	ctx := context.Background()
+	db := openShardedDB(t)
+	gx := newShardedOrders(t, db, sharding.WithRejectFanOut())
+	seedOrders(t, gx)
+
+	if _, err := gx.FindByPage(ctx, 1, 10); !errors.IsShardKeyRequired(err) {
+		t.Errorf("FindByPage() = %v, want ErrShardKeyRequired", err)
+	}
+	if _, err := gx.GetByID(ctx, 1); !errors.IsShardKeyRequired(err) {
+		t.Errorf("GetByID() = %v, want ErrShardKeyRequired", err)
+	}
+	if _, err := gx.DeleteByMapFilter(ctx, map[string]any{"status": "new"}); !errors.IsShardKeyRequired(err) {
+		t.Errorf("DeleteByMapFilter() = %v, want ErrShardKeyRequired", err)
+	}
+	got, err := gx.FindByMapFilter(ctx, map[string]any{"user_id": uint64(3), "status": "new"})
+	if err != nil || len(got) != 1 {
+		t.Errorf("routed FindByMapFilter() = %v, %v", orderIDs(got), err)
+	}
+}
+
+func TestShardedShardKeyImmutable(t *testing.T) {
+	ctx := context.Background()
+	db := openShardedDB(t)
+	gx := newShardedOrders(t, db)
+	seedOrders(t, gx)
+
+	if _, err := gx.UpdateByMapFilter(ctx, map[string]any{"id": uint64(2)}, map[string]any{"user_id": uint64(9)}); !errors.IsShardKeyImmutable(err) {
+		t.Errorf("UpdateByMapFilter() = %v, want ErrShardKeyImmutable", err)
+	}
+	if _, err := gx.UpdateByStructFilter(ctx, &shardedOrder{ID: 2}, &shardedOrder{UserID: 9}); !errors.IsShardKeyImmutable(err) {
+		t.Errorf("UpdateByStructFilter() = %v, want ErrShardKeyImmutable", err)
+	}
+	if _, err := gx.PatchByID(ctx, 2, []byte(`{"user_id": 9}`), "user_id"); !errors.IsShardKeyImmutable(err) {
+		t.Errorf("PatchByID() = %v, want ErrShardKeyImmutable", err)
+	}
+	got, err := gx.GetByID(ctx, 2)
+	if err != nil || got.UserID != 1 {
+		t.Errorf("order moved to another shard: %+v, %v", got, err)
+	}
+}
+
+func TestShardedDeleteByIDsAcrossShards(t *testing.T) {
+	ctx := context.Background()
+	db := openShardedDB(t)
+	gx := newShardedOrders(t, db)
+	seedOrders(t, gx)
+
+	// 检查失败时回滚全部分片
+	_, err := gx.DeleteByIDs(ctx, []uint64{1, 2, 3, 100}, options.ExpectRowsAffectedOption(4))
+	if !errors.IsRowsAffectedMismatch(err) {
+		t.Fatalf("DeleteByIDs() = %v, want ErrRowsAffectedMismatch", err)
+	}
+	if all, _ := gx.FindByPage(ctx, 1, 100); len(all) != 8 {
+		t.Fatalf("rows after rolled back delete = %v, want 8", orderIDs(all))
+	}
+
+	res, err := gx.DeleteByIDs(ctx, []uint64{1, 2, 3, 6}, options.ExpectRowsAffectedOption(4))
+	if err != nil || res.RowsAffected != 4 {
+		t.Fatalf("DeleteByIDs() = %+v, %v", res, err)
+	}
+	all, err := gx.FindByPage(ctx, 1, 100)
+	if err != nil || !slices.Equal(orderIDs(all), []uint64{4, 5, 7, 8}) {
+		t.Fatalf("rows after delete = %v, %v", orderIDs(all), err)
+	}
+}
+
+func TestShardedTransactionRollback(t *testing.T) {
+	ctx := context.Background()
+	db := openShardedDB(t)
+	gx := newShardedOrders(t, db)
+	seedOrders(t, gx)
+
+	errRollback := stderrors.New("rollback")
+	err := gormx.NewGormXTx(db).Exec(ctx, func(ctx context.Context) error {
+		if _, err := gx.Create(ctx, &shardedOrder{ID: 20, UserID: 1, Amount: 1, Status: "new"}); err != nil {
+			return err
+		}
+		if _, err := gx.UpdateByMapFilter(ctx, map[string]any{"status": "new"}, map[string]any{"status": "void"}); err != nil {
+			return err
+		}
+		if _, err := gx.DeleteByIDs(ctx, []uint64{1, 2}); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !stderrors.Is(err, errRollback) {
+		t.Fatalf("Exec() = %v, want rollback error", err)
+	}
+
+	all, err := gx.FindByPage(ctx, 1, 100)
+	if err != nil || !slices.Equal(orderIDs(all), []uint64{1, 2, 3, 4, 5, 6, 7, 8}) {
+		t.Fatalf("rows after rollback = %v, %v", orderIDs(all), err)
+	}
+	if void, _ := gx.FindByMapFilter(ctx, map[string]any{"status": "void"}); len(void) != 0 {
+		t.Fatalf("fan-out update was not rolled back: %v", orderIDs(void))
+	}
+}
+
+func TestShardedFanOutLogsOnce(t *testing.T) {
+	ctx := context.Background()
+	db := openShardedDB(t)
+	gx := newShardedOrders(t, db)
+	seedOrders(t, gx)
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	if _, err := gx.GetByID(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := gx.UpdateByMapFilter(ctx, map[string]any{"id": uint64(3)}, map[string]any{"amount": 1}); err != nil || res.RowsAffected != 1 {
+		t.Fatalf("UpdateByMapFilter() = %+v, %v", res, err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("successful fan-out logged per-shard misses:\n%s", buf.String())
+	}
+
+	if _, err := gx.GetByID(ctx, 999); err == nil {
+		t.Fatal("expected record not found")
+	}
+	if got := strings.Count(buf.String(), "\n"); got != 1 {
+		t.Fatalf("missing row logged %d times, want once:\n%s", got, buf.String())
+	}
+}
@@ -0,0 +1,43 @@
+package sharding
+
+import "gorm.io/gorm"
+
+type Options struct {
+	// Databases 保存 Target.Database 对应的连接, 为空的 Database 使用默认连接
+	Databases map[string]*gorm.DB
+	// RejectFanOut 为 true 时, 缺少分片键的查询与写入返回错误而不是扇出到全部分片
+	RejectFanOut bool
+	// MaxConcurrency 限制扇出读的并发数, 0 表示不限制
+	MaxConcurrency int
+}
+
+type Option func(*Options)
+
+// WithDatabase 注册 Target.Database 为 name 的数据库连接
+func WithDatabase(name string, db *gorm.DB) Option {
+	return func(o *Options) {
+		o.Databases[name] = db
+	}
+}
+
+// WithRejectFanOut 拒绝缺少分片键的操作, 返回 ErrShardKeyRequired
+func WithRejectFanOut() Option {
+	return func(o *Options) {
+		o.RejectFanOut = true
+	}
+}
+
+// WithMaxConcurrency 限制扇出读的并发数
+func WithMaxConcurrency(n int) Option {
+	return func(o *Options) {
+		o.MaxConcurrency = n
+	}
+}
+
+func NewOptions(opts ...Option) *Options {
+	o := &Options{Databases: make(map[string]*gorm.DB)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
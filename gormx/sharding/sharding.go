@@ -0,0 +1,243 @@
+/*
+Package sharding 定义分表(分库)策略, 配合 model.Sharded 与 gormx.NewShardedGormX 使用.
+
+模型通过 ShardKey() 声明分片键列, 通过 ShardStrategy() 声明策略:
+  - HashMod: 按哈希取模, 例如 orders_00 ~ orders_63
+  - Range: 按整数区间
+  - ByTime: 按时间周期, 例如 orders_202401
+
+Package sharding defines strategies that map a shard key value to a physical table
+(and optionally a database).
+
+Example:
+
+	func (o *Order) ShardKey() string {
+		return "user_id"
+	}
+
+	func (o *Order) ShardStrategy() sharding.Strategy {
+		return orderSharding
+	}
+
+	var orderSharding = sharding.HashMod(64, "orders_%02d")
+*/
+package sharding
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"time"
+)
+
+// Target 是一个物理分片, Database 为空表示默认数据库
+type Target struct {
+	Database string
+	Table    string
+}
+
+func (t Target) String() string {
+	if t.Database == "" {
+		return t.Table
+	}
+	return t.Database + "." + t.Table
+}
+
+// Strategy 将分片键的值映射到物理分片
+type Strategy interface {
+	// Shard 返回 value 所在的分片
+	Shard(value any) (Target, error)
+	// Targets 返回全部分片, 用于缺少分片键时的查询扇出
+	Targets() []Target
+}
+
+type hashMod struct {
+	targets []Target
+}
+
+/*
+HashMod 按哈希取模分片, 整数取值本身, 字符串使用 FNV-1a.
+tableFormat 接收分片下标, 例如 "orders_%02d". 传入 databases 时分片按顺序均匀分布到这些数据库.
+*/
+func HashMod(shards int, tableFormat string, databases ...string) Strategy {
+	if shards <= 0 {
+		panic(fmt.Sprintf("sharding: shards must be positive, got %d", shards))
+	}
+	targets := make([]Target, shards)
+	for i := range targets {
+		targets[i] = Target{Table: fmt.Sprintf(tableFormat, i)}
+		if len(databases) > 0 {
+			targets[i].Database = databases[i*len(databases)/shards]
+		}
+	}
+	return &hashMod{targets: targets}
+}
+
+func (h *hashMod) Shard(value any) (Target, error) {
+	sum, err := hashValue(value)
+	if err != nil {
+		return Target{}, err
+	}
+	return h.targets[sum%uint64(len(h.targets))], nil
+}
+
+func (h *hashMod) Targets() []Target {
+	return h.targets
+}
+
+func hashValue(value any) (uint64, error) {
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), nil
+	case reflect.String:
+		h := fnv.New64a()
+		h.Write([]byte(rv.String()))
+		return h.Sum64(), nil
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			h := fnv.New64a()
+			h.Write(rv.Bytes())
+			return h.Sum64(), nil
+		}
+	}
+	return 0, fmt.Errorf("sharding: unsupported shard key type %T", value)
+}
+
+// RangeShard 是 Range 策略的一个区间, 包含小于 Upper 且不小于上一个区间 Upper 的值
+type RangeShard struct {
+	Upper  int64
+	Target Target
+}
+
+type rangeStrategy struct {
+	ranges []RangeShard
+}
+
+/*
+Range 按整数区间分片, ranges 必须按 Upper 升序排列, 超出最后一个区间的值返回错误.
+
+Example:
+
+	sharding.Range(
+		sharding.RangeShard{Upper: 1_000_000, Target: sharding.Target{Table: "users_0"}},
+		sharding.RangeShard{Upper: math.MaxInt64, Target: sharding.Target{Table: "users_1"}},
+	)
+*/
+func Range(ranges ...RangeShard) Strategy {
+	for i := 1; i < len(ranges); i++ {
+		if ranges[i].Upper <= ranges[i-1].Upper {
+			panic("sharding: ranges must be sorted by Upper in ascending order")
+		}
+	}
+	return &rangeStrategy{ranges: ranges}
+}
+
+func (r *rangeStrategy) Shard(value any) (Target, error) {
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	var v int64
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v = rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > uint64(1<<63-1) {
+			return Target{}, fmt.Errorf("sharding: value %v out of range", value)
+		}
+		v = int64(rv.Uint())
+	default:
+		return Target{}, fmt.Errorf("sharding: unsupported shard key type %T", value)
+	}
+	for _, rs := range r.ranges {
+		if v < rs.Upper {
+			return rs.Target, nil
+		}
+	}
+	return Target{}, fmt.Errorf("sharding: value %d out of range", v)
+}
+
+func (r *rangeStrategy) Targets() []Target {
+	targets := make([]Target, len(r.ranges))
+	for i, rs := range r.ranges {
+		targets[i] = rs.Target
+	}
+	return targets
+}
+
+// Period 是 ByTime 策略的分片周期
+type Period int
+
+const (
+	Daily Period = iota
+	Monthly
+	Yearly
+)
+
+type byTime struct {
+	layout     string
+	period     Period
+	start, end time.Time
+	location   *time.Location
+}
+
+/*
+ByTime 按时间周期分片, 表名由 time.Format(tableLayout) 生成, 例如 "orders_200601".
+[start, end) 限定了分片范围, 扇出查询时会遍历其中的全部周期, 范围外的值返回错误.
+*/
+func ByTime(tableLayout string, period Period, start, end time.Time) Strategy {
+	if !start.Before(end) {
+		panic("sharding: start must be before end")
+	}
+	return &byTime{layout: tableLayout, period: period, start: start, end: end, location: start.Location()}
+}
+
+func (b *byTime) Shard(value any) (Target, error) {
+	var t time.Time
+	switch v := value.(type) {
+	case time.Time:
+		t = v
+	case *time.Time:
+		if v == nil {
+			return Target{}, fmt.Errorf("sharding: nil time")
+		}
+		t = *v
+	default:
+		return Target{}, fmt.Errorf("sharding: unsupported shard key type %T", value)
+	}
+	if t.Before(b.start) || !t.Before(b.end) {
+		return Target{}, fmt.Errorf("sharding: time %s out of range [%s, %s)", t, b.start, b.end)
+	}
+	return Target{Table: b.truncate(t).Format(b.layout)}, nil
+}
+
+func (b *byTime) Targets() []Target {
+	var targets []Target
+	for t := b.truncate(b.start); t.Before(b.end); t = b.next(t) {
+		targets = append(targets, Target{Table: t.Format(b.layout)})
+	}
+	return targets
+}
+
+func (b *byTime) truncate(t time.Time) time.Time {
+	t = t.In(b.location)
+	switch b.period {
+	case Yearly:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, b.location)
+	case Monthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, b.location)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, b.location)
+	}
+}
+
+func (b *byTime) next(t time.Time) time.Time {
+	switch b.period {
+	case Yearly:
+		return t.AddDate(1, 0, 0)
+	case Monthly:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,118 @@
+// Package retry 为启动阶段的连接提供带指数退避与抖动的重试, 供 gormx 与 redisx 共用
+package retry
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	defaultInitialBackoff = 500 * time.Millisecond
+	defaultMaxBackoff     = 10 * time.Second
+)
+
+/*
+Config 是启动时的连接重试配置, 由 gormx 与 redisx 的配置共用, 总时长由传入 Init 的 ctx 限制.
+Attempts <= 1 时不重试, 退避时间从 InitialBackoff 开始每次翻倍, 不超过 MaxBackoff.
+*/
+type Config struct {
+	// 最大尝试次数 (默认 1, 不重试)
+	Attempts int `mapstructure:"attempts"`
+	// 首次退避时间 (默认 500ms)
+	InitialBackoff string `mapstructure:"initial_backoff"`
+	// 最大退避时间 (默认 10s)
+	MaxBackoff string `mapstructure:"max_backoff"`
+	// 退避时间的随机浮动比例, 取值 [0, 1] (e.g. 0.2)
+	Jitter float64 `mapstructure:"jitter"`
+}
+
+// Policy 解析配置得到重试策略
+func (c Config) Policy() (Policy, error) {
+	return NewPolicy(c.Attempts, c.InitialBackoff, c.MaxBackoff, c.Jitter)
+}
+
+// Policy 重试策略, Attempts <= 1 表示只尝试一次
+type Policy struct {
+	Attempts       int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Jitter 是退避时间的随机浮动比例, 取值 [0, 1]
+	Jitter float64
+}
+
+// NewPolicy 解析配置中的时长字符串, 空字符串使用默认值 (500ms, 10s)
+func NewPolicy(attempts int, initialBackoff, maxBackoff string, jitter float64) (Policy, error) {
+	p := Policy{
+		Attempts:       attempts,
+		InitialBackoff: defaultInitialBackoff,
+		MaxBackoff:     defaultMaxBackoff,
+		Jitter:         jitter,
+	}
+	if initialBackoff != "" {
+		d, err := time.ParseDuration(initialBackoff)
+		if err != nil {
+			return Policy{}, fmt.Errorf("parse initial_backoff: %w", err)
+		}
+		p.InitialBackoff = d
+	}
+	if maxBackoff != "" {
+		d, err := time.ParseDuration(maxBackoff)
+		if err != nil {
+			return Policy{}, fmt.Errorf("parse max_backoff: %w", err)
+		}
+		p.MaxBackoff = d
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return Policy{}, fmt.Errorf("jitter %v out of range [0, 1]", p.Jitter)
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		p.MaxBackoff = p.InitialBackoff
+	}
+	return p, nil
+}
+
+/*
+Do 执行 fn 直到成功, 用尽 Attempts 或 ctx 结束, 每次失败都会记录日志.
+退避时间从 InitialBackoff 开始每次翻倍, 不超过 MaxBackoff.
+ctx 结束时返回同时包装 ctx.Err() 与最后一次错误的错误.
+*/
+func Do(ctx context.Context, name string, p Policy, fn func(ctx context.Context) error) error {
+	attempts := max(p.Attempts, 1)
+	backoff := p.InitialBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(ctx); err == nil {
+			if attempt > 1 {
+				log.Printf("%s: attempt %d/%d succeeded", name, attempt, attempts)
+			}
+			return nil
+		}
+		if attempt >= attempts {
+			log.Printf("%s: attempt %d/%d failed: %v", name, attempt, attempts, err)
+			return err
+		}
+
+		wait := jitter(backoff, p.Jitter)
+		log.Printf("%s: attempt %d/%d failed: %v, retrying in %s", name, attempt, attempts, err, wait)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, p.MaxBackoff)
+	}
+}
+
+func jitter(d time.Duration, ratio float64) time.Duration {
+	if ratio <= 0 || d <= 0 {
+		return d
+	}
+	// 在 [d*(1-ratio), d*(1+ratio)] 内均匀分布
+	delta := float64(d) * ratio
+	return time.Duration(float64(d) - delta + rand.Float64()*2*delta)
+}
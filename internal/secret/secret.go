@@ -0,0 +1,40 @@
+// Package secret 解析配置中的密码, 供 gormx 与 redisx 共用
+package secret
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+/*
+Resolve 返回密码, 来源为 value(明文), env(环境变量名) 或 file(文件路径) 之一, 同时设置多个来源时返回错误.
+文件内容会去掉末尾的换行符, 适用于 Docker / Kubernetes secret 挂载的文件.
+*/
+func Resolve(value, env, file string) (string, error) {
+	sources := 0
+	for _, s := range []string{value, env, file} {
+		if s != "" {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return "", fmt.Errorf("only one of password, password_env and password_file can be set")
+	}
+
+	switch {
+	case env != "":
+		v, ok := os.LookupEnv(env)
+		if !ok {
+			return "", fmt.Errorf("password environment variable %q is not set", env)
+		}
+		return v, nil
+	case file != "":
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("read password file: %w", err)
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	}
+	return value, nil
+}
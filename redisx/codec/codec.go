@@ -0,0 +1,125 @@
+/*
+Package codec 定义 RedisX 写入与读取值时使用的编码与压缩方式.
+
+内置 JSON (默认), Gob, Msgpack 与 Raw 四种编码, 以及 Gzip 与 Zstd 两种压缩.
+压缩后的数据带有 5 字节的头部 ("\x00RXC" + 压缩算法ID), 没有头部的数据按未压缩处理,
+因此开启或更换压缩后, 之前写入的数据仍然可以读取.
+
+Package codec provides the value codecs and compressors used by RedisX.
+*/
+package codec
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec 将值编码为字节并解码回来
+type Codec interface {
+	// Name 返回编码名称, 用于日志与错误信息
+	Name() string
+	Marshal(v any) ([]byte, error)
+	// Unmarshal 将 data 解码到 v, v 必须为指针
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	// JSON 使用 encoding/json, 是 RedisX 的默认编码
+	JSON Codec = jsonCodec{}
+	// Gob 使用 encoding/gob, 只能被 Go 程序读取
+	Gob Codec = gobCodec{}
+	// Msgpack 使用 MessagePack, 通常比 JSON 更小更快, 字段名沿用 json 标签
+	Msgpack Codec = msgpackCodec{}
+	/*
+		Raw 不做任何编码, 支持 []byte, string 以及实现了
+		encoding.BinaryMarshaler / encoding.BinaryUnmarshaler 的类型(例如 protobuf 生成的包装类型)
+	*/
+	Raw Codec = rawCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                       { return "json" }
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type gobCodec struct{}
+
+func (gobCodec) Name() string { return "gob" }
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return "msgpack" }
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+type rawCodec struct{}
+
+func (rawCodec) Name() string { return "raw" }
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	switch x := v.(type) {
+	case []byte:
+		return x, nil
+	case string:
+		return []byte(x), nil
+	case encoding.BinaryMarshaler:
+		return x.MarshalBinary()
+	}
+	// 方法定义在指针接收者上时, 复制一份取地址
+	rv := reflect.ValueOf(v)
+	if rv.IsValid() {
+		ptr := reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		if m, ok := ptr.Interface().(encoding.BinaryMarshaler); ok {
+			return m.MarshalBinary()
+		}
+	}
+	return nil, fmt.Errorf("raw codec cannot marshal %T", v)
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	switch x := v.(type) {
+	case *[]byte:
+		*x = bytes.Clone(data)
+		return nil
+	case *string:
+		*x = string(data)
+		return nil
+	case encoding.BinaryUnmarshaler:
+		return x.UnmarshalBinary(data)
+	}
+	return fmt.Errorf("raw codec cannot unmarshal into %T", v)
+}
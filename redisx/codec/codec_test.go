@@ -0,0 +1,200 @@
+package codec_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/LouYuanbo1/go-webservice/redisx/codec"
+)
+
+type product struct {
+	ID    uint64   `json:"id"`
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags"`
+	Price float64  `json:"price"`
+}
+
+var sample = product{ID: 7, Name: "keyboard", Tags: []string{"usb", "mechanical"}, Price: 99.5}
+
+func equalProduct(a, b product) bool {
+	return a.ID == b.ID && a.Name == b.Name && a.Price == b.Price && strings.Join(a.Tags, ",") == strings.Join(b.Tags, ",")
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	for _, c := range []codec.Codec{codec.JSON, codec.Gob, codec.Msgpack} {
+		t.Run(c.Name(), func(t *testing.T) {
+			data, err := c.Marshal(sample)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got product
+			if err := c.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !equalProduct(got, sample) {
+				t.Errorf("round trip = %+v, want %+v", got, sample)
+			}
+		})
+	}
+}
+
+func TestMsgpackUsesJSONTags(t *testing.T) {
+	data, err := codec.Msgpack.Marshal(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("name")) || bytes.Contains(data, []byte("Name")) {
+		t.Errorf("msgpack field names do not follow json tags: %q", data)
+	}
+}
+
+// version 只在指针接收者上实现 BinaryMarshaler
+type version struct {
+	Major, Minor byte
+}
+
+func (v *version) MarshalBinary() ([]byte, error) {
+	return []byte{v.Major, v.Minor}, nil
+}
+
+func (v *version) UnmarshalBinary(data []byte) error {
+	v.Major, v.Minor = data[0], data[1]
+	return nil
+}
+
+func TestRawCodec(t *testing.T) {
+	data, err := codec.Raw.Marshal([]byte("bytes"))
+	if err != nil || string(data) != "bytes" {
+		t.Fatalf("marshal []byte = %q, %v", data, err)
+	}
+	var b []byte
+	if err := codec.Raw.Unmarshal(data, &b); err != nil || string(b) != "bytes" {
+		t.Fatalf("unmarshal []byte = %q, %v", b, err)
+	}
+	data[0] = 'B'
+	if string(b) != "bytes" {
+		t.Error("unmarshal into []byte shares the input buffer")
+	}
+
+	data, err = codec.Raw.Marshal("text")
+	if err != nil || string(data) != "text" {
+		t.Fatalf("marshal string = %q, %v", data, err)
+	}
+	var s string
+	if err := codec.Raw.Unmarshal(data, &s); err != nil || s != "text" {
+		t.Fatalf("unmarshal string = %q, %v", s, err)
+	}
+
+	// 值与指针都可以使用指针接收者上的 MarshalBinary
+	for _, v := range []any{version{1, 2}, &version{1, 2}} {
+		data, err := codec.Raw.Marshal(v)
+		if err != nil || !bytes.Equal(data, []byte{1, 2}) {
+			t.Fatalf("marshal %T = %v, %v", v, data, err)
+		}
+	}
+	var v version
+	if err := codec.Raw.Unmarshal([]byte{3, 4}, &v); err != nil || v != (version{3, 4}) {
+		t.Fatalf("unmarshal binary = %+v, %v", v, err)
+	}
+
+	if _, err := codec.Raw.Marshal(42); err == nil {
+		t.Error("expected an error when marshaling an int")
+	}
+	var n int
+	if err := codec.Raw.Unmarshal([]byte("42"), &n); err == nil {
+		t.Error("expected an error when unmarshaling into an int")
+	}
+}
+
+func TestCompressorRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"id":7,"name":"keyboard"},`), 100)
+	for _, c := range []codec.Compressor{codec.Gzip, codec.Zstd} {
+		t.Run(c.Name(), func(t *testing.T) {
+			compressed, err := codec.Compress(c, 64, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(compressed) >= len(data) {
+				t.Fatalf("compressed %d bytes into %d", len(data), len(compressed))
+			}
+			if !bytes.HasPrefix(compressed, append([]byte("\x00RXC"), c.ID())) {
+				t.Fatalf("missing header: %q", compressed[:5])
+			}
+			got, err := codec.Decompress(compressed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Error("decompressed data differs from the input")
+			}
+		})
+	}
+}
+
+func TestCompressSkips(t *testing.T) {
+	small := []byte("short")
+	if got, _ := codec.Compress(codec.Zstd, 64, small); !bytes.Equal(got, small) {
+		t.Errorf("data below the threshold was compressed: %q", got)
+	}
+	if got, _ := codec.Compress(nil, 0, small); !bytes.Equal(got, small) {
+		t.Errorf("nil compressor changed the data: %q", got)
+	}
+	// 随机数据无法压缩, 加上头部后更大, 返回原数据
+	random := make([]byte, 256)
+	rand.Read(random)
+	if got, _ := codec.Compress(codec.Gzip, 0, random); !bytes.Equal(got, random) {
+		t.Error("incompressible data was not returned as is")
+	}
+}
+
+func TestDecompressUncompressedValue(t *testing.T) {
+	// 开启压缩前写入的值没有头部, 原样返回后可以直接解码
+	for _, c := range []codec.Codec{codec.JSON, codec.Gob, codec.Msgpack} {
+		t.Run(c.Name(), func(t *testing.T) {
+			data, err := c.Marshal(sample)
+			if err != nil {
+				t.Fatal(err)
+			}
+			plain, err := codec.Decompress(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got product
+			if err := c.Unmarshal(plain, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !equalProduct(got, sample) {
+				t.Errorf("got %+v, want %+v", got, sample)
+			}
+		})
+	}
+
+	if _, err := codec.Decompress([]byte("\x00RXC\x0fdata")); err == nil {
+		t.Error("expected an error for an unknown compressor id")
+	}
+}
+
+type upperCompressor struct{ id byte }
+
+func (c upperCompressor) ID() byte                               { return c.id }
+func (c upperCompressor) Name() string                           { return "upper" }
+func (c upperCompressor) Compress(data []byte) ([]byte, error)   { return data[:1], nil }
+func (c upperCompressor) Decompress(data []byte) ([]byte, error) { return bytes.ToUpper(data), nil }
+
+func TestRegisterCompressor(t *testing.T) {
+	if err := codec.RegisterCompressor(upperCompressor{id: 3}); err == nil {
+		t.Error("expected an error for a reserved id")
+	}
+	if err := codec.RegisterCompressor(upperCompressor{id: 200}); err != nil {
+		t.Fatal(err)
+	}
+	if err := codec.RegisterCompressor(upperCompressor{id: 200}); err == nil {
+		t.Error("expected an error for a duplicate id")
+	}
+	got, err := codec.Decompress([]byte("\x00RXC\xc8abc"))
+	if err != nil || string(got) != "ABC" {
+		t.Errorf("decompress with registered compressor = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,141 @@
+package codec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compressor 压缩与解压数据, ID 写入头部, 用于读取时选择解压算法
+type Compressor interface {
+	ID() byte
+	Name() string
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+var (
+	// Gzip 使用 compress/gzip, 默认压缩级别
+	Gzip Compressor = gzipCompressor{}
+	// Zstd 使用 zstd, 压缩与解压都比 gzip 快, 推荐用于较大的缓存对象
+	Zstd Compressor = zstdCompressor{}
+)
+
+// header 标记压缩数据, 后跟 1 字节的压缩算法ID
+var header = []byte("\x00RXC")
+
+var (
+	compressorsMu sync.RWMutex
+	compressors   = map[byte]Compressor{Gzip.ID(): Gzip, Zstd.ID(): Zstd}
+)
+
+// RegisterCompressor 注册自定义压缩算法, 使 Decompress 能够识别它写入的数据, ID 1-15 保留给内置算法
+func RegisterCompressor(c Compressor) error {
+	if c.ID() < 16 {
+		return fmt.Errorf("compressor id %d is reserved", c.ID())
+	}
+	compressorsMu.Lock()
+	defer compressorsMu.Unlock()
+	if existing, ok := compressors[c.ID()]; ok {
+		return fmt.Errorf("compressor id %d is already registered by %s", c.ID(), existing.Name())
+	}
+	compressors[c.ID()] = c
+	return nil
+}
+
+/*
+Compress 在 data 长度不小于 threshold 时压缩并加上头部, 压缩后没有变小时返回原数据.
+c 为 nil 时不压缩.
+*/
+func Compress(c Compressor, threshold int, data []byte) ([]byte, error) {
+	if c == nil || len(data) < threshold {
+		return data, nil
+	}
+	compressed, err := c.Compress(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s compress: %w", c.Name(), err)
+	}
+	if len(header)+1+len(compressed) >= len(data) {
+		return data, nil
+	}
+	out := make([]byte, 0, len(header)+1+len(compressed))
+	out = append(out, header...)
+	out = append(out, c.ID())
+	return append(out, compressed...), nil
+}
+
+// Decompress 按头部中的算法ID解压, 没有头部的数据原样返回
+func Decompress(data []byte) ([]byte, error) {
+	if len(data) <= len(header) || !bytes.HasPrefix(data, header) {
+		return data, nil
+	}
+	id := data[len(header)]
+	compressorsMu.RLock()
+	c, ok := compressors[id]
+	compressorsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown compressor id %d", id)
+	}
+	out, err := c.Decompress(data[len(header)+1:])
+	if err != nil {
+		return nil, fmt.Errorf("%s decompress: %w", c.Name(), err)
+	}
+	return out, nil
+}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) ID() byte     { return 1 }
+func (gzipCompressor) Name() string { return "gzip" }
+
+func (gzipCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCompressor) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// zstd 的 Encoder 与 Decoder 创建代价较高, 全局共享, EncodeAll 与 DecodeAll 可以并发调用
+var (
+	zstdEncoder = sync.OnceValues(func() (*zstd.Encoder, error) { return zstd.NewWriter(nil) })
+	zstdDecoder = sync.OnceValues(func() (*zstd.Decoder, error) { return zstd.NewReader(nil) })
+)
+
+type zstdCompressor struct{}
+
+func (zstdCompressor) ID() byte     { return 2 }
+func (zstdCompressor) Name() string { return "zstd" }
+
+func (zstdCompressor) Compress(data []byte) ([]byte, error) {
+	enc, err := zstdEncoder()
+	if err != nil {
+		return nil, err
+	}
+	return enc.EncodeAll(data, nil), nil
+}
+
+func (zstdCompressor) Decompress(data []byte) ([]byte, error) {
+	dec, err := zstdDecoder()
+	if err != nil {
+		return nil, err
+	}
+	return dec.DecodeAll(data, nil)
+}
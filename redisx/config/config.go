@@ -1,12 +1,80 @@
 package config
 
+import "github.com/LouYuanbo1/go-webservice/internal/retry"
+
+/*
+RedisConfig 描述单机, Sentinel 与 Cluster 三种部署方式:
+  - standalone (默认): 连接 Host:Port, 或 Addrs 中唯一的地址
+  - sentinel: 通过 Addrs 中的哨兵地址发现 MasterName 对应的主节点
+  - cluster: Addrs 为集群的种子节点, 不支持 DB
+*/
 type RedisConfig struct {
-	Host          string `mapstructure:"host"`
-	Port          int    `mapstructure:"port"`
-	Password      string `mapstructure:"password"`
+	// 部署方式: standalone / sentinel / cluster (默认 standalone)
+	Mode string `mapstructure:"mode"`
+	Host string `mapstructure:"host"`
+	Port int    `mapstructure:"port"`
+	// sentinel 为哨兵地址, cluster 为种子节点地址, 格式 host:port
+	Addrs []string `mapstructure:"addrs"`
+	// sentinel 模式下主节点的名称
+	MasterName string `mapstructure:"master_name"`
+	// 哨兵的认证信息, 未设置时哨兵不需要认证
+	SentinelUsername string `mapstructure:"sentinel_username"`
+	SentinelPassword string `mapstructure:"sentinel_password"`
+	Username         string `mapstructure:"username"`
+	Password         string `mapstructure:"password"`
+	// 从环境变量读取密码, 与 Password, PasswordFile 互斥
+	PasswordEnv string `mapstructure:"password_env"`
+	// 从文件读取密码 (e.g. Docker / Kubernetes secret), 与 Password, PasswordEnv 互斥
+	PasswordFile  string `mapstructure:"password_file"`
 	DB            int    `mapstructure:"db"`
 	Protocol      int    `mapstructure:"protocol"`
 	UnstableResp3 bool   `mapstructure:"unstable_resp3"`
-	MaxSize       int    `mapstructure:"max_size"`
-	DefaultTTL    int64  `mapstructure:"default_ttl"`
+	// 每个节点的连接池大小 (默认 10 * GOMAXPROCS)
+	MaxSize int `mapstructure:"max_size"`
+	// 最少/最多保持的空闲连接数
+	MinIdleConns int `mapstructure:"min_idle_conns"`
+	MaxIdleConns int `mapstructure:"max_idle_conns"`
+	// 连接空闲超过该时长后关闭 (e.g. "30m")
+	ConnMaxIdleTime string `mapstructure:"conn_max_idle_time"`
+	// 连接存活超过该时长后关闭 (e.g. "1h")
+	ConnMaxLifetime string `mapstructure:"conn_max_lifetime"`
+	// 连接池已满时等待空闲连接的超时时间 (默认 ReadTimeout + 1s)
+	PoolTimeout string `mapstructure:"pool_timeout"`
+	// 建立连接的超时时间 (默认 5s)
+	DialTimeout string `mapstructure:"dial_timeout"`
+	// 读写超时时间 (默认 3s, "-1" 表示不超时)
+	ReadTimeout  string `mapstructure:"read_timeout"`
+	WriteTimeout string `mapstructure:"write_timeout"`
+	// 命令失败时的重试次数 (默认 3, -1 表示不重试), 与启动时的连接重试 Retry 无关
+	MaxRetries int `mapstructure:"max_retries"`
+	// 命令重试的退避时间范围 (默认 8ms - 512ms)
+	MinRetryBackoff string `mapstructure:"min_retry_backoff"`
+	MaxRetryBackoff string `mapstructure:"max_retry_backoff"`
+	/*
+		只读命令的路由, 写命令始终发送到主节点, standalone 模式不支持:
+		  - cluster: 只读命令发送到从节点
+		  - sentinel: 只读命令随机发送到主节点或从节点
+	*/
+	ReadOnly   bool      `mapstructure:"read_only"`
+	DefaultTTL int64     `mapstructure:"default_ttl"`
+	TLS        TLSConfig `mapstructure:"tls"`
+	// 启动时的连接重试
+	Retry RetryConfig `mapstructure:"retry"`
+}
+
+// TLSConfig 是 Redis 的 TLS 配置, Enabled 为 false 时其他字段被忽略
+type TLSConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// 校验服务端证书的 CA 证书文件 (PEM), 未设置时使用系统 CA
+	CAFile string `mapstructure:"ca_file"`
+	// 双向认证的客户端证书与私钥文件 (PEM)
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// 校验证书时使用的服务器名称, 未设置时使用连接地址中的主机名
+	ServerName string `mapstructure:"server_name"`
+	// 跳过服务端证书校验, 仅用于测试环境
+	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"`
 }
+
+// RetryConfig 是启动时的连接重试配置, 与 gormx 共用, 见 retry.Config
+type RetryConfig = retry.Config
@@ -2,29 +2,187 @@ package redisx
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/LouYuanbo1/go-webservice/internal/retry"
+	"github.com/LouYuanbo1/go-webservice/internal/secret"
 	"github.com/LouYuanbo1/go-webservice/redisx/config"
 	"github.com/redis/go-redis/v9"
 )
 
-func InitRedis(config *config.RedisConfig) (*redis.Client, error) {
+/*
+InitRedis 按 Mode 创建单机, Sentinel 或 Cluster 客户端并 ping, 配置 Retry 时失败会按退避策略重试,
+每次尝试都会记录日志. ctx 限制包括重试在内的总时长.
+返回的 redis.UniversalClient 可以直接传给 NewRedisX 等函数, 业务代码不需要关心部署方式.
+
+InitRedis builds a standalone, Sentinel or Cluster client from config and pings it.
+*/
+func InitRedis(ctx context.Context, config *config.RedisConfig) (redis.UniversalClient, error) {
 	if config == nil {
 		return nil, fmt.Errorf("RedisConfig cannot be nil")
 	}
-	// 构建Redis连接字符串
-	redisAddr := fmt.Sprintf("%s:%d", config.Host, config.Port)
-	redisClient := redis.NewClient(&redis.Options{
-		Addr:          redisAddr,
-		Password:      config.Password,
-		DB:            config.DB,
-		Protocol:      config.Protocol,      // RESP3 协议,这个必须启用(2),否则在使用向量搜索时会出现无法寻找结果的问题
-		UnstableResp3: config.UnstableResp3, // 启用 RESP3 支持
+	policy, err := config.Retry.Policy()
+	if err != nil {
+		return nil, fmt.Errorf("invalid RedisConfig retry: %w", err)
+	}
+	opts, err := universalOptions(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RedisConfig: %w", err)
+	}
+
+	var redisClient redis.UniversalClient
+	mode := config.Mode
+	switch mode {
+	case "", "standalone":
+		mode = "standalone"
+		redisClient = redis.NewClient(opts.Simple())
+	case "sentinel":
+		if opts.RouteRandomly {
+			redisClient = redis.NewFailoverClusterClient(opts.Failover())
+		} else {
+			redisClient = redis.NewFailoverClient(opts.Failover())
+		}
+	case "cluster":
+		redisClient = redis.NewClusterClient(opts.Cluster())
+	}
+	name := fmt.Sprintf("ping redis (%s) %s", mode, strings.Join(opts.Addrs, ","))
+	err = retry.Do(ctx, name, policy, func(ctx context.Context) error {
+		return redisClient.Ping(ctx).Err()
 	})
-	ctx := context.Background()
-	_, err := redisClient.Ping(ctx).Result()
 	if err != nil {
+		_ = redisClient.Close()
 		return nil, fmt.Errorf("Redis connection failed: %w", err)
 	}
 	return redisClient, nil
 }
+
+func universalOptions(config *config.RedisConfig) (*redis.UniversalOptions, error) {
+	password, err := secret.Resolve(config.Password, config.PasswordEnv, config.PasswordFile)
+	if err != nil {
+		return nil, fmt.Errorf("password: %w", err)
+	}
+
+	addrs := config.Addrs
+	switch config.Mode {
+	case "", "standalone":
+		if len(addrs) == 0 {
+			addrs = []string{net.JoinHostPort(config.Host, strconv.Itoa(config.Port))}
+		}
+		if len(addrs) != 1 {
+			return nil, fmt.Errorf("standalone mode requires exactly one address, got %d", len(addrs))
+		}
+		if config.ReadOnly {
+			return nil, fmt.Errorf("standalone mode does not support read_only")
+		}
+	case "sentinel":
+		if config.MasterName == "" {
+			return nil, fmt.Errorf("sentinel mode requires master_name")
+		}
+		if len(addrs) == 0 {
+			return nil, fmt.Errorf("sentinel mode requires sentinel addrs")
+		}
+	case "cluster":
+		if len(addrs) == 0 {
+			return nil, fmt.Errorf("cluster mode requires addrs")
+		}
+		if config.DB != 0 {
+			return nil, fmt.Errorf("cluster mode does not support db %d", config.DB)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported mode %q", config.Mode)
+	}
+
+	opts := &redis.UniversalOptions{
+		Addrs:            addrs,
+		MasterName:       config.MasterName,
+		SentinelUsername: config.SentinelUsername,
+		SentinelPassword: config.SentinelPassword,
+		Username:         config.Username,
+		Password:         password,
+		DB:               config.DB,
+		Protocol:         config.Protocol,      // RESP3 协议,这个必须启用(2),否则在使用向量搜索时会出现无法寻找结果的问题
+		UnstableResp3:    config.UnstableResp3, // 启用 RESP3 支持
+		PoolSize:         config.MaxSize,
+		MinIdleConns:     config.MinIdleConns,
+		MaxIdleConns:     config.MaxIdleConns,
+		MaxRetries:       config.MaxRetries,
+	}
+	switch config.Mode {
+	case "cluster":
+		opts.ReadOnly = config.ReadOnly
+	case "sentinel":
+		// Failover() 将 ReadOnly 映射为 ReplicaOnly, 写命令也会发送到从节点, 因此改为按命令路由
+		opts.RouteRandomly = config.ReadOnly
+	}
+	durations := []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"conn_max_idle_time", config.ConnMaxIdleTime, &opts.ConnMaxIdleTime},
+		{"conn_max_lifetime", config.ConnMaxLifetime, &opts.ConnMaxLifetime},
+		{"pool_timeout", config.PoolTimeout, &opts.PoolTimeout},
+		{"dial_timeout", config.DialTimeout, &opts.DialTimeout},
+		{"read_timeout", config.ReadTimeout, &opts.ReadTimeout},
+		{"write_timeout", config.WriteTimeout, &opts.WriteTimeout},
+		{"min_retry_backoff", config.MinRetryBackoff, &opts.MinRetryBackoff},
+		{"max_retry_backoff", config.MaxRetryBackoff, &opts.MaxRetryBackoff},
+	}
+	for _, d := range durations {
+		if *d.dst, err = parseDuration(d.value); err != nil {
+			return nil, fmt.Errorf("%s: %w", d.name, err)
+		}
+	}
+
+	if config.TLS.Enabled {
+		if opts.TLSConfig, err = newTLSConfig(&config.TLS); err != nil {
+			return nil, fmt.Errorf("tls: %w", err)
+		}
+	}
+	return opts, nil
+}
+
+// parseDuration 解析时长, 空值返回 0 (使用 go-redis 的默认值), "-1" 表示禁用
+func parseDuration(value string) (time.Duration, error) {
+	switch value {
+	case "":
+		return 0, nil
+	case "-1":
+		return -1, nil
+	}
+	return time.ParseDuration(value)
+}
+
+func newTLSConfig(config *config.TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         config.ServerName,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+	if config.CAFile != "" {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in ca file %s", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if config.CertFile != "" || config.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
@@ -1,6 +1,9 @@
 package internal
 
 import (
+	"fmt"
+
+	"github.com/LouYuanbo1/go-webservice/redisx/codec"
 	"github.com/LouYuanbo1/go-webservice/redisx/options"
 )
 
@@ -11,3 +14,38 @@ func (rx *redisX[T]) ttlBuilder(opts ...options.TTLOption) *options.TTL {
 	}
 	return ttl
 }
+
+// encode 按配置的 Codec 编码, 超过阈值时压缩
+func (rx *redisX[T]) encode(value T) ([]byte, error) {
+	c := rx.encoding.GetCodec()
+	data, err := c.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s marshal: %w", c.Name(), err)
+	}
+	return codec.Compress(rx.encoding.GetCompressor(), rx.encoding.GetThreshold(), data)
+}
+
+// decode 先去掉 GetOrLoad 的头部, 按头部解压(没有头部的旧数据原样返回), 再按配置的 Codec 解码
+func (rx *redisX[T]) decode(data []byte, result *T) error {
+	data, err := unwrapLoaded(data)
+	if err != nil {
+		return err
+	}
+	data, err = codec.Decompress(data)
+	if err != nil {
+		return err
+	}
+	c := rx.encoding.GetCodec()
+	if err := c.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("%s unmarshal: %w", c.Name(), err)
+	}
+	return nil
+}
+
+func (rx *redisX[T]) loadBuilder(opts ...options.LoadOption) *options.Load {
+	load := options.NewLoad().WithTTL(rx.defaultTTLKey)
+	for _, opt := range opts {
+		opt(load)
+	}
+	return load
+}
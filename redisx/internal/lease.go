@@ -0,0 +1,147 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// 仅当槽位仍属于当前持有者时续期
+var renewScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("pexpire", KEYS[1], ARGV[2])
+else
+	return 0
+end
+`)
+
+// 仅当槽位仍属于当前持有者时删除
+var releaseScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+else
+	return 0
+end
+`)
+
+type lease struct {
+	client redis.UniversalClient
+	key    string
+	token  string
+	slot   int64
+	ttl    time.Duration
+
+	lost     chan struct{}
+	lostOnce sync.Once
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+func AcquireLease(ctx context.Context, client redis.UniversalClient, prefix string, slots int64, ttl time.Duration) (*lease, error) {
+	if client == nil {
+		return nil, fmt.Errorf("redis client cannot be nil")
+	}
+	if slots <= 0 {
+		return nil, fmt.Errorf("lease slots must be positive, got %d", slots)
+	}
+	if ttl < time.Second {
+		return nil, fmt.Errorf("lease ttl must be at least 1s, got %s", ttl)
+	}
+
+	token := uuid.New().String()
+	// 从随机位置开始探测, 减少多个实例同时启动时的竞争
+	start := rand.Int64N(slots)
+	for i := range slots {
+		slot := (start + i) % slots
+		key := fmt.Sprintf("%s:%d", prefix, slot)
+		ok, err := client.SetNX(ctx, key, token, ttl).Result()
+		if err != nil {
+			return nil, fmt.Errorf("redis acquire lease error: %w", err)
+		}
+		if !ok {
+			continue
+		}
+
+		l := &lease{
+			client: client,
+			key:    key,
+			token:  token,
+			slot:   slot,
+			ttl:    ttl,
+			lost:   make(chan struct{}),
+			stop:   make(chan struct{}),
+			done:   make(chan struct{}),
+		}
+		go l.keepAlive()
+		return l, nil
+	}
+	return nil, fmt.Errorf("redis acquire lease error: all %d slots of %q are taken", slots, prefix)
+}
+
+func (l *lease) Slot() int64 {
+	return l.slot
+}
+
+func (l *lease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+func (l *lease) Release(ctx context.Context) error {
+	l.stopOnce.Do(func() { close(l.stop) })
+	<-l.done
+	l.markLost()
+
+	if err := releaseScript.Run(ctx, l.client, []string{l.key}, l.token).Err(); err != nil {
+		log.Printf("redis release lease error: %v", err)
+		return fmt.Errorf("redis release lease error: %w", err)
+	}
+	return nil
+}
+
+func (l *lease) markLost() {
+	l.lostOnce.Do(func() { close(l.lost) })
+}
+
+// keepAlive 每 ttl/3 续期一次, 续期持续失败且租约即将过期时视为丢失
+func (l *lease) keepAlive() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	deadline := time.Now().Add(l.ttl)
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+
+		renewAt := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), l.ttl/3)
+		renewed, err := renewScript.Run(ctx, l.client, []string{l.key}, l.token, l.ttl.Milliseconds()).Int64()
+		cancel()
+		switch {
+		case err == nil && renewed == 1:
+			deadline = renewAt.Add(l.ttl)
+		case err == nil:
+			// 槽位已过期或被其他实例占用
+			log.Printf("redis lease %s lost", l.key)
+			l.markLost()
+			return
+		default:
+			log.Printf("redis renew lease %s error: %v", l.key, err)
+			// 下一次续期前租约就会过期, 提前放弃
+			if !time.Now().Add(l.ttl / 3).Before(deadline) {
+				l.markLost()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/redisx/options"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrNotFound 由 loader 返回表示数据不存在, 开启空结果缓存时 GetOrLoad 命中空结果也返回该错误
+var ErrNotFound = errors.New("redisx: not found")
+
+var (
+	// negativeValue 是缓存的空结果
+	negativeValue = []byte("\x00RXN")
+	// loadedHeader 标记 GetOrLoad 写入的值, 后跟 4 字节的加载耗时(毫秒), 用于提前刷新
+	loadedHeader = []byte("\x00RXL")
+)
+
+// lockPollInterval 是等待其他实例加载时读取缓存的间隔
+const lockPollInterval = 50 * time.Millisecond
+
+func (rx *redisX[T]) GetOrLoad(ctx context.Context, key string, loader func(ctx context.Context) (T, error), opts ...options.LoadOption) (T, error) {
+	var zero T
+	load := rx.loadBuilder(opts...)
+
+	var get *redis.StringCmd
+	var pttl *redis.DurationCmd
+	_, err := rx.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pttl = pipe.PTTL(ctx, key)
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		log.Printf("redis get or load error: %v", err)
+		return zero, fmt.Errorf("redis get or load error: %w", err)
+	}
+
+	if data, err := get.Bytes(); err == nil {
+		var value T
+		err = rx.decode(data, &value)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return zero, ErrNotFound
+		case err == nil:
+			if rx.shouldRefresh(data, pttl.Val(), load.GetBeta()) {
+				go rx.refresh(context.WithoutCancel(ctx), key, loader, load)
+			}
+			return value, nil
+		}
+		// 无法解码的旧数据按未命中处理, 重新加载后覆盖
+		log.Printf("redis get or load decode error, reloading. key: %s, error: %v", key, err)
+	}
+
+	// 同一进程内并发加载同一个 key 时只执行一次, 单个调用方取消不影响其他调用方
+	ch := rx.group.DoChan(key, func() (any, error) {
+		return rx.load(context.WithoutCancel(ctx), key, loader, load, true)
+	})
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return zero, res.Err
+		}
+		return res.Val.(T), nil
+	}
+}
+
+// load 执行 loader 并写入缓存; wait 为 false 时(后台刷新)未抢到锁直接放弃
+func (rx *redisX[T]) load(ctx context.Context, key string, loader func(ctx context.Context) (T, error), load *options.Load, wait bool) (T, error) {
+	var zero T
+	if enabled, lockTTL, lockWait := load.GetLock(); enabled {
+		lockKey := key + ":lock"
+		lockID, ok, err := rx.Acquire(ctx, lockKey, lockTTL)
+		if err != nil {
+			log.Printf("redis get or load lock error, loading without lock. key: %s, error: %v", key, err)
+		}
+		switch {
+		case ok:
+			defer func() {
+				if err := rx.Release(ctx, lockKey, lockID); err != nil {
+					log.Printf("redis get or load unlock error. key: %s, error: %v", key, err)
+				}
+			}()
+		case err == nil && !wait:
+			return zero, nil
+		case err == nil:
+			// 其他实例正在加载, 等待其写入缓存
+			if value, found, err := rx.waitLoaded(ctx, key, lockWait); found {
+				return value, err
+			}
+			log.Printf("redis get or load lock wait timeout, loading. key: %s", key)
+		}
+	}
+
+	start := time.Now()
+	value, err := loader(ctx)
+	elapsed := time.Since(start)
+	if errors.Is(err, ErrNotFound) {
+		if ttl := load.GetNegativeTTL(); ttl > 0 {
+			if err := rx.client.Set(ctx, key, negativeValue, ttl).Err(); err != nil {
+				log.Printf("redis set negative error. key: %s, error: %v", key, err)
+			}
+		}
+		return zero, ErrNotFound
+	}
+	if err != nil {
+		return zero, err
+	}
+
+	data, err := rx.encode(value)
+	if err != nil {
+		log.Printf("redis encode error: %v", err)
+		return zero, fmt.Errorf("redis encode error: %w", err)
+	}
+	envelope := make([]byte, len(loadedHeader)+4, len(loadedHeader)+4+len(data))
+	copy(envelope, loadedHeader)
+	binary.BigEndian.PutUint32(envelope[len(loadedHeader):], uint32(min(elapsed.Milliseconds(), math.MaxUint32)))
+	// 写缓存失败不影响本次结果, 下次读取时重新加载
+	if err := rx.client.Set(ctx, key, append(envelope, data...), load.GetTTL()).Err(); err != nil {
+		log.Printf("redis set error. key: %s, error: %v", key, err)
+	}
+	return value, nil
+}
+
+// waitLoaded 等待其他实例写入缓存, 超时返回 found = false
+func (rx *redisX[T]) waitLoaded(ctx context.Context, key string, wait time.Duration) (T, bool, error) {
+	var zero T
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+	timeout := time.After(wait)
+	for {
+		select {
+		case <-ctx.Done():
+			return zero, true, ctx.Err()
+		case <-timeout:
+			return zero, false, nil
+		case <-ticker.C:
+		}
+		data, err := rx.client.Get(ctx, key).Bytes()
+		if err != nil {
+			continue
+		}
+		var value T
+		if err := rx.decode(data, &value); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return zero, true, ErrNotFound
+			}
+			continue
+		}
+		return value, true, nil
+	}
+}
+
+func (rx *redisX[T]) refresh(ctx context.Context, key string, loader func(ctx context.Context) (T, error), load *options.Load) {
+	// 刷新未抢到锁时直接放弃, 使用单独的 singleflight key, 避免同步加载的调用方拿到空结果
+	_, err, _ := rx.group.Do("refresh:"+key, func() (any, error) {
+		return rx.load(ctx, key, loader, load, false)
+	})
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		log.Printf("redis early refresh error. key: %s, error: %v", key, err)
+	}
+}
+
+/*
+shouldRefresh 实现 XFetch 算法: 当 加载耗时 * beta * -ln(rand) >= 剩余过期时间 时提前刷新,
+剩余时间越短, 加载越慢, 刷新的概率越大.
+*/
+func (rx *redisX[T]) shouldRefresh(data []byte, ttl time.Duration, beta float64) bool {
+	if beta <= 0 || ttl <= 0 || !bytes.HasPrefix(data, loadedHeader) || len(data) < len(loadedHeader)+4 {
+		return false
+	}
+	delta := time.Duration(binary.BigEndian.Uint32(data[len(loadedHeader):])) * time.Millisecond
+	if delta <= 0 {
+		delta = time.Millisecond
+	}
+	return float64(delta)*beta*-math.Log(1-rand.Float64()) >= float64(ttl)
+}
+
+// unwrapLoaded 去掉 GetOrLoad 写入的头部, 空结果返回 ErrNotFound
+func unwrapLoaded(data []byte) ([]byte, error) {
+	if bytes.Equal(data, negativeValue) {
+		return nil, ErrNotFound
+	}
+	if bytes.HasPrefix(data, loadedHeader) && len(data) >= len(loadedHeader)+4 {
+		return data[len(loadedHeader)+4:], nil
+	}
+	return data, nil
+}
@@ -0,0 +1,417 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/redisx/options"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+/*
+锁保存在哈希 {key} 中(owner, count, token), 防护令牌计数器保存在 {key}:fence 中,
+两个 key 的 hash tag 相同, 在 Cluster 中位于同一个槽.
+*/
+
+// 未被持有或由同一持有者重入时加锁, 返回防护令牌, 被其他持有者占用时返回 0
+var lockAcquireScript = redis.NewScript(`
+local owner = redis.call("hget", KEYS[1], "owner")
+if not owner then
+	local token = redis.call("incr", KEYS[2])
+	redis.call("hset", KEYS[1], "owner", ARGV[1], "count", 1, "token", token)
+	redis.call("pexpire", KEYS[1], ARGV[2])
+	return token
+elseif owner == ARGV[1] then
+	redis.call("hincrby", KEYS[1], "count", 1)
+	redis.call("pexpire", KEYS[1], ARGV[2])
+	return tonumber(redis.call("hget", KEYS[1], "token"))
+end
+return 0
+`)
+
+// 将防护令牌计数器提升到不小于 ARGV[1], Redlock 用它把选中的令牌写回各节点
+var fenceFloorScript = redis.NewScript(`
+local fence = tonumber(redis.call("get", KEYS[1]) or "0")
+if fence < tonumber(ARGV[1]) then
+	redis.call("set", KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+// 仅当锁属于当前持有者时续期
+var lockRenewScript = redis.NewScript(`
+if redis.call("hget", KEYS[1], "owner") == ARGV[1] then
+	return redis.call("pexpire", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// 重入计数减一, 归零时删除; 锁不属于当前持有者时返回 -1
+var lockReleaseScript = redis.NewScript(`
+if redis.call("hget", KEYS[1], "owner") ~= ARGV[1] then
+	return -1
+end
+local count = redis.call("hincrby", KEYS[1], "count", -1)
+if count > 0 then
+	redis.call("pexpire", KEYS[1], ARGV[2])
+	return count
+end
+redis.call("del", KEYS[1])
+return 0
+`)
+
+// ErrLockLost 表示锁已经丢失但还没有被完全解锁, 此时不能重入, 需要先 Unlock 每一次加锁
+var ErrLockLost = errors.New("redis lock lost")
+
+type lock struct {
+	// 单节点锁只有一个节点, Redlock 需要在多数(quorum)节点上加锁成功
+	nodes    []redis.UniversalClient
+	quorum   int
+	key      string
+	fenceKey string
+	owner    string
+	config   *options.Lock
+
+	mu    sync.Mutex
+	held  int
+	token int64
+	lost  chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+func NewLock(client redis.UniversalClient, key string, opts ...options.LockOption) *lock {
+	return newLock([]redis.UniversalClient{client}, key, opts...)
+}
+
+func NewRedlock(clients []redis.UniversalClient, key string, opts ...options.LockOption) (*lock, error) {
+	if len(clients) == 0 {
+		return nil, fmt.Errorf("redlock requires at least one redis client")
+	}
+	if slices.Contains(clients, nil) {
+		return nil, fmt.Errorf("redis client cannot be nil")
+	}
+	return newLock(clients, key, opts...), nil
+}
+
+func newLock(nodes []redis.UniversalClient, key string, opts ...options.LockOption) *lock {
+	config := options.NewLockWithOptions(opts...)
+	owner := config.GetOwner()
+	if owner == "" {
+		owner = uuid.New().String()
+	}
+	lost := make(chan struct{})
+	close(lost)
+	return &lock{
+		nodes:    nodes,
+		quorum:   len(nodes)/2 + 1,
+		key:      "{" + key + "}",
+		fenceKey: "{" + key + "}:fence",
+		owner:    owner,
+		config:   config,
+		lost:     lost,
+	}
+}
+
+func (l *lock) Lock(ctx context.Context) (int64, error) {
+	minBackoff, maxBackoff := l.config.GetBackoff()
+	backoff := minBackoff
+	for {
+		token, ok, err := l.TryLock(ctx)
+		if err != nil || ok {
+			return token, err
+		}
+		// 随机浮动 ±20%, 避免多个等待者同时重试
+		wait := time.Duration(float64(backoff) * (0.8 + 0.4*rand.Float64()))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, fmt.Errorf("redis lock %s error: %w", l.key, ctx.Err())
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+func (l *lock) TryLock(ctx context.Context) (int64, bool, error) {
+	if l.heldButLost() {
+		return 0, false, fmt.Errorf("redis lock %s error: %w", l.key, ErrLockLost)
+	}
+	ttl := l.config.GetTTL()
+	start := time.Now()
+	results := l.runAll(ctx, func(ctx context.Context, client redis.UniversalClient) (int64, error) {
+		return lockAcquireScript.Run(ctx, client, []string{l.key, l.fenceKey}, l.owner, ttl.Milliseconds()).Int64()
+	})
+	var acquired int
+	var token int64
+	for _, r := range results {
+		if r.err == nil && r.value > 0 {
+			acquired++
+			token = max(token, r.value)
+		}
+	}
+	if acquired >= l.quorum && len(l.nodes) > 1 {
+		acquired = l.raiseFence(ctx, results, token)
+	}
+
+	// 扣除加锁耗时与时钟漂移后锁仍然有效才算成功
+	if acquired < l.quorum || ttl-time.Since(start)-clockDrift(ttl) <= 0 {
+		if acquired > 0 {
+			l.undo(ctx)
+		}
+		if err := l.quorumError(results); err != nil {
+			log.Printf("redis lock %s error: %v", l.key, err)
+			return 0, false, fmt.Errorf("redis lock %s error: %w", l.key, err)
+		}
+		return 0, false, nil
+	}
+
+	l.mu.Lock()
+	if l.held > 0 && isClosed(l.lost) {
+		// 加锁期间看门狗标记了丢失, Redis 中可能是新建的锁, 不能按重入计数
+		l.mu.Unlock()
+		l.undo(ctx)
+		return 0, false, fmt.Errorf("redis lock %s error: %w", l.key, ErrLockLost)
+	}
+	defer l.mu.Unlock()
+	l.held++
+	if l.held == 1 {
+		l.token = token
+		l.lost = make(chan struct{})
+		if l.config.GetWatchdog() {
+			l.stop = make(chan struct{})
+			l.done = make(chan struct{})
+			go l.watchdog(l.lost, l.stop, l.done)
+		}
+	}
+	return l.token, true, nil
+}
+
+func (l *lock) Unlock(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	if l.held == 0 {
+		l.mu.Unlock()
+		return false, nil
+	}
+	l.held--
+	released := l.held == 0
+	lost, stop, done := l.lost, l.stop, l.done
+	if released {
+		l.stop, l.done = nil, nil
+	}
+	l.mu.Unlock()
+
+	// 看门狗标记丢失时需要获取 mu, 因此在 mu 之外等待其退出
+	if released {
+		if stop != nil {
+			close(stop)
+			<-done
+		}
+		l.mu.Lock()
+		closeOnce(lost)
+		l.mu.Unlock()
+	}
+
+	// 在所有节点上释放, 包括加锁时未成功的节点
+	results := l.runAll(ctx, func(ctx context.Context, client redis.UniversalClient) (int64, error) {
+		return lockReleaseScript.Run(ctx, client, []string{l.key}, l.owner, l.config.GetTTL().Milliseconds()).Int64()
+	})
+	var held int
+	for _, r := range results {
+		if r.err == nil && r.value >= 0 {
+			held++
+		}
+	}
+	if held >= l.quorum {
+		return true, nil
+	}
+	if err := l.quorumError(results); err != nil {
+		log.Printf("redis unlock %s error: %v", l.key, err)
+		return false, fmt.Errorf("redis unlock %s error: %w", l.key, err)
+	}
+	log.Printf("redis unlock %s: lock was no longer held", l.key)
+	return false, nil
+}
+
+func (l *lock) Token() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held == 0 {
+		return 0
+	}
+	return l.token
+}
+
+func (l *lock) Lost() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lost
+}
+
+// heldButLost 返回锁是否仍被本地持有但已经丢失
+func (l *lock) heldButLost() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.held > 0 && isClosed(l.lost)
+}
+
+/*
+raiseFence 将选中的令牌写回加锁成功的节点(fence = max(fence, token)), 返回写回成功的节点数.
+各节点的计数器相互独立, 新的多数派可能不包含计数器最大的节点; 写回后任意两个多数派至少有一个公共节点
+保存了上一次的令牌, 因此下一次取到的最大值一定更大.
+*/
+func (l *lock) raiseFence(ctx context.Context, results []nodeResult, token int64) int {
+	raised := l.runAll(ctx, func(ctx context.Context, client redis.UniversalClient) (int64, error) {
+		r := results[slices.Index(l.nodes, client)]
+		switch {
+		case r.err != nil || r.value <= 0:
+			return 0, nil
+		case r.value == token:
+			return 1, nil
+		}
+		return fenceFloorScript.Run(ctx, client, []string{l.fenceKey}, token).Int64()
+	})
+	var n int
+	for _, r := range raised {
+		if r.err == nil && r.value == 1 {
+			n++
+		}
+	}
+	return n
+}
+
+// undo 撤销刚刚在各节点上的一次加锁(或重入计数)
+func (l *lock) undo(ctx context.Context) {
+	l.runAll(context.WithoutCancel(ctx), func(ctx context.Context, client redis.UniversalClient) (int64, error) {
+		return lockReleaseScript.Run(ctx, client, []string{l.key}, l.owner, l.config.GetTTL().Milliseconds()).Int64()
+	})
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// closeOnce 关闭 ch, 已关闭时忽略, 调用方需持有 mu
+func closeOnce(ch chan struct{}) {
+	if !isClosed(ch) {
+		close(ch)
+	}
+}
+
+// clockDrift 是 Redlock 对各节点时钟漂移的补偿: TTL 的 1% 加 2ms
+func clockDrift(ttl time.Duration) time.Duration {
+	return ttl/100 + 2*time.Millisecond
+}
+
+type nodeResult struct {
+	value int64
+	err   error
+}
+
+/*
+runAll 在所有节点上并发执行 fn. 多节点时每个节点的超时远小于 TTL,
+避免单个不可用的节点耗尽锁的有效时间.
+*/
+func (l *lock) runAll(ctx context.Context, fn func(ctx context.Context, client redis.UniversalClient) (int64, error)) []nodeResult {
+	results := make([]nodeResult, len(l.nodes))
+	if len(l.nodes) == 1 {
+		results[0].value, results[0].err = fn(ctx, l.nodes[0])
+		return results
+	}
+	timeout := max(l.config.GetTTL()/100, 50*time.Millisecond)
+	var wg sync.WaitGroup
+	for i, client := range l.nodes {
+		wg.Go(func() {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			results[i].value, results[i].err = fn(ctx, client)
+		})
+	}
+	wg.Wait()
+	return results
+}
+
+// nodeErrors 合并所有节点的错误
+func nodeErrors(results []nodeResult) []error {
+	var errs []error
+	for _, r := range results {
+		if r.err != nil {
+			errs = append(errs, r.err)
+		}
+	}
+	return errs
+}
+
+// quorumError 在出错的节点过多以致无法达到多数时返回合并的错误, 否则返回 nil
+func (l *lock) quorumError(results []nodeResult) error {
+	if errs := nodeErrors(results); len(errs) > len(l.nodes)-l.quorum {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// watchdog 每 ttl/3 续期一次, 锁被其他持有者占用或续期持续失败且即将过期时关闭 lost
+func (l *lock) watchdog(lost, stop, done chan struct{}) {
+	defer close(done)
+	ttl := l.config.GetTTL()
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+
+	markLost := func() {
+		l.mu.Lock()
+		closeOnce(lost)
+		l.mu.Unlock()
+	}
+	deadline := time.Now().Add(ttl)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		renewAt := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+		results := l.runAll(ctx, func(ctx context.Context, client redis.UniversalClient) (int64, error) {
+			return lockRenewScript.Run(ctx, client, []string{l.key}, l.owner, ttl.Milliseconds()).Int64()
+		})
+		cancel()
+		var renewed, failed int
+		for _, r := range results {
+			switch {
+			case r.err != nil:
+				failed++
+			case r.value == 1:
+				renewed++
+			}
+		}
+		switch {
+		case renewed >= l.quorum:
+			deadline = renewAt.Add(ttl - clockDrift(ttl))
+		case renewed+failed < l.quorum:
+			// 多数节点上的锁已过期或被其他持有者占用
+			log.Printf("redis lock %s lost", l.key)
+			markLost()
+			return
+		default:
+			log.Printf("redis renew lock %s error: %v", l.key, errors.Join(nodeErrors(results)...))
+			if !time.Now().Add(ttl / 3).Before(deadline) {
+				markLost()
+				return
+			}
+		}
+	}
+}
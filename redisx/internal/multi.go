@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/LouYuanbo1/go-webservice/redisx/options"
+	"github.com/redis/go-redis/v9"
+)
+
+// multiKeyBatchSize 是单条 MGET / DEL 命令包含的最大 key 数量
+const multiKeyBatchSize = 500
+
+// KeyErrors 记录批量操作中每个失败 key 的错误
+type KeyErrors map[string]error
+
+func (e KeyErrors) Error() string {
+	keys := make([]string, 0, len(e))
+	for key := range e {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s: %v", key, e[key])
+	}
+	return fmt.Sprintf("%d keys failed: %s", len(e), strings.Join(parts, "; "))
+}
+
+func (rx *redisX[T]) MGet(ctx context.Context, keys []string) (map[string]T, []string, error) {
+	groups := groupBySlot(rx.client, keys)
+	var cmds []*redis.SliceCmd
+	var cmdKeys [][]string
+	_, err := rx.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, group := range groups {
+			for _, batch := range chunk(group, multiKeyBatchSize) {
+				cmds = append(cmds, pipe.MGet(ctx, batch...))
+				cmdKeys = append(cmdKeys, batch)
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		log.Printf("redis mget error: %v", err)
+		return nil, nil, fmt.Errorf("redis mget error: %w", err)
+	}
+
+	result := make(map[string]T, len(keys))
+	var missing []string
+	keyErrors := make(KeyErrors)
+	for i, cmd := range cmds {
+		for j, value := range cmd.Val() {
+			key := cmdKeys[i][j]
+			s, ok := value.(string)
+			if !ok {
+				missing = append(missing, key)
+				continue
+			}
+			var v T
+			err := rx.decode([]byte(s), &v)
+			if errors.Is(err, ErrNotFound) {
+				missing = append(missing, key)
+				continue
+			}
+			if err != nil {
+				keyErrors[key] = err
+				continue
+			}
+			result[key] = v
+		}
+	}
+	if len(keyErrors) > 0 {
+		log.Printf("redis mget decode error: %v", keyErrors)
+		return result, missing, fmt.Errorf("redis mget decode error: %w", keyErrors)
+	}
+	return result, missing, nil
+}
+
+func (rx *redisX[T]) MSetWithTTL(ctx context.Context, values map[string]T, opts ...options.TTLOption) error {
+	if len(values) == 0 {
+		return nil
+	}
+	// 先编码全部的值, 任意一个失败时不写入
+	encoded := make(map[string][]byte, len(values))
+	keyErrors := make(KeyErrors)
+	for key, value := range values {
+		data, err := rx.encode(value)
+		if err != nil {
+			keyErrors[key] = err
+			continue
+		}
+		encoded[key] = data
+	}
+	if len(keyErrors) > 0 {
+		log.Printf("redis mset encode error: %v", keyErrors)
+		return fmt.Errorf("redis mset encode error: %w", keyErrors)
+	}
+
+	ttl := rx.ttlBuilder(opts...)
+	keys := make([]string, 0, len(encoded))
+	for key := range encoded {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	cmds := make(map[string]*redis.StatusCmd, len(keys))
+	_, err := rx.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, group := range groupBySlot(rx.client, keys) {
+			for _, key := range group {
+				cmds[key] = pipe.Set(ctx, key, encoded[key], ttl.GetKeyTTL(key))
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		return nil
+	}
+	for key, cmd := range cmds {
+		if cmd.Err() != nil {
+			keyErrors[key] = cmd.Err()
+		}
+	}
+	if len(keyErrors) == 0 {
+		log.Printf("redis mset error: %v", err)
+		return fmt.Errorf("redis mset error: %w", err)
+	}
+	log.Printf("redis mset error: %v", keyErrors)
+	return fmt.Errorf("redis mset error: %w", keyErrors)
+}
+
+func (rx *redisX[T]) MDel(ctx context.Context, keys []string) (int64, error) {
+	var cmds []*redis.IntCmd
+	_, err := rx.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, group := range groupBySlot(rx.client, keys) {
+			for _, batch := range chunk(group, multiKeyBatchSize) {
+				cmds = append(cmds, pipe.Del(ctx, batch...))
+			}
+		}
+		return nil
+	})
+	var deleted int64
+	for _, cmd := range cmds {
+		deleted += cmd.Val()
+	}
+	if err != nil {
+		log.Printf("redis mdel error: %v", err)
+		return deleted, fmt.Errorf("redis mdel error: %w", err)
+	}
+	return deleted, nil
+}
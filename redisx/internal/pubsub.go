@@ -0,0 +1,161 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// subscriptionBufferSize 是消息与错误 channel 的缓冲大小
+const subscriptionBufferSize = 100
+
+// Message 是解码后的订阅消息
+type Message[T any] struct {
+	// Channel 是消息发布到的频道
+	Channel string
+	// Pattern 是匹配该频道的模式, 仅模式订阅时非空
+	Pattern string
+	Payload T
+}
+
+// DecodeError 是无法解码的消息, 通过 Subscription.Errors 返回, 不影响后续消息
+type DecodeError struct {
+	Channel string
+	Payload []byte
+	Err     error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("redis subscribe decode error. channel: %s, error: %v", e.Channel, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Subscription 是一个类型化的订阅
+type Subscription[T any] interface {
+	// Messages 返回解码后的消息, 订阅结束后关闭
+	Messages() <-chan Message[T]
+	// Errors 返回解码失败的消息(*DecodeError), 缓冲区满时丢弃并记录日志, 订阅结束后关闭
+	Errors() <-chan error
+	// Close 取消订阅并等待 Messages 与 Errors 关闭
+	Close() error
+}
+
+type subscription[T any] struct {
+	pubsub   *redis.PubSub
+	cancel   context.CancelFunc
+	messages chan Message[T]
+	errors   chan error
+	done     chan struct{}
+}
+
+func (rx *redisX[T]) Publish(ctx context.Context, channel string, value T) (int64, error) {
+	data, err := rx.encode(value)
+	if err != nil {
+		log.Printf("redis encode error: %v", err)
+		return 0, fmt.Errorf("redis encode error: %w", err)
+	}
+	receivers, err := rx.client.Publish(ctx, channel, data).Result()
+	if err != nil {
+		log.Printf("redis publish error: %v", err)
+		return 0, fmt.Errorf("redis publish error: %w", err)
+	}
+	return receivers, nil
+}
+
+func (rx *redisX[T]) Subscribe(ctx context.Context, channels ...string) (Subscription[T], error) {
+	if len(channels) == 0 {
+		return nil, fmt.Errorf("redis subscribe requires at least one channel")
+	}
+	return rx.subscribe(ctx, rx.client.Subscribe(ctx, channels...))
+}
+
+func (rx *redisX[T]) PSubscribe(ctx context.Context, patterns ...string) (Subscription[T], error) {
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("redis psubscribe requires at least one pattern")
+	}
+	return rx.subscribe(ctx, rx.client.PSubscribe(ctx, patterns...))
+}
+
+func (rx *redisX[T]) subscribe(ctx context.Context, pubsub *redis.PubSub) (Subscription[T], error) {
+	// 等待订阅确认, 连接或权限错误在这里返回, 之后的断线由 go-redis 自动重连并重新订阅
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		log.Printf("redis subscribe error: %v", err)
+		return nil, fmt.Errorf("redis subscribe error: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &subscription[T]{
+		pubsub:   pubsub,
+		cancel:   cancel,
+		messages: make(chan Message[T], subscriptionBufferSize),
+		errors:   make(chan error, subscriptionBufferSize),
+		done:     make(chan struct{}),
+	}
+	go s.run(ctx, rx.decode)
+	return s, nil
+}
+
+/*
+run 将收到的消息解码后转发, ctx 结束时取消订阅并关闭 channel.
+pubsub.Channel 定期 PING 检测连接, 断线时自动重连并重新订阅, 断线期间发布的消息会丢失.
+*/
+func (s *subscription[T]) run(ctx context.Context, decode func([]byte, *T) error) {
+	defer close(s.done)
+	defer close(s.errors)
+	defer close(s.messages)
+	defer func() {
+		if err := s.pubsub.Close(); err != nil {
+			log.Printf("redis unsubscribe error: %v", err)
+		}
+	}()
+
+	ch := s.pubsub.Channel(redis.WithChannelSize(subscriptionBufferSize))
+	for {
+		var msg *redis.Message
+		select {
+		case <-ctx.Done():
+			return
+		case m, ok := <-ch:
+			if !ok {
+				return
+			}
+			msg = m
+		}
+
+		var value T
+		if err := decode([]byte(msg.Payload), &value); err != nil {
+			decodeErr := &DecodeError{Channel: msg.Channel, Payload: []byte(msg.Payload), Err: err}
+			select {
+			case s.errors <- decodeErr:
+			default:
+				log.Printf("%v (dropped, errors channel is full)", decodeErr)
+			}
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case s.messages <- Message[T]{Channel: msg.Channel, Pattern: msg.Pattern, Payload: value}:
+		}
+	}
+}
+
+func (s *subscription[T]) Messages() <-chan Message[T] {
+	return s.messages
+}
+
+func (s *subscription[T]) Errors() <-chan error {
+	return s.errors
+}
+
+func (s *subscription[T]) Close() error {
+	s.cancel()
+	<-s.done
+	return nil
+}
@@ -0,0 +1,232 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/redisx/options"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Algorithm 是限流算法
+type Algorithm string
+
+const (
+	SlidingWindowLog     Algorithm = "sliding_window_log"
+	SlidingWindowCounter Algorithm = "sliding_window_counter"
+	TokenBucket          Algorithm = "token_bucket"
+	GCRA                 Algorithm = "gcra"
+)
+
+// RateLimitResult 是一次限流判断的结果
+type RateLimitResult struct {
+	Allowed bool
+	// Limit 是窗口内允许的请求数(令牌桶与 GCRA 为突发容量)
+	Limit int
+	// Remaining 是当前还可以立即通过的请求数
+	Remaining int
+	// ResetAfter 是限额完全恢复所需的时间
+	ResetAfter time.Duration
+	// RetryAfter 是被拒绝时到下一次可能通过的等待时间, 通过时为 0
+	RetryAfter time.Duration
+}
+
+/*
+所有脚本使用 Redis 服务器的 TIME 作为当前时间, 避免各实例的时钟偏差, 时间单位为毫秒.
+脚本返回 {allowed, remaining, reset_ms, retry_ms}.
+*/
+
+// 滑动窗口日志: 有序集合记录窗口内每个请求的时间, 精确但内存与请求数成正比
+var slidingWindowLogScript = redis.NewScript(`
+local limit, window, n = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+redis.call("zremrangebyscore", KEYS[1], "-inf", now - window)
+local count = redis.call("zcard", KEYS[1])
+local allowed, retry = 0, 0
+if count + n <= limit then
+	for i = 1, n do
+		redis.call("zadd", KEYS[1], now, ARGV[4] .. ":" .. i)
+	end
+	count = count + n
+	allowed = 1
+else
+	-- 需要等到第 count+n-limit 个最早的请求移出窗口
+	local e = redis.call("zrange", KEYS[1], count + n - limit - 1, count + n - limit - 1, "WITHSCORES")
+	retry = tonumber(e[2]) + window - now
+end
+local reset = 0
+local newest = redis.call("zrange", KEYS[1], -1, -1, "WITHSCORES")
+if newest[2] then
+	reset = tonumber(newest[2]) + window - now
+	redis.call("pexpire", KEYS[1], reset)
+end
+return {allowed, limit - count, reset, retry}
+`)
+
+// 滑动窗口计数: 按上一个窗口的计数加权估算, 内存固定, 误差很小
+var slidingWindowCounterScript = redis.NewScript(`
+local limit, window, n = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local cur = math.floor(now / window)
+local elapsed = now - cur * window
+local fields = redis.call("hkeys", KEYS[1])
+for _, f in ipairs(fields) do
+	if tonumber(f) < cur - 1 then
+		redis.call("hdel", KEYS[1], f)
+	end
+end
+local prev = tonumber(redis.call("hget", KEYS[1], tostring(cur - 1)) or "0")
+local count = tonumber(redis.call("hget", KEYS[1], tostring(cur)) or "0")
+local weight = (window - elapsed) / window
+local estimated = prev * weight + count
+local allowed, retry = 0, 0
+if estimated + n <= limit then
+	redis.call("hincrby", KEYS[1], tostring(cur), n)
+	redis.call("pexpire", KEYS[1], window * 2)
+	count = count + n
+	estimated = estimated + n
+	allowed = 1
+else
+	-- 当前窗口内上一窗口的权重逐渐减小, 不够时等到下一个窗口
+	local need = limit - count - n
+	if prev > 0 and need >= 0 then
+		retry = math.ceil(window - elapsed - need * window / prev)
+	else
+		retry = window - elapsed
+		if count > 0 then
+			retry = retry + math.max(0, math.ceil(window - (limit - n) * window / count))
+		end
+	end
+end
+-- 上一窗口的权重随时间降为 0, 当前窗口的计数在下一个窗口结束时完全移出
+local reset = window - elapsed
+if count > 0 then
+	reset = reset + window
+end
+return {allowed, math.max(0, math.floor(limit - estimated)), reset, retry}
+`)
+
+// 令牌桶: 以 rate 的速度补充令牌, 容量为 burst
+var tokenBucketScript = redis.NewScript(`
+local burst, period, limit, n = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3]), tonumber(ARGV[4])
+local rate = limit / period
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local state = redis.call("hmget", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+local allowed, retry = 0, 0
+if tokens >= n then
+	tokens = tokens - n
+	allowed = 1
+else
+	retry = math.ceil((n - tokens) / rate)
+end
+local reset = math.ceil((burst - tokens) / rate)
+redis.call("hset", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("pexpire", KEYS[1], math.max(reset, 1))
+return {allowed, math.floor(tokens), reset, retry}
+`)
+
+// GCRA: 只保存理论到达时间(TAT), 等价于令牌桶但只需一个值
+var gcraScript = redis.NewScript(`
+local burst, period, limit, n = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3]), tonumber(ARGV[4])
+local interval = period / limit
+local tolerance = interval * burst
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local tat = math.max(tonumber(redis.call("get", KEYS[1]) or "0"), now)
+local newTat = tat + n * interval
+local allowAt = newTat - tolerance
+local allowed, retry = 0, 0
+if allowAt <= now then
+	tat = newTat
+	allowed = 1
+	redis.call("set", KEYS[1], tostring(tat), "PX", math.max(math.ceil(tat - now), 1))
+else
+	retry = math.ceil(allowAt - now)
+end
+local remaining = math.floor((now - (tat - tolerance)) / interval)
+return {allowed, math.max(0, remaining), math.ceil(tat - now), retry}
+`)
+
+type rateLimiter struct {
+	client    redis.UniversalClient
+	algorithm Algorithm
+	limit     int
+	period    time.Duration
+	config    *options.RateLimit
+}
+
+func NewRateLimiter(client redis.UniversalClient, algorithm Algorithm, limit int, period time.Duration, opts ...options.RateLimitOption) (*rateLimiter, error) {
+	if client == nil {
+		return nil, fmt.Errorf("redis client cannot be nil")
+	}
+	switch algorithm {
+	case SlidingWindowLog, SlidingWindowCounter, TokenBucket, GCRA:
+	default:
+		return nil, fmt.Errorf("unsupported rate limit algorithm %q", algorithm)
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("rate limit must be positive, got %d", limit)
+	}
+	if period < time.Millisecond {
+		return nil, fmt.Errorf("rate limit period must be at least 1ms, got %s", period)
+	}
+	config := options.NewRateLimitWithOptions(opts...)
+	if config.GetBurst() < 0 {
+		return nil, fmt.Errorf("rate limit burst cannot be negative, got %d", config.GetBurst())
+	}
+	return &rateLimiter{client: client, algorithm: algorithm, limit: limit, period: period, config: config}, nil
+}
+
+func (rl *rateLimiter) Allow(ctx context.Context, key string) (RateLimitResult, error) {
+	return rl.AllowN(ctx, key, 1)
+}
+
+func (rl *rateLimiter) AllowN(ctx context.Context, key string, n int) (RateLimitResult, error) {
+	capacity := rl.capacity()
+	if n <= 0 || n > capacity {
+		return RateLimitResult{}, fmt.Errorf("rate limit n must be in [1, %d], got %d", capacity, n)
+	}
+
+	keys := []string{rl.config.GetKeyPrefix() + key}
+	period := rl.period.Milliseconds()
+	var cmd *redis.Cmd
+	switch rl.algorithm {
+	case SlidingWindowLog:
+		cmd = slidingWindowLogScript.Run(ctx, rl.client, keys, rl.limit, period, n, uuid.New().String())
+	case SlidingWindowCounter:
+		cmd = slidingWindowCounterScript.Run(ctx, rl.client, keys, rl.limit, period, n)
+	case TokenBucket:
+		cmd = tokenBucketScript.Run(ctx, rl.client, keys, capacity, period, rl.limit, n)
+	case GCRA:
+		cmd = gcraScript.Run(ctx, rl.client, keys, capacity, period, rl.limit, n)
+	}
+	values, err := cmd.Int64Slice()
+	if err != nil {
+		log.Printf("redis rate limit %s error: %v", key, err)
+		return RateLimitResult{}, fmt.Errorf("redis rate limit error: %w", err)
+	}
+	return RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      capacity,
+		Remaining:  int(values[1]),
+		ResetAfter: time.Duration(values[2]) * time.Millisecond,
+		RetryAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
+
+// capacity 返回一次最多允许的请求数, 令牌桶与 GCRA 为 burst (默认等于 limit)
+func (rl *rateLimiter) capacity() int {
+	if burst := rl.config.GetBurst(); burst > 0 && (rl.algorithm == TokenBucket || rl.algorithm == GCRA) {
+		return burst
+	}
+	return rl.limit
+}
@@ -2,7 +2,6 @@ package internal
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"time"
@@ -11,27 +10,30 @@ import (
 	"github.com/go-viper/mapstructure/v2"
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
 )
 
 type redisX[T any] struct {
-	client        *redis.Client
+	client        redis.UniversalClient
 	defaultTTLKey time.Duration
+	encoding      *options.Encoding
+	group         singleflight.Group
 }
 
-func NewRedisX[T any](client *redis.Client, defaultTTLKey time.Duration) *redisX[T] {
-	return &redisX[T]{client: client, defaultTTLKey: defaultTTLKey}
+func NewRedisX[T any](client redis.UniversalClient, defaultTTLKey time.Duration, opts ...options.EncodingOption) *redisX[T] {
+	return &redisX[T]{client: client, defaultTTLKey: defaultTTLKey, encoding: options.NewEncodingWithOptions(opts...)}
 }
 
 func (rx *redisX[T]) SetWithTTL(ctx context.Context, key string, value T, opts ...options.TTLOption) error {
-	jsonValue, err := json.Marshal(value)
+	data, err := rx.encode(value)
 	if err != nil {
-		log.Printf("json marshal error: %v", err)
-		return fmt.Errorf("json marshal error: %w", err)
+		log.Printf("redis encode error: %v", err)
+		return fmt.Errorf("redis encode error: %w", err)
 	}
 
 	ttl := rx.ttlBuilder(opts...)
 
-	err = rx.client.Set(ctx, key, jsonValue, ttl.GetTTL()).Err()
+	err = rx.client.Set(ctx, key, data, ttl.GetTTL()).Err()
 	if err != nil {
 		log.Printf("redis set error: %v", err)
 		return fmt.Errorf("redis set error: %w", err)
@@ -77,30 +79,30 @@ func (rx *redisX[T]) HSetWithTTL(ctx context.Context, key string, value T, opts
 
 func (rx *redisX[T]) Get(ctx context.Context, key string) (T, error) {
 	var result T
-	jsonValue, err := rx.client.Get(ctx, key).Bytes()
+	data, err := rx.client.Get(ctx, key).Bytes()
 	if err != nil {
 		log.Printf("redis get error: %v", err)
 		return result, fmt.Errorf("redis get error: %w", err)
 	}
-	err = json.Unmarshal(jsonValue, &result)
+	err = rx.decode(data, &result)
 	if err != nil {
-		log.Printf("json unmarshal error: %v", err)
-		return result, fmt.Errorf("json unmarshal error: %w", err)
+		log.Printf("redis decode error: %v", err)
+		return result, fmt.Errorf("redis decode error: %w", err)
 	}
 	return result, nil
 }
 
 func (rx *redisX[T]) GetPointer(ctx context.Context, key string) (*T, error) {
 	var result T
-	jsonValue, err := rx.client.Get(ctx, key).Bytes()
+	data, err := rx.client.Get(ctx, key).Bytes()
 	if err != nil {
 		log.Printf("redis get error: %v", err)
 		return nil, fmt.Errorf("redis get error: %w", err)
 	}
-	err = json.Unmarshal(jsonValue, &result)
+	err = rx.decode(data, &result)
 	if err != nil {
-		log.Printf("json unmarshal error: %v", err)
-		return nil, fmt.Errorf("json unmarshal error: %w", err)
+		log.Printf("redis decode error: %v", err)
+		return nil, fmt.Errorf("redis decode error: %w", err)
 	}
 	return &result, nil
 }
@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// clusterSlots 是 Redis Cluster 的哈希槽数量
+const clusterSlots = 16384
+
+// crc16Table 是 CRC16-XMODEM 的查找表, 与 Redis Cluster 的 key 分布算法一致
+var crc16Table = func() [256]uint16 {
+	var table [256]uint16
+	for i := range table {
+		crc := uint16(i) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+// hashSlot 返回 key 所在的哈希槽, key 中包含非空的 {tag} 时只对 tag 计算
+func hashSlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	var crc uint16
+	for i := 0; i < len(key); i++ {
+		crc = crc<<8 ^ crc16Table[byte(crc>>8)^key[i]]
+	}
+	return int(crc) % clusterSlots
+}
+
+/*
+isCluster 判断客户端是否连接真实的 Redis Cluster.
+Sentinel 开启 read_only 时使用 NewFailoverClusterClient, 它同样是 *redis.ClusterClient,
+但通过 ClusterSlots 将全部哈希槽映射到同一组节点, 不需要按槽拆分命令(go-redis 也按 ClusterSlots 区分两者).
+*/
+func isCluster(client redis.UniversalClient) bool {
+	cc, ok := client.(*redis.ClusterClient)
+	return ok && cc.Options().ClusterSlots == nil
+}
+
+// groupBySlot 按哈希槽分组并去重, 非 Cluster 客户端返回一组; 每组按 key 的首次出现顺序排列
+func groupBySlot(client redis.UniversalClient, keys []string) [][]string {
+	seen := make(map[string]bool, len(keys))
+	unique := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, key)
+		}
+	}
+	if len(unique) == 0 {
+		return nil
+	}
+	if !isCluster(client) {
+		return [][]string{unique}
+	}
+
+	index := make(map[int]int)
+	var groups [][]string
+	for _, key := range unique {
+		slot := hashSlot(key)
+		i, ok := index[slot]
+		if !ok {
+			i = len(groups)
+			index[slot] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], key)
+	}
+	return groups
+}
+
+// chunk 将 keys 按 size 切分, 避免单条命令过大阻塞 Redis
+func chunk(keys []string, size int) [][]string {
+	var chunks [][]string
+	for len(keys) > size {
+		chunks = append(chunks, keys[:size:size])
+		keys = keys[size:]
+	}
+	return append(chunks, keys)
+}
@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestHashSlot(t *testing.T) {
+	// 与 CLUSTER KEYSLOT 的结果一致
+	cases := map[string]int{
+		"foo":                  12182,
+		"123456789":            12739,
+		"{user1000}.following": hashSlot("user1000"),
+		"foo{{bar}}":           hashSlot("{bar"),
+	}
+	for key, want := range cases {
+		if got := hashSlot(key); got != want {
+			t.Errorf("hashSlot(%q) = %d, want %d", key, got, want)
+		}
+	}
+	if hashSlot("{user1000}.following") != hashSlot("{user1000}.followers") {
+		t.Error("keys with the same hash tag are in different slots")
+	}
+}
+
+func TestGroupBySlot(t *testing.T) {
+	keys := []string{"a", "{u1}.x", "b", "{u1}.y", "a", "c"}
+	unique := []string{"a", "{u1}.x", "b", "{u1}.y", "c"}
+
+	standalone := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	defer standalone.Close()
+	if got := groupBySlot(standalone, keys); len(got) != 1 || !slices.Equal(got[0], unique) {
+		t.Errorf("standalone groups = %v, want one deduplicated group", got)
+	}
+
+	// Sentinel read_only 使用的 FailoverClusterClient 只有一组节点, 不按槽拆分
+	failover := redis.NewFailoverClusterClient(&redis.FailoverOptions{MasterName: "mymaster", SentinelAddrs: []string{"127.0.0.1:0"}, RouteRandomly: true})
+	defer failover.Close()
+	if got := groupBySlot(failover, keys); len(got) != 1 || !slices.Equal(got[0], unique) {
+		t.Errorf("failover cluster groups = %v, want one deduplicated group", got)
+	}
+
+	cluster := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{"127.0.0.1:0"}})
+	defer cluster.Close()
+	groups := groupBySlot(cluster, keys)
+	var flat []string
+	for _, group := range groups {
+		slot := hashSlot(group[0])
+		for _, key := range group {
+			if hashSlot(key) != slot {
+				t.Errorf("group %v mixes slots", group)
+			}
+		}
+		flat = append(flat, group...)
+	}
+	if len(flat) != len(unique) {
+		t.Errorf("cluster groups = %v, want each of %v once", groups, unique)
+	}
+	if !slices.Equal(groups[1], []string{"{u1}.x", "{u1}.y"}) {
+		t.Errorf("keys with the same hash tag = %v, want one group in first-seen order", groups[1])
+	}
+
+	if got := groupBySlot(cluster, nil); got != nil {
+		t.Errorf("empty keys = %v", got)
+	}
+}
+
+func TestChunk(t *testing.T) {
+	keys := make([]string, 7)
+	for i := range keys {
+		keys[i] = fmt.Sprint(i)
+	}
+	chunks := chunk(keys, 3)
+	if len(chunks) != 3 || len(chunks[0]) != 3 || len(chunks[2]) != 1 {
+		t.Fatalf("chunks = %v", chunks)
+	}
+	// 每块的容量被截断, append 不会覆盖下一块
+	_ = append(chunks[0], "x")
+	if chunks[1][0] != "3" {
+		t.Error("appending to a chunk overwrote the next one")
+	}
+}
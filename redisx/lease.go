@@ -0,0 +1,42 @@
+package redisx
+
+import (
+	"context"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/redisx/internal"
+	"github.com/redis/go-redis/v9"
+)
+
+/*
+Lease 是 Redis 上带 TTL 的槽位租约, 用于在多个实例间分配有限的编号(例如 Snowflake worker ID).
+租约在后台按 TTL/3 的间隔续期, 续期失败或租约被其他实例占用时 Lost() 返回的 channel 会被关闭,
+此时持有者必须停止使用该槽位.
+
+Lease is a TTL-bound slot lease in Redis, used to hand out a small set of numbers
+(e.g. Snowflake worker IDs) among instances. The lease is renewed in the background;
+Lost() is closed once renewal fails and the slot must no longer be used.
+*/
+type Lease interface {
+	// Slot 返回分配到的槽位编号 [0, slots)
+	Slot() int64
+	// Lost 在租约丢失后关闭
+	Lost() <-chan struct{}
+	// Release 停止续期并释放槽位
+	Release(ctx context.Context) error
+}
+
+/*
+AcquireLease 在 prefix:{0..slots-1} 中抢占一个空闲槽位, 所有槽位都被占用时返回错误.
+
+Example:
+
+	lease, err := redisx.AcquireLease(ctx, client, "idgen:worker", 1024, 30*time.Second)
+	if err != nil {
+		return err
+	}
+	defer lease.Release(context.Background())
+*/
+func AcquireLease(ctx context.Context, client redis.UniversalClient, prefix string, slots int64, ttl time.Duration) (Lease, error) {
+	return internal.AcquireLease(ctx, client, prefix, slots, ttl)
+}
@@ -0,0 +1,240 @@
+package redisx_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/redisx"
+	"github.com/LouYuanbo1/go-webservice/redisx/options"
+)
+
+func TestGetOrLoadCollapsesConcurrentLoads(t *testing.T) {
+	_, client := newMiniredisClient(t)
+	ctx := context.Background()
+	rx := redisx.NewRedisX[cachedProduct](client, time.Minute)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (cachedProduct, error) {
+		calls.Add(1)
+		<-release
+		return cachedProduct{ID: 1, Description: "loaded"}, nil
+	}
+
+	const callers = 10
+	var wg sync.WaitGroup
+	results := make(chan cachedProduct, callers)
+	for range callers {
+		wg.Go(func() {
+			value, err := rx.GetOrLoad(ctx, "p:1", loader)
+			if err != nil {
+				t.Error(err)
+			}
+			results <- value
+		})
+	}
+	// 等待所有调用方进入 singleflight 后再放行 loader
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	for value := range results {
+		if value.Description != "loaded" {
+			t.Errorf("value = %+v", value)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("loader called %d times, want 1", n)
+	}
+	if _, err := rx.GetOrLoad(ctx, "p:1", loader); err != nil || calls.Load() != 1 {
+		t.Errorf("cached GetOrLoad() err = %v, loader calls = %d", err, calls.Load())
+	}
+}
+
+func TestGetOrLoadNegativeCache(t *testing.T) {
+	m, client := newMiniredisClient(t)
+	ctx := context.Background()
+	rx := redisx.NewRedisX[cachedProduct](client, time.Minute)
+
+	var calls atomic.Int32
+	loader := func(ctx context.Context) (cachedProduct, error) {
+		calls.Add(1)
+		return cachedProduct{}, redisx.ErrNotFound
+	}
+
+	// 未开启空结果缓存时每次都调用 loader, 也不写入缓存
+	for range 2 {
+		if _, err := rx.GetOrLoad(ctx, "p:none", loader); !errors.Is(err, redisx.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	}
+	if calls.Load() != 2 || m.Exists("p:none") {
+		t.Fatalf("loader calls = %d, key cached = %v", calls.Load(), m.Exists("p:none"))
+	}
+
+	calls.Store(0)
+	for range 3 {
+		if _, err := rx.GetOrLoad(ctx, "p:none", loader, options.WithNegativeTTL(10*time.Second)); !errors.Is(err, redisx.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("loader called %d times with a negative ttl, want 1", n)
+	}
+	if ttl := m.TTL("p:none"); ttl != 10*time.Second {
+		t.Errorf("negative ttl = %v", ttl)
+	}
+
+	// 其他读取方式把空结果视为不存在
+	if _, err := rx.Get(ctx, "p:none"); !errors.Is(err, redisx.ErrNotFound) {
+		t.Errorf("Get() of a negative entry = %v, want ErrNotFound", err)
+	}
+	if values, missing, err := rx.MGet(ctx, []string{"p:none"}); err != nil || len(values) != 0 || len(missing) != 1 {
+		t.Errorf("MGet() of a negative entry = %v, %v, %v", values, missing, err)
+	}
+
+	m.FastForward(11 * time.Second)
+	if _, err := rx.GetOrLoad(ctx, "p:none", loader, options.WithNegativeTTL(10*time.Second)); !errors.Is(err, redisx.ErrNotFound) || calls.Load() != 2 {
+		t.Errorf("after expiry err = %v, loader calls = %d, want 2", err, calls.Load())
+	}
+}
+
+func TestGetOrLoadWaitsForLockHolder(t *testing.T) {
+	m, client := newMiniredisClient(t)
+	ctx := context.Background()
+	// 两个 RedisX 模拟两个实例, singleflight 不共享, 只能通过分布式锁协调
+	a := redisx.NewRedisX[cachedProduct](client, time.Minute)
+	b := redisx.NewRedisX[cachedProduct](client, time.Minute)
+	lock := options.WithLoadLock(5*time.Second, 2*time.Second)
+
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		_, err := a.GetOrLoad(ctx, "p:1", func(ctx context.Context) (cachedProduct, error) {
+			<-release
+			return cachedProduct{ID: 1, Description: "from a"}, nil
+		}, lock)
+		done <- err
+	}()
+	waitUntil(t, func() bool { return m.Exists("p:1:lock") })
+
+	var bCalls atomic.Int32
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		close(release)
+	}()
+	value, err := b.GetOrLoad(ctx, "p:1", func(ctx context.Context) (cachedProduct, error) {
+		bCalls.Add(1)
+		return cachedProduct{ID: 1, Description: "from b"}, nil
+	}, lock)
+	if err != nil || value.Description != "from a" {
+		t.Errorf("waiting instance got %+v, %v, want the lock holder's value", value, err)
+	}
+	if bCalls.Load() != 0 {
+		t.Error("waiting instance called its own loader")
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if m.Exists("p:1:lock") {
+		t.Error("lock was not released after loading")
+	}
+}
+
+func TestGetOrLoadLockWaitTimeout(t *testing.T) {
+	m, client := newMiniredisClient(t)
+	ctx := context.Background()
+	rx := redisx.NewRedisX[cachedProduct](client, time.Minute)
+
+	// 持有锁的实例没有写入缓存, 等待超时后自行加载
+	m.Set("p:1:lock", "other")
+	value, err := rx.GetOrLoad(ctx, "p:1", func(ctx context.Context) (cachedProduct, error) {
+		return cachedProduct{ID: 1, Description: "self"}, nil
+	}, options.WithLoadLock(5*time.Second, 100*time.Millisecond))
+	if err != nil || value.Description != "self" {
+		t.Errorf("GetOrLoad() = %+v, %v", value, err)
+	}
+	if got, _ := m.Get("p:1:lock"); got != "other" {
+		t.Errorf("lock of another instance was changed to %q", got)
+	}
+}
+
+func TestGetOrLoadValueReadableByGet(t *testing.T) {
+	m, client := newMiniredisClient(t)
+	ctx := context.Background()
+	rx := redisx.NewRedisX[cachedProduct](client, time.Minute)
+
+	want := cachedProduct{ID: 1, Description: "loaded"}
+	if _, err := rx.GetOrLoad(ctx, "p:1", func(ctx context.Context) (cachedProduct, error) {
+		return want, nil
+	}, options.WithLoadTTL(30*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := m.Get("p:1")
+	if err != nil || !strings.HasPrefix(raw, "\x00RXL") {
+		t.Fatalf("raw value = %q, %v, want the GetOrLoad envelope", raw, err)
+	}
+	if ttl := m.TTL("p:1"); ttl != 30*time.Second {
+		t.Errorf("ttl = %v", ttl)
+	}
+
+	if got, err := rx.Get(ctx, "p:1"); err != nil || got != want {
+		t.Errorf("Get() = %+v, %v", got, err)
+	}
+	if got, err := rx.GetPointer(ctx, "p:1"); err != nil || *got != want {
+		t.Errorf("GetPointer() = %+v, %v", got, err)
+	}
+	if values, _, err := rx.MGet(ctx, []string{"p:1"}); err != nil || values["p:1"] != want {
+		t.Errorf("MGet() = %+v, %v", values, err)
+	}
+}
+
+func TestGetOrLoadEarlyRefresh(t *testing.T) {
+	_, client := newMiniredisClient(t)
+	ctx := context.Background()
+	rx := redisx.NewRedisX[cachedProduct](client, time.Minute)
+
+	var version atomic.Int32
+	loader := func(ctx context.Context) (cachedProduct, error) {
+		return cachedProduct{ID: uint64(version.Add(1))}, nil
+	}
+	if _, err := rx.GetOrLoad(ctx, "p:1", loader); err != nil {
+		t.Fatal(err)
+	}
+
+	// beta 极大时几乎必定提前刷新: 命中时先返回旧值, 后台写入新值
+	got, err := rx.GetOrLoad(ctx, "p:1", loader, options.WithEarlyRefresh(1e9))
+	if err != nil || got.ID != 1 {
+		t.Fatalf("GetOrLoad() = %+v, %v, want the cached value", got, err)
+	}
+	waitUntil(t, func() bool {
+		value, err := rx.Get(ctx, "p:1")
+		return err == nil && value.ID == 2
+	})
+
+	// 未开启时不刷新
+	if got, _ := rx.GetOrLoad(ctx, "p:1", loader); got.ID != 2 {
+		t.Errorf("GetOrLoad() = %+v", got)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if version.Load() != 2 {
+		t.Errorf("loader called %d times, want 2", version.Load())
+	}
+}
+
+func waitUntil(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 2s")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,61 @@
+package redisx
+
+import (
+	"context"
+
+	"github.com/LouYuanbo1/go-webservice/redisx/internal"
+	"github.com/LouYuanbo1/go-webservice/redisx/options"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrLockLost 由 Lock 与 TryLock 返回, 表示锁已经丢失但还没有被完全解锁
+var ErrLockLost = internal.ErrLockLost
+
+/*
+Lock 是可重入的分布式锁, 持有期间由看门狗按 TTL/3 的间隔自动续期.
+每次从未持有变为持有时分配单调递增的防护令牌(fencing token), 写入外部存储时携带该令牌,
+存储拒绝小于已见最大令牌的写入, 即可避免锁过期后旧持有者的写入覆盖新持有者.
+
+同一持有者(默认每个 Lock 对象一个随机标识)可以重入, 加锁几次就需要解锁几次.
+Lock 对象代表一个持有者, 不要在需要互相排斥的 goroutine 之间共享.
+
+Lock is a reentrant distributed lock with a renewal watchdog and fencing tokens.
+*/
+type Lock interface {
+	// Lock 阻塞直到获得锁或 ctx 结束, 等待期间按退避策略重试, 返回防护令牌
+	Lock(ctx context.Context) (int64, error)
+	/*
+		TryLock 尝试获得锁一次, 锁被其他持有者占用时返回 false.
+		锁丢失后(Lost 已关闭)返回 ErrLockLost, 直到每一次加锁都已 Unlock, 之后可以重新加锁并获得新的防护令牌.
+	*/
+	TryLock(ctx context.Context) (int64, bool, error)
+	// Unlock 释放一次重入, 返回释放前锁是否仍由当前持有者持有(false 表示锁已过期或被他人占用)
+	Unlock(ctx context.Context) (bool, error)
+	// Token 返回当前持有的防护令牌, 未持有时返回 0
+	Token() int64
+	// Lost 在锁丢失(续期失败或被他人占用)或完全释放后关闭, 未持有时返回已关闭的 channel
+	Lost() <-chan struct{}
+}
+
+/*
+NewLock 创建名为 key 的锁, 锁保存在 Redis 的 {key} 与 {key}:fence 中.
+
+Example:
+
+	lock := redisx.NewLock(client, "order:1001", options.WithLockTTL(10*time.Second))
+	token, err := lock.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock(context.Background())
+
+	select {
+	case <-lock.Lost():
+		return errors.New("lock lost")
+	default:
+	}
+	return store.SaveWithFence(ctx, order, token)
+*/
+func NewLock(client redis.UniversalClient, key string, opts ...options.LockOption) Lock {
+	return internal.NewLock(client, key, opts...)
+}
@@ -0,0 +1,131 @@
+package redisx_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/redisx"
+	"github.com/LouYuanbo1/go-webservice/redisx/options"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newMiniredisClient(t *testing.T) (*miniredis.Miniredis, redis.UniversalClient) {
+	t.Helper()
+	m := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return m, client
+}
+
+func TestLockReentrantAndExclusive(t *testing.T) {
+	_, client := newMiniredisClient(t)
+	ctx := context.Background()
+	a := redisx.NewLock(client, "job", options.WithLockTTL(time.Second))
+	b := redisx.NewLock(client, "job", options.WithLockTTL(time.Second))
+
+	token, err := a.Lock(ctx)
+	if err != nil || token <= 0 {
+		t.Fatalf("Lock() = %d, %v", token, err)
+	}
+	if again, ok, err := a.TryLock(ctx); err != nil || !ok || again != token {
+		t.Fatalf("reentrant TryLock() = %d, %v, %v, want %d, true, nil", again, ok, err, token)
+	}
+	if _, ok, err := b.TryLock(ctx); err != nil || ok {
+		t.Fatalf("TryLock() by another owner = %v, %v, want false, nil", ok, err)
+	}
+
+	if held, err := a.Unlock(ctx); err != nil || !held {
+		t.Fatalf("first Unlock() = %v, %v", held, err)
+	}
+	if _, ok, _ := b.TryLock(ctx); ok {
+		t.Fatal("lock released after one Unlock of two acquisitions")
+	}
+	if held, err := a.Unlock(ctx); err != nil || !held {
+		t.Fatalf("second Unlock() = %v, %v", held, err)
+	}
+
+	next, ok, err := b.TryLock(ctx)
+	if err != nil || !ok || next <= token {
+		t.Fatalf("TryLock() after release = %d, %v, %v, want a token > %d", next, ok, err, token)
+	}
+	b.Unlock(ctx)
+}
+
+func TestLockWatchdogRenews(t *testing.T) {
+	m, client := newMiniredisClient(t)
+	ctx := context.Background()
+	l := redisx.NewLock(client, "job", options.WithLockTTL(300*time.Millisecond))
+	if _, err := l.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Unlock(ctx)
+
+	// miniredis 不会自动过期, 手动推进时间, 看门狗每 100ms 续期一次
+	for range 10 {
+		time.Sleep(100 * time.Millisecond)
+		m.FastForward(100 * time.Millisecond)
+	}
+	select {
+	case <-l.Lost():
+		t.Fatal("lock lost although the watchdog was renewing it")
+	default:
+	}
+	if !m.Exists("{job}") {
+		t.Fatal("lock key expired although the watchdog was renewing it")
+	}
+}
+
+func TestLockReacquireAfterLost(t *testing.T) {
+	m, client := newMiniredisClient(t)
+	ctx := context.Background()
+	l := redisx.NewLock(client, "job", options.WithLockTTL(300*time.Millisecond))
+	token, err := l.Lock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 锁过期后被其他持有者占用, 看门狗续期失败并标记丢失
+	m.Del("{job}")
+	other := redisx.NewLock(client, "job", options.WithLockTTL(300*time.Millisecond), options.WithWatchdog(false))
+	otherToken, ok, err := other.TryLock(ctx)
+	if err != nil || !ok {
+		t.Fatalf("TryLock() by another owner = %v, %v", ok, err)
+	}
+	select {
+	case <-l.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("Lost() not closed after the lock was taken over")
+	}
+
+	// 丢失后不能按重入处理, 也不能返回旧的防护令牌
+	if _, ok, err := l.TryLock(ctx); ok || !errors.Is(err, redisx.ErrLockLost) {
+		t.Fatalf("TryLock() after loss = %v, %v, want ErrLockLost", ok, err)
+	}
+	if held, err := l.Unlock(ctx); err != nil || held {
+		t.Fatalf("Unlock() after loss = %v, %v, want false, nil", held, err)
+	}
+	if !m.Exists("{job}") {
+		t.Fatal("Unlock() of a lost lock deleted the other owner's lock")
+	}
+	other.Unlock(ctx)
+
+	newToken, err := l.Lock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Unlock(ctx)
+	if newToken <= otherToken || newToken <= token {
+		t.Fatalf("token after reacquire = %d, want > %d", newToken, otherToken)
+	}
+	if l.Token() != newToken {
+		t.Fatalf("Token() = %d, want %d", l.Token(), newToken)
+	}
+	select {
+	case <-l.Lost():
+		t.Fatal("Lost() closed for the new acquisition")
+	default:
+	}
+}
@@ -0,0 +1,51 @@
+package options
+
+import "github.com/LouYuanbo1/go-webservice/redisx/codec"
+
+// Encoding 是 RedisX 的编码配置, 默认使用 JSON 且不压缩
+type Encoding struct {
+	codec      codec.Codec
+	compressor codec.Compressor
+	threshold  int
+}
+
+func NewEncoding() *Encoding {
+	return &Encoding{codec: codec.JSON}
+}
+
+func (e *Encoding) GetCodec() codec.Codec {
+	return e.codec
+}
+
+func (e *Encoding) GetCompressor() codec.Compressor {
+	return e.compressor
+}
+
+func (e *Encoding) GetThreshold() int {
+	return e.threshold
+}
+
+type EncodingOption func(*Encoding)
+
+// WithCodec 设置值的编码方式, 例如 codec.Msgpack
+func WithCodec(c codec.Codec) EncodingOption {
+	return func(e *Encoding) {
+		e.codec = c
+	}
+}
+
+// WithCompression 对编码后不小于 threshold 字节的值进行压缩, 例如 WithCompression(codec.Zstd, 1024)
+func WithCompression(c codec.Compressor, threshold int) EncodingOption {
+	return func(e *Encoding) {
+		e.compressor = c
+		e.threshold = threshold
+	}
+}
+
+func NewEncodingWithOptions(opts ...EncodingOption) *Encoding {
+	e := NewEncoding()
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
@@ -0,0 +1,84 @@
+package options
+
+import "time"
+
+// Load 是 GetOrLoad 的配置, 默认使用 RedisX 的默认过期时间, 不缓存空结果, 不加分布式锁, 不提前刷新
+type Load struct {
+	ttl         time.Duration
+	negativeTTL time.Duration
+	lock        bool
+	lockTTL     time.Duration
+	lockWait    time.Duration
+	beta        float64
+}
+
+func NewLoad() *Load {
+	return &Load{lockTTL: 10 * time.Second, lockWait: 3 * time.Second}
+}
+
+func (l *Load) GetTTL() time.Duration {
+	return l.ttl
+}
+
+func (l *Load) GetNegativeTTL() time.Duration {
+	return l.negativeTTL
+}
+
+func (l *Load) GetLock() (enabled bool, ttl, wait time.Duration) {
+	return l.lock, l.lockTTL, l.lockWait
+}
+
+func (l *Load) GetBeta() float64 {
+	return l.beta
+}
+
+func (l *Load) WithTTL(ttl time.Duration) *Load {
+	l.ttl = ttl
+	return l
+}
+
+type LoadOption func(*Load)
+
+// WithLoadTTL 设置加载结果的过期时间
+func WithLoadTTL(ttl time.Duration) LoadOption {
+	return func(l *Load) {
+		l.ttl = ttl
+	}
+}
+
+// WithNegativeTTL 在 loader 返回 redisx.ErrNotFound 时缓存空结果 ttl 时长, 避免不存在的 key 反复穿透到数据库
+func WithNegativeTTL(ttl time.Duration) LoadOption {
+	return func(l *Load) {
+		l.negativeTTL = ttl
+	}
+}
+
+/*
+WithLoadLock 在多个实例之间使用分布式锁(Acquire/Release)保证同一时刻只有一个实例加载,
+其他实例最多等待 wait 读取加载结果, 超时后自行加载. ttl 是锁的过期时间, 应大于 loader 的最长耗时.
+*/
+func WithLoadLock(ttl, wait time.Duration) LoadOption {
+	return func(l *Load) {
+		l.lock = true
+		l.lockTTL = ttl
+		l.lockWait = wait
+	}
+}
+
+/*
+WithEarlyRefresh 开启概率提前刷新 (XFetch): 命中时以随剩余时间减少而增大的概率在后台重新加载,
+beta 越大越早刷新, 通常为 1. 加载耗时越长的 key 越早开始刷新.
+*/
+func WithEarlyRefresh(beta float64) LoadOption {
+	return func(l *Load) {
+		l.beta = beta
+	}
+}
+
+func NewLoadWithOptions(opts ...LoadOption) *Load {
+	l := NewLoad()
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
@@ -0,0 +1,76 @@
+package options
+
+import "time"
+
+// Lock 是分布式锁的配置, 默认 TTL 30s, 开启看门狗, 等待锁时从 50ms 开始退避, 最长 1s
+type Lock struct {
+	ttl        time.Duration
+	owner      string
+	watchdog   bool
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+func NewLock() *Lock {
+	return &Lock{
+		ttl:        30 * time.Second,
+		watchdog:   true,
+		minBackoff: 50 * time.Millisecond,
+		maxBackoff: time.Second,
+	}
+}
+
+func (l *Lock) GetTTL() time.Duration {
+	return l.ttl
+}
+
+func (l *Lock) GetOwner() string {
+	return l.owner
+}
+
+func (l *Lock) GetWatchdog() bool {
+	return l.watchdog
+}
+
+func (l *Lock) GetBackoff() (min, max time.Duration) {
+	return l.minBackoff, l.maxBackoff
+}
+
+type LockOption func(*Lock)
+
+// WithLockTTL 设置锁的过期时间, 开启看门狗时每 ttl/3 续期一次
+func WithLockTTL(ttl time.Duration) LockOption {
+	return func(l *Lock) {
+		l.ttl = ttl
+	}
+}
+
+// WithLockOwner 设置锁的持有者标识, 相同持有者可以重入; 默认每个 Lock 对象使用随机标识
+func WithLockOwner(owner string) LockOption {
+	return func(l *Lock) {
+		l.owner = owner
+	}
+}
+
+// WithWatchdog 设置是否在持有期间自动续期, 关闭后锁在 TTL 到期时释放
+func WithWatchdog(enabled bool) LockOption {
+	return func(l *Lock) {
+		l.watchdog = enabled
+	}
+}
+
+// WithLockBackoff 设置 Lock 等待锁时的重试退避范围, 每次翻倍并加入随机浮动
+func WithLockBackoff(min, max time.Duration) LockOption {
+	return func(l *Lock) {
+		l.minBackoff = min
+		l.maxBackoff = max
+	}
+}
+
+func NewLockWithOptions(opts ...LockOption) *Lock {
+	l := NewLock()
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
@@ -0,0 +1,43 @@
+package options
+
+// RateLimit 是限流器的配置, 默认 key 前缀为 "ratelimit:", 突发容量等于 limit
+type RateLimit struct {
+	keyPrefix string
+	burst     int
+}
+
+func NewRateLimit() *RateLimit {
+	return &RateLimit{keyPrefix: "ratelimit:"}
+}
+
+func (r *RateLimit) GetKeyPrefix() string {
+	return r.keyPrefix
+}
+
+func (r *RateLimit) GetBurst() int {
+	return r.burst
+}
+
+type RateLimitOption func(*RateLimit)
+
+// WithRateLimitKeyPrefix 设置限流 key 的前缀, 不同用途的限流器应使用不同的前缀
+func WithRateLimitKeyPrefix(prefix string) RateLimitOption {
+	return func(r *RateLimit) {
+		r.keyPrefix = prefix
+	}
+}
+
+// WithBurst 设置令牌桶与 GCRA 的突发容量, 对滑动窗口算法无效
+func WithBurst(burst int) RateLimitOption {
+	return func(r *RateLimit) {
+		r.burst = burst
+	}
+}
+
+func NewRateLimitWithOptions(opts ...RateLimitOption) *RateLimit {
+	r := NewRateLimit()
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
@@ -3,7 +3,8 @@ package options
 import "time"
 
 type TTL struct {
-	ttl time.Duration
+	ttl    time.Duration
+	keyTTL func(key string) time.Duration
 }
 
 func NewTTL() *TTL {
@@ -14,6 +15,14 @@ func (t *TTL) GetTTL() time.Duration {
 	return t.ttl
 }
 
+// GetKeyTTL 返回 key 的过期时间, 设置了 WithKeyTTL 时使用其结果, 否则使用 GetTTL
+func (t *TTL) GetKeyTTL(key string) time.Duration {
+	if t.keyTTL != nil {
+		return t.keyTTL(key)
+	}
+	return t.ttl
+}
+
 func (t *TTL) WithTTL(ttl time.Duration) *TTL {
 	t.ttl = ttl
 	return t
@@ -27,6 +36,13 @@ func WithTTL(ttl time.Duration) TTLOption {
 	}
 }
 
+// WithKeyTTL 为批量写入的每个 key 单独计算过期时间, 例如加入随机偏移避免缓存同时过期
+func WithKeyTTL(fn func(key string) time.Duration) TTLOption {
+	return func(t *TTL) {
+		t.keyTTL = fn
+	}
+}
+
 func NewTTLWithOptions(opts ...TTLOption) *TTL {
 	t := NewTTL()
 	for _, opt := range opts {
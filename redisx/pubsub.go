@@ -0,0 +1,30 @@
+package redisx
+
+import "github.com/LouYuanbo1/go-webservice/redisx/internal"
+
+// Message 是解码后的订阅消息, Pattern 仅在 PSubscribe 时非空
+type Message[T any] = internal.Message[T]
+
+// DecodeError 是无法按 RedisX 的编码解码的消息, 通过 Subscription.Errors 返回
+type DecodeError = internal.DecodeError
+
+/*
+Subscription 是类型化的订阅, 断线后自动重连并重新订阅(断线期间发布的消息会丢失),
+ctx 结束或调用 Close 时取消订阅并关闭 Messages 与 Errors.
+
+Example:
+
+	sub, err := rx.Subscribe(ctx, "cache:invalidate")
+	if err != nil {
+		return err
+	}
+	go func() {
+		for err := range sub.Errors() {
+			log.Printf("invalid message: %v", err)
+		}
+	}()
+	for msg := range sub.Messages() {
+		localCache.Delete(msg.Payload.Key)
+	}
+*/
+type Subscription[T any] = internal.Subscription[T]
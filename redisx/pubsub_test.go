@@ -0,0 +1,167 @@
+package redisx_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/redisx"
+)
+
+type event struct {
+	Key string `json:"key"`
+}
+
+func receive[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v, ok := <-ch:
+		if !ok {
+			t.Fatal("channel closed")
+		}
+		return v
+	case <-time.After(2 * time.Second):
+		t.Fatal("nothing received within 2s")
+	}
+	var zero T
+	return zero
+}
+
+func expectClosed[T any](t *testing.T, name string, ch <-chan T) {
+	t.Helper()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Fatalf("%s delivered a value after the subscription ended", name)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("%s not closed within 2s", name)
+	}
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	_, client := newMiniredisClient(t)
+	ctx := context.Background()
+	rx := redisx.NewRedisX[event](client, time.Minute)
+
+	sub, err := rx.Subscribe(ctx, "cache:invalidate", "cache:other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	receivers, err := rx.Publish(ctx, "cache:invalidate", event{Key: "product:1"})
+	if err != nil || receivers != 1 {
+		t.Fatalf("Publish() = %d, %v, want 1 receiver", receivers, err)
+	}
+	msg := receive(t, sub.Messages())
+	if msg.Channel != "cache:invalidate" || msg.Pattern != "" || msg.Payload.Key != "product:1" {
+		t.Errorf("message = %+v", msg)
+	}
+
+	if _, err := rx.Publish(ctx, "cache:other", event{Key: "product:2"}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := receive(t, sub.Messages()); msg.Channel != "cache:other" || msg.Payload.Key != "product:2" {
+		t.Errorf("message = %+v", msg)
+	}
+
+	if _, err := rx.Subscribe(ctx); err == nil {
+		t.Error("Subscribe() without channels should fail")
+	}
+	if _, err := rx.PSubscribe(ctx); err == nil {
+		t.Error("PSubscribe() without patterns should fail")
+	}
+}
+
+func TestPSubscribePattern(t *testing.T) {
+	_, client := newMiniredisClient(t)
+	ctx := context.Background()
+	rx := redisx.NewRedisX[event](client, time.Minute)
+
+	sub, err := rx.PSubscribe(ctx, "notify:*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	if _, err := rx.Publish(ctx, "other", event{Key: "ignored"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rx.Publish(ctx, "notify:user:1", event{Key: "user:1"}); err != nil {
+		t.Fatal(err)
+	}
+	msg := receive(t, sub.Messages())
+	if msg.Channel != "notify:user:1" || msg.Pattern != "notify:*" || msg.Payload.Key != "user:1" {
+		t.Errorf("message = %+v", msg)
+	}
+}
+
+func TestSubscribeDecodeError(t *testing.T) {
+	_, client := newMiniredisClient(t)
+	ctx := context.Background()
+	rx := redisx.NewRedisX[event](client, time.Minute)
+
+	sub, err := rx.Subscribe(ctx, "events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	if err := client.Publish(ctx, "events", "not json").Err(); err != nil {
+		t.Fatal(err)
+	}
+	var decodeErr *redisx.DecodeError
+	if err := receive(t, sub.Errors()); !errors.As(err, &decodeErr) {
+		t.Fatalf("error = %v, want *DecodeError", err)
+	}
+	if decodeErr.Channel != "events" || string(decodeErr.Payload) != "not json" || decodeErr.Err == nil {
+		t.Errorf("decode error = %+v", decodeErr)
+	}
+
+	// 无法解码的消息不影响后续消息
+	if _, err := rx.Publish(ctx, "events", event{Key: "next"}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := receive(t, sub.Messages()); msg.Payload.Key != "next" {
+		t.Errorf("message = %+v", msg)
+	}
+}
+
+func TestSubscriptionEnds(t *testing.T) {
+	_, client := newMiniredisClient(t)
+	rx := redisx.NewRedisX[event](client, time.Minute)
+
+	t.Run("ContextCanceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		sub, err := rx.Subscribe(ctx, "events")
+		if err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+		expectClosed(t, "Messages()", sub.Messages())
+		expectClosed(t, "Errors()", sub.Errors())
+		if err := sub.Close(); err != nil {
+			t.Errorf("Close() after cancel = %v", err)
+		}
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		ctx := context.Background()
+		sub, err := rx.PSubscribe(ctx, "events:*")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sub.Close(); err != nil {
+			t.Fatal(err)
+		}
+		// Close 等待取消订阅完成后返回
+		expectClosed(t, "Messages()", sub.Messages())
+		expectClosed(t, "Errors()", sub.Errors())
+		waitUntil(t, func() bool {
+			n, err := rx.Publish(ctx, "events:1", event{Key: "late"})
+			return err == nil && n == 0
+		})
+	})
+}
@@ -0,0 +1,112 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// promoteBatchSize 是每次移动到期任务的最大数量
+const promoteBatchSize = 100
+
+/*
+将到期(score <= Redis 服务器当前毫秒时间)的定时任务写入 Stream 并从有序集合与哈希中删除, 返回移动的数量.
+整个过程在脚本中原子执行, 多个消费者同时轮询时每个任务只会被写入一次.
+*/
+var promoteScript = redis.NewScript(`
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local ids = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", now, "LIMIT", 0, ARGV[1])
+for _, id in ipairs(ids) do
+	local payload = redis.call("HGET", KEYS[2], id)
+	if payload then
+		redis.call("XADD", KEYS[3], "*", ARGV[2], payload, ARGV[3], id)
+	end
+	redis.call("ZREM", KEYS[1], id)
+	redis.call("HDEL", KEYS[2], id)
+end
+return #ids
+`)
+
+// 仅当任务仍在等待时修改执行时间, 返回 1, 否则返回 0
+var rescheduleScript = redis.NewScript(`
+if redis.call("ZSCORE", KEYS[1], ARGV[1]) then
+	redis.call("ZADD", KEYS[1], ARGV[2], ARGV[1])
+	return 1
+end
+return 0
+`)
+
+func (q *queue[T]) Schedule(ctx context.Context, id string, payload T, at time.Time) (string, error) {
+	if id == "" {
+		id = uuid.New().String()
+	}
+	data, err := q.opts.Codec.Marshal(payload)
+	if err != nil {
+		log.Printf("queue %s encode error: %v", q.stream, err)
+		return "", fmt.Errorf("queue %s encode error: %w", q.stream, err)
+	}
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, q.delayedJobs, id, data)
+		pipe.ZAdd(ctx, q.delayed, redis.Z{Score: float64(at.UnixMilli()), Member: id})
+		return nil
+	})
+	if err != nil {
+		log.Printf("queue %s schedule %s error: %v", q.stream, id, err)
+		return "", fmt.Errorf("queue %s schedule %s error: %w", q.stream, id, err)
+	}
+	return id, nil
+}
+
+func (q *queue[T]) Cancel(ctx context.Context, id string) (bool, error) {
+	var zrem *redis.IntCmd
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		zrem = pipe.ZRem(ctx, q.delayed, id)
+		pipe.HDel(ctx, q.delayedJobs, id)
+		return nil
+	})
+	if err != nil {
+		log.Printf("queue %s cancel %s error: %v", q.stream, id, err)
+		return false, fmt.Errorf("queue %s cancel %s error: %w", q.stream, id, err)
+	}
+	return zrem.Val() > 0, nil
+}
+
+func (q *queue[T]) Reschedule(ctx context.Context, id string, at time.Time) (bool, error) {
+	n, err := rescheduleScript.Run(ctx, q.client, []string{q.delayed}, id, at.UnixMilli()).Int64()
+	if err != nil {
+		log.Printf("queue %s reschedule %s error: %v", q.stream, id, err)
+		return false, fmt.Errorf("queue %s reschedule %s error: %w", q.stream, id, err)
+	}
+	return n == 1, nil
+}
+
+// poll 每 PollInterval 将到期的定时任务移入 Stream, 直到 ctx 结束
+func (q *queue[T]) poll(ctx context.Context) {
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			n, err := promoteScript.Run(ctx, q.client, []string{q.delayed, q.delayedJobs, q.stream}, promoteBatchSize, payloadField, scheduleIDField).Int64()
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("queue %s promote delayed jobs error: %v", q.stream, err)
+				}
+				break
+			}
+			// 一次没有移完时继续移动, 避免积压
+			if n < promoteBatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package queue
+
+import (
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/redisx/codec"
+)
+
+type Options struct {
+	// Codec 是任务的编码方式, 默认 codec.JSON
+	Codec codec.Codec
+	// Group 是消费者组名称, 默认 "workers"
+	Group string
+	// Consumer 是当前消费者的名称, 默认 主机名-随机后缀
+	Consumer string
+	// Concurrency 是同时执行的任务数, 默认 10
+	Concurrency int
+	// MaxAttempts 是任务最多执行的次数(包括第一次), 超过后移入死信队列, 默认 5
+	MaxAttempts int
+	// MinBackoff 与 MaxBackoff 是失败重试的指数退避范围, 默认 1s ~ 1m
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// VisibilityTimeout 是未确认的任务被认为消费者已失效而被其他消费者接管的时间, 默认 5m
+	VisibilityTimeout time.Duration
+	// ClaimInterval 是检查待重试与失效消费者任务的间隔, 默认 1s
+	ClaimInterval time.Duration
+	// Block 是读取新任务时的最长阻塞时间, 也决定了停止时的最长等待, 默认 2s
+	Block time.Duration
+	// PollInterval 是检查到期定时任务的间隔, 默认 1s
+	PollInterval time.Duration
+	// ShutdownTimeout 是停止时等待执行中任务完成的最长时间, 超时后取消任务的 ctx, 默认 30s
+	ShutdownTimeout time.Duration
+}
+
+type Option func(*Options)
+
+// WithCodec 设置任务的编码方式
+func WithCodec(c codec.Codec) Option {
+	return func(o *Options) {
+		o.Codec = c
+	}
+}
+
+// WithGroup 设置消费者组, 不同的组各自完整地消费一遍队列
+func WithGroup(group string) Option {
+	return func(o *Options) {
+		o.Group = group
+	}
+}
+
+// WithConsumer 设置消费者名称, 同一个组内的消费者名称必须唯一
+func WithConsumer(consumer string) Option {
+	return func(o *Options) {
+		o.Consumer = consumer
+	}
+}
+
+// WithConcurrency 设置同时执行的任务数
+func WithConcurrency(n int) Option {
+	return func(o *Options) {
+		o.Concurrency = n
+	}
+}
+
+// WithMaxAttempts 设置任务最多执行的次数
+func WithMaxAttempts(n int) Option {
+	return func(o *Options) {
+		o.MaxAttempts = n
+	}
+}
+
+/*
+WithBackoff 设置失败重试的退避范围, 第 n 次失败后等待 min * 2^(n-1), 不超过 max.
+退避通过调整待确认任务的空闲时间实现, 因此实际等待不会超过 VisibilityTimeout.
+*/
+func WithBackoff(min, max time.Duration) Option {
+	return func(o *Options) {
+		o.MinBackoff = min
+		o.MaxBackoff = max
+	}
+}
+
+// WithVisibilityTimeout 设置任务被其他消费者接管前的空闲时间, 执行中的任务会按 1/3 的间隔续期
+func WithVisibilityTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.VisibilityTimeout = d
+	}
+}
+
+// WithClaimInterval 设置检查待重试与失效消费者任务的间隔
+func WithClaimInterval(d time.Duration) Option {
+	return func(o *Options) {
+		o.ClaimInterval = d
+	}
+}
+
+// WithBlock 设置读取新任务时的最长阻塞时间
+func WithBlock(d time.Duration) Option {
+	return func(o *Options) {
+		o.Block = d
+	}
+}
+
+// WithPollInterval 设置检查到期定时任务的间隔
+func WithPollInterval(d time.Duration) Option {
+	return func(o *Options) {
+		o.PollInterval = d
+	}
+}
+
+// WithShutdownTimeout 设置停止时等待执行中任务完成的最长时间
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.ShutdownTimeout = d
+	}
+}
+
+func NewOptions(opts ...Option) *Options {
+	o := &Options{
+		Codec:             codec.JSON,
+		Group:             "workers",
+		Concurrency:       10,
+		MaxAttempts:       5,
+		MinBackoff:        time.Second,
+		MaxBackoff:        time.Minute,
+		VisibilityTimeout: 5 * time.Minute,
+		ClaimInterval:     time.Second,
+		Block:             2 * time.Second,
+		PollInterval:      time.Second,
+		ShutdownTimeout:   30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
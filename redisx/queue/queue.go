@@ -0,0 +1,200 @@
+/*
+Package queue 是基于 Redis Streams 与消费者组的可靠任务队列.
+
+  - 任务写入 Stream {name}, 由消费者组中的消费者读取, 执行成功后确认并删除
+  - 执行失败的任务保留在待确认列表中, 按指数退避后由任意消费者重试
+  - 消费者失效(超过 VisibilityTimeout 未确认)时, 其任务通过 XAUTOCLAIM 被其他消费者接管
+  - 执行 MaxAttempts 次仍失败的任务移入死信队列 {name}:dead, 保留原始字段并附加 id, attempts 与 error
+  - 定时任务保存在有序集合 {name}:delayed 中(按执行时间排序), 到期后由 Run 原子地移入 Stream,
+    等待期间可以按任务 ID 取消或修改执行时间
+  - ctx 结束后停止读取新任务, 等待执行中的任务完成
+
+任务至少执行一次(at-least-once), handler 需要保证幂等.
+
+Package queue is a reliable at-least-once job queue on Redis Streams with consumer groups,
+retry with backoff, reclaiming of jobs from dead consumers and a dead-letter stream.
+
+Example:
+
+	q, err := queue.New[Email](client, "email", queue.WithConcurrency(20))
+	if err != nil {
+		return err
+	}
+	id, err := q.Enqueue(ctx, Email{To: "a@example.com"})
+	// 30 分钟后发送, 之前可以通过 q.Cancel(ctx, "reminder:1001") 取消
+	_, err = q.Schedule(ctx, "reminder:1001", Email{To: "b@example.com"}, time.Now().Add(30*time.Minute))
+
+	// 阻塞直到 ctx 结束且执行中的任务完成
+	err = q.Run(ctx, func(ctx context.Context, job *queue.Job[Email]) error {
+		return mailer.Send(ctx, job.Payload)
+	})
+*/
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// payloadField 是 Stream 消息中保存任务编码的字段
+	payloadField = "payload"
+	// scheduleIDField 是定时任务移入 Stream 时保存任务 ID 的字段
+	scheduleIDField = "schedule_id"
+)
+
+// Job 是一个待执行的任务
+type Job[T any] struct {
+	// ID 是任务在 Stream 中的消息 ID
+	ID      string
+	Payload T
+	// ScheduleID 是通过 Schedule 写入的定时任务的 ID, 直接 Enqueue 的任务为空
+	ScheduleID string
+	// Attempt 是本次为第几次执行, 从 1 开始
+	Attempt int
+	// EnqueuedAt 是任务写入的时间(精确到毫秒)
+	EnqueuedAt time.Time
+}
+
+// Handler 执行任务, 返回 nil 时确认任务, 返回错误或 panic 时按退避重试
+type Handler[T any] func(ctx context.Context, job *Job[T]) error
+
+// Queue 是类型化的任务队列
+type Queue[T any] interface {
+	// Enqueue 写入任务, 返回任务 ID
+	Enqueue(ctx context.Context, payload T) (string, error)
+	/*
+		Schedule 写入在 at 执行的定时任务, 返回任务 ID; id 为空时生成随机 ID.
+		ID 已存在且尚未执行时覆盖其内容与执行时间. 执行时间与 Redis 服务器时间比较, 精度取决于 PollInterval.
+	*/
+	Schedule(ctx context.Context, id string, payload T, at time.Time) (string, error)
+	// Cancel 取消尚未到期的定时任务, 任务不存在或已经移入 Stream 时返回 false
+	Cancel(ctx context.Context, id string) (bool, error)
+	// Reschedule 修改尚未到期的定时任务的执行时间, 任务不存在或已经移入 Stream 时返回 false
+	Reschedule(ctx context.Context, id string, at time.Time) (bool, error)
+	// Run 以当前消费者的身份执行任务并移动到期的定时任务, 阻塞直到 ctx 结束且执行中的任务完成
+	Run(ctx context.Context, handler Handler[T]) error
+	// Stream 返回任务所在的 Stream
+	Stream() string
+	// DeadLetterStream 返回死信队列所在的 Stream
+	DeadLetterStream() string
+}
+
+type queue[T any] struct {
+	client redis.UniversalClient
+	// 所有 key 的 hash tag 相同, 在 Cluster 中位于同一个槽, 脚本可以同时操作
+	stream      string
+	dead        string
+	delayed     string
+	delayedJobs string
+	opts        *Options
+}
+
+/*
+New 创建名为 name 的队列, 任务保存在 Stream {name} 中, 死信保存在 {name}:dead 中,
+定时任务保存在 {name}:delayed (执行时间) 与 {name}:delayed:jobs (任务内容) 中.
+*/
+func New[T any](client redis.UniversalClient, name string, opts ...Option) (Queue[T], error) {
+	if client == nil {
+		return nil, fmt.Errorf("redis client cannot be nil")
+	}
+	if name == "" {
+		return nil, fmt.Errorf("queue name cannot be empty")
+	}
+	o := NewOptions(opts...)
+	switch {
+	case o.Codec == nil:
+		return nil, fmt.Errorf("queue codec cannot be nil")
+	case o.Group == "":
+		return nil, fmt.Errorf("queue group cannot be empty")
+	case o.Concurrency <= 0:
+		return nil, fmt.Errorf("queue concurrency must be positive, got %d", o.Concurrency)
+	case o.MaxAttempts <= 0:
+		return nil, fmt.Errorf("queue max attempts must be positive, got %d", o.MaxAttempts)
+	case o.MinBackoff < 0 || o.MaxBackoff < o.MinBackoff:
+		return nil, fmt.Errorf("invalid queue backoff [%s, %s]", o.MinBackoff, o.MaxBackoff)
+	case o.VisibilityTimeout < 3*time.Millisecond:
+		return nil, fmt.Errorf("queue visibility timeout must be at least 3ms, got %s", o.VisibilityTimeout)
+	case o.ClaimInterval <= 0 || o.Block <= 0 || o.PollInterval <= 0:
+		return nil, fmt.Errorf("queue claim interval, block and poll interval must be positive")
+	}
+	if o.Consumer == "" {
+		hostname, _ := os.Hostname()
+		o.Consumer = hostname + "-" + strings.SplitN(uuid.New().String(), "-", 2)[0]
+	}
+	return &queue[T]{
+		client:      client,
+		stream:      "{" + name + "}",
+		dead:        "{" + name + "}:dead",
+		delayed:     "{" + name + "}:delayed",
+		delayedJobs: "{" + name + "}:delayed:jobs",
+		opts:        o,
+	}, nil
+}
+
+func (q *queue[T]) Stream() string {
+	return q.stream
+}
+
+func (q *queue[T]) DeadLetterStream() string {
+	return q.dead
+}
+
+func (q *queue[T]) Enqueue(ctx context.Context, payload T) (string, error) {
+	data, err := q.opts.Codec.Marshal(payload)
+	if err != nil {
+		log.Printf("queue %s encode error: %v", q.stream, err)
+		return "", fmt.Errorf("queue %s encode error: %w", q.stream, err)
+	}
+	id, err := q.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: q.stream,
+		Values: []any{payloadField, data},
+	}).Result()
+	if err != nil {
+		log.Printf("queue %s enqueue error: %v", q.stream, err)
+		return "", fmt.Errorf("queue %s enqueue error: %w", q.stream, err)
+	}
+	return id, nil
+}
+
+// ensureGroup 创建消费者组, 新建的组从头读取, 已经写入的任务不会被跳过
+func (q *queue[T]) ensureGroup(ctx context.Context) error {
+	err := q.client.XGroupCreateMkStream(ctx, q.stream, q.opts.Group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
+
+// decodeJob 解码 Stream 消息, 无法解码的任务返回错误, 直接移入死信队列
+func (q *queue[T]) decodeJob(msg redis.XMessage, attempt int) (*Job[T], error) {
+	job := &Job[T]{ID: msg.ID, Attempt: attempt, EnqueuedAt: idTime(msg.ID)}
+	job.ScheduleID, _ = msg.Values[scheduleIDField].(string)
+	data, ok := msg.Values[payloadField].(string)
+	if !ok {
+		return job, errors.New("missing payload")
+	}
+	if err := q.opts.Codec.Unmarshal([]byte(data), &job.Payload); err != nil {
+		return job, fmt.Errorf("%s unmarshal: %w", q.opts.Codec.Name(), err)
+	}
+	return job, nil
+}
+
+// idTime 返回 Stream 消息 ID 中的毫秒时间戳
+func idTime(id string) time.Time {
+	ms, _, _ := strings.Cut(id, "-")
+	t, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(t)
+}
@@ -0,0 +1,180 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/redisx/queue"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type testJob struct {
+	N int
+}
+
+func newTestQueue(t *testing.T, opts ...queue.Option) (*miniredis.Miniredis, redis.UniversalClient, queue.Queue[testJob]) {
+	t.Helper()
+	m := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	t.Cleanup(func() { client.Close() })
+	opts = append([]queue.Option{
+		queue.WithConsumer("test"),
+		queue.WithBlock(50 * time.Millisecond),
+		queue.WithClaimInterval(20 * time.Millisecond),
+		queue.WithPollInterval(20 * time.Millisecond),
+	}, opts...)
+	q, err := queue.New[testJob](client, "jobs", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, client, q
+}
+
+// run 在后台执行 q.Run, 返回停止并等待其退出的函数
+func run(t *testing.T, q queue.Queue[testJob], handler queue.Handler[testJob]) func() {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- q.Run(ctx, handler)
+	}()
+	return func() {
+		cancel()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Run() = %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("Run() did not stop")
+		}
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRunRecoversFromTransientErrors(t *testing.T) {
+	m, _, q := newTestQueue(t)
+	ctx := context.Background()
+	var handled atomic.Int32
+	stop := run(t, q, func(ctx context.Context, job *queue.Job[testJob]) error {
+		handled.Add(1)
+		return nil
+	})
+	defer stop()
+
+	// XAUTOCLAIM 与 XREADGROUP 在故障期间都会失败
+	m.SetError("LOADING Redis is loading the dataset in memory")
+	time.Sleep(150 * time.Millisecond)
+	m.SetError("")
+
+	if _, err := q.Enqueue(ctx, testJob{N: 1}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the job enqueued after the outage", func() bool { return handled.Load() == 1 })
+}
+
+func TestRunRetriesAndDeadLetters(t *testing.T) {
+	_, client, q := newTestQueue(t,
+		queue.WithMaxAttempts(3),
+		queue.WithBackoff(10*time.Millisecond, 20*time.Millisecond),
+		queue.WithVisibilityTimeout(100*time.Millisecond),
+	)
+	ctx := context.Background()
+	var mu sync.Mutex
+	attempts := make(map[int][]int)
+	stop := run(t, q, func(ctx context.Context, job *queue.Job[testJob]) error {
+		mu.Lock()
+		attempts[job.Payload.N] = append(attempts[job.Payload.N], job.Attempt)
+		mu.Unlock()
+		switch {
+		case job.Payload.N == 1 && job.Attempt == 1:
+			return errors.New("transient")
+		case job.Payload.N == 2:
+			panic("permanent")
+		}
+		return nil
+	})
+	defer stop()
+
+	q.Enqueue(ctx, testJob{N: 1})
+	q.Enqueue(ctx, testJob{N: 2})
+	waitFor(t, "the failing job to be dead-lettered", func() bool {
+		n, _ := client.XLen(ctx, q.DeadLetterStream()).Result()
+		return n == 1
+	})
+	waitFor(t, "the stream to be drained", func() bool {
+		n, _ := client.XLen(ctx, q.Stream()).Result()
+		return n == 0
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := attempts[1]; len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("attempts of the transient job = %v, want [1 2]", got)
+	}
+	if got := attempts[2]; len(got) != 3 {
+		t.Errorf("attempts of the failing job = %v, want 3 attempts", got)
+	}
+	dead, err := client.XRange(ctx, q.DeadLetterStream(), "-", "+").Result()
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("dead letters = %v, %v", dead, err)
+	}
+	if dead[0].Values["attempts"] != "3" || dead[0].Values["error"] != "panic: permanent" {
+		t.Errorf("dead letter = %v", dead[0].Values)
+	}
+}
+
+func TestScheduleCancelReschedule(t *testing.T) {
+	_, _, q := newTestQueue(t)
+	ctx := context.Background()
+	now := time.Now()
+	q.Schedule(ctx, "due", testJob{N: 1}, now.Add(50*time.Millisecond))
+	q.Schedule(ctx, "cancelled", testJob{N: 2}, now.Add(50*time.Millisecond))
+	q.Schedule(ctx, "moved", testJob{N: 3}, now.Add(time.Hour))
+	if ok, err := q.Cancel(ctx, "cancelled"); err != nil || !ok {
+		t.Fatalf("Cancel() = %v, %v", ok, err)
+	}
+	if ok, err := q.Reschedule(ctx, "moved", now.Add(100*time.Millisecond)); err != nil || !ok {
+		t.Fatalf("Reschedule() = %v, %v", ok, err)
+	}
+	if ok, err := q.Reschedule(ctx, "missing", now); err != nil || ok {
+		t.Fatalf("Reschedule() of a missing job = %v, %v, want false, nil", ok, err)
+	}
+
+	var mu sync.Mutex
+	handled := make(map[string]int)
+	stop := run(t, q, func(ctx context.Context, job *queue.Job[testJob]) error {
+		mu.Lock()
+		handled[job.ScheduleID] = job.Payload.N
+		mu.Unlock()
+		return nil
+	})
+	defer stop()
+
+	waitFor(t, "the scheduled jobs", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(handled) == 2
+	})
+	time.Sleep(100 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if handled["due"] != 1 || handled["moved"] != 3 || len(handled) != 2 {
+		t.Errorf("handled = %v, want due and moved only", handled)
+	}
+}
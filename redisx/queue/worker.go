@@ -0,0 +1,372 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// opTimeout 是确认, 重试与续期等单个 Redis 操作的超时, 不受 Run 的 ctx 影响
+const opTimeout = 5 * time.Second
+
+// errorBackoff 是读取任务出错后的等待时间, 避免 Redis 不可用时空转
+const errorBackoff = time.Second
+
+/*
+仅当任务仍属于当前消费者时设置其空闲时间, 返回投递次数, 不属于当前消费者时返回 -1.
+空闲时间为 0 即续期; XCLAIM 通过 RETRYCOUNT 保持投递次数不变.
+*/
+var touchScript = redis.NewScript(`
+local p = redis.call("XPENDING", KEYS[1], ARGV[1], ARGV[2], ARGV[2], 1)
+if not p[1] or p[1][2] ~= ARGV[3] then
+	return -1
+end
+redis.call("XCLAIM", KEYS[1], ARGV[1], ARGV[3], 0, ARGV[2], "IDLE", ARGV[4], "RETRYCOUNT", p[1][4], "JUSTID")
+return tonumber(p[1][4])
+`)
+
+/*
+记录一次失败: 投递次数达到上限时将任务连同 id, attempts 与 error 写入死信队列并确认删除, 返回 0;
+否则将空闲时间设置为 visibility - backoff, 使 XAUTOCLAIM 在 backoff 之后接管重试, 返回投递次数.
+任务不属于当前消费者时返回 -1.
+*/
+var failScript = redis.NewScript(`
+local p = redis.call("XPENDING", KEYS[1], ARGV[1], ARGV[2], ARGV[2], 1)
+if not p[1] or p[1][2] ~= ARGV[3] then
+	return -1
+end
+local count = tonumber(p[1][4])
+if count >= tonumber(ARGV[4]) then
+	local fields = {}
+	local e = redis.call("XRANGE", KEYS[1], ARGV[2], ARGV[2])
+	if e[1] then
+		fields = e[1][2]
+	end
+	table.insert(fields, "id")
+	table.insert(fields, ARGV[2])
+	table.insert(fields, "attempts")
+	table.insert(fields, count)
+	table.insert(fields, "error")
+	table.insert(fields, ARGV[8])
+	redis.call("XADD", KEYS[2], "*", unpack(fields))
+	redis.call("XACK", KEYS[1], ARGV[1], ARGV[2])
+	redis.call("XDEL", KEYS[1], ARGV[2])
+	return 0
+end
+local backoff = math.min(tonumber(ARGV[5]) * 2 ^ (count - 1), tonumber(ARGV[6]))
+local idle = math.max(math.floor(tonumber(ARGV[7]) - backoff), 0)
+redis.call("XCLAIM", KEYS[1], ARGV[1], ARGV[3], 0, ARGV[2], "IDLE", idle, "RETRYCOUNT", count, "JUSTID")
+return count
+`)
+
+// delivery 是一条已投递给当前消费者的消息
+type delivery struct {
+	msg     redis.XMessage
+	attempt int
+}
+
+func (q *queue[T]) Run(ctx context.Context, handler Handler[T]) error {
+	if err := q.ensureGroup(ctx); err != nil {
+		log.Printf("queue %s create group error: %v", q.stream, err)
+		return fmt.Errorf("queue %s create group error: %w", q.stream, err)
+	}
+
+	// 执行中的任务不随 ctx 取消, 超过 ShutdownTimeout 后才取消
+	jobCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
+
+	// 多个消费者同时移动到期的定时任务是安全的
+	pollCtx, stopPoll := context.WithCancel(ctx)
+	var pollDone sync.WaitGroup
+	pollDone.Go(func() {
+		q.poll(pollCtx)
+	})
+	defer func() {
+		stopPoll()
+		pollDone.Wait()
+	}()
+
+	concurrency := q.opts.Concurrency
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	cursor := "0-0"
+	var nextClaim time.Time
+	for ctx.Err() == nil {
+		// 等待至少一个空闲的执行位, 再尽可能多地占用空闲执行位
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			continue
+		}
+		free := 1
+	fill:
+		for free < concurrency {
+			select {
+			case slots <- struct{}{}:
+				free++
+			default:
+				break fill
+			}
+		}
+
+		var deliveries []delivery
+		var err error
+		if !time.Now().Before(nextClaim) {
+			// 接管失败不影响读取新任务, 游标保持不变, 下一轮从同一位置重试
+			var claimErr error
+			deliveries, cursor, claimErr = q.claim(ctx, cursor, free)
+			// 一轮扫描完成后等待 ClaimInterval, 否则继续扫描
+			if cursor == "0-0" || claimErr != nil {
+				nextClaim = time.Now().Add(q.opts.ClaimInterval)
+			}
+			if claimErr != nil && ctx.Err() == nil {
+				log.Printf("queue %s claim error: %v", q.stream, claimErr)
+				q.recoverGroup(ctx, claimErr)
+			}
+		}
+		if len(deliveries) == 0 {
+			deliveries, err = q.read(ctx, free)
+		}
+		if err != nil && ctx.Err() != nil {
+			// 停止时取消的读取不是错误
+			err = nil
+		}
+		if err != nil {
+			log.Printf("queue %s read error: %v", q.stream, err)
+			q.recoverGroup(ctx, err)
+		}
+
+		for _, d := range deliveries {
+			wg.Go(func() {
+				defer func() { <-slots }()
+				q.handle(jobCtx, handler, d)
+			})
+		}
+		for range free - len(deliveries) {
+			<-slots
+		}
+
+		if err != nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(errorBackoff):
+			}
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(q.opts.ShutdownTimeout):
+		log.Printf("queue %s shutdown timeout, cancelling running jobs", q.stream)
+		cancelJobs()
+		<-done
+	}
+	q.removeConsumer()
+	return nil
+}
+
+// recoverGroup 在 Redis 重启或 Stream 被删除导致消费者组不存在时重新创建
+func (q *queue[T]) recoverGroup(ctx context.Context, err error) {
+	if !strings.HasPrefix(err.Error(), "NOGROUP") {
+		return
+	}
+	if err := q.ensureGroup(ctx); err != nil {
+		log.Printf("queue %s create group error: %v", q.stream, err)
+	}
+}
+
+// read 读取新任务, 最多阻塞 Block; 已投递的消息在 ctx 结束后仍会执行, 因此不使用 ctx 取消读取
+func (q *queue[T]) read(ctx context.Context, count int) ([]delivery, error) {
+	streams, err := q.client.XReadGroup(context.WithoutCancel(ctx), &redis.XReadGroupArgs{
+		Group:    q.opts.Group,
+		Consumer: q.opts.Consumer,
+		Streams:  []string{q.stream, ">"},
+		Count:    int64(count),
+		Block:    q.opts.Block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var deliveries []delivery
+	for _, stream := range streams {
+		for _, msg := range stream.Messages {
+			deliveries = append(deliveries, delivery{msg: msg, attempt: 1})
+		}
+	}
+	return deliveries, nil
+}
+
+/*
+claim 通过 XAUTOCLAIM 接管空闲超过 VisibilityTimeout 的任务, 包括失效消费者的任务与退避结束的失败任务,
+返回下一轮扫描的起点, 出错时返回原来的 cursor.
+*/
+func (q *queue[T]) claim(ctx context.Context, cursor string, count int) ([]delivery, string, error) {
+	msgs, next, err := q.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   q.stream,
+		Group:    q.opts.Group,
+		MinIdle:  q.opts.VisibilityTimeout,
+		Start:    cursor,
+		Count:    int64(count),
+		Consumer: q.opts.Consumer,
+	}).Result()
+	if err != nil {
+		return nil, cursor, err
+	}
+	if len(msgs) == 0 {
+		return nil, next, nil
+	}
+
+	// 查询投递次数, 范围内还可能包含当前消费者正在执行的其他任务
+	pending, err := q.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream:   q.stream,
+		Group:    q.opts.Group,
+		Start:    msgs[0].ID,
+		End:      msgs[len(msgs)-1].ID,
+		Count:    int64(len(msgs) + q.opts.Concurrency),
+		Consumer: q.opts.Consumer,
+	}).Result()
+	attempts := make(map[string]int, len(pending))
+	if err == nil {
+		for _, p := range pending {
+			attempts[p.ID] = int(p.RetryCount)
+		}
+	} else {
+		log.Printf("queue %s pending error: %v", q.stream, err)
+	}
+
+	deliveries := make([]delivery, len(msgs))
+	for i, msg := range msgs {
+		deliveries[i] = delivery{msg: msg, attempt: max(attempts[msg.ID], 1)}
+	}
+	return deliveries, next, nil
+}
+
+func (q *queue[T]) handle(ctx context.Context, handler Handler[T], d delivery) {
+	job, err := q.decodeJob(d.msg, d.attempt)
+	if err != nil {
+		// 无法解码的任务重试也不会成功
+		q.fail(job, err, 0)
+		return
+	}
+	if job.Attempt > q.opts.MaxAttempts {
+		// 上一次执行时消费者失效, 任务可能导致进程崩溃, 不再执行
+		q.fail(job, fmt.Errorf("exceeded %d attempts", q.opts.MaxAttempts), 0)
+		return
+	}
+
+	stop := make(chan struct{})
+	heartbeatDone := make(chan struct{})
+	go q.heartbeat(job.ID, stop, heartbeatDone)
+	err = call(ctx, handler, job)
+	close(stop)
+	<-heartbeatDone
+
+	if err != nil {
+		log.Printf("queue %s job %s attempt %d error: %v", q.stream, job.ID, job.Attempt, err)
+		q.fail(job, err, q.opts.MaxAttempts)
+		return
+	}
+	q.ack(job.ID)
+}
+
+// call 执行 handler, 将 panic 转换为错误
+func call[T any](ctx context.Context, handler Handler[T], job *Job[T]) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// heartbeat 在任务执行期间每 VisibilityTimeout/3 续期一次, 避免长时间运行的任务被其他消费者接管
+func (q *queue[T]) heartbeat(id string, stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(q.opts.VisibilityTimeout / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+		n, err := touchScript.Run(ctx, q.client, []string{q.stream}, q.opts.Group, id, q.opts.Consumer, 0).Int64()
+		cancel()
+		switch {
+		case err != nil:
+			log.Printf("queue %s renew job %s error: %v", q.stream, id, err)
+		case n < 0:
+			log.Printf("queue %s job %s was taken over by another consumer", q.stream, id)
+			return
+		}
+	}
+}
+
+// ack 确认并删除任务
+func (q *queue[T]) ack(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAck(ctx, q.stream, q.opts.Group, id)
+		pipe.XDel(ctx, q.stream, id)
+		return nil
+	})
+	if err != nil {
+		// 未确认的任务会在 VisibilityTimeout 之后被重新执行
+		log.Printf("queue %s ack job %s error: %v", q.stream, id, err)
+	}
+}
+
+// fail 记录一次失败, 投递次数达到 maxAttempts 时移入死信队列, 否则按退避重试
+func (q *queue[T]) fail(job *Job[T], cause error, maxAttempts int) {
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	n, err := failScript.Run(ctx, q.client, []string{q.stream, q.dead},
+		q.opts.Group, job.ID, q.opts.Consumer, maxAttempts,
+		q.opts.MinBackoff.Milliseconds(), q.opts.MaxBackoff.Milliseconds(), q.opts.VisibilityTimeout.Milliseconds(),
+		cause.Error(),
+	).Int64()
+	switch {
+	case err != nil:
+		log.Printf("queue %s fail job %s error: %v", q.stream, job.ID, err)
+	case n < 0:
+		log.Printf("queue %s job %s was taken over by another consumer", q.stream, job.ID)
+	case n == 0:
+		log.Printf("queue %s job %s moved to %s: %v", q.stream, job.ID, q.dead, cause)
+	}
+}
+
+// removeConsumer 在当前消费者没有待确认的任务时将其从消费者组中删除, 避免消费者列表无限增长
+func (q *queue[T]) removeConsumer() {
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	pending, err := q.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream:   q.stream,
+		Group:    q.opts.Group,
+		Start:    "-",
+		End:      "+",
+		Count:    1,
+		Consumer: q.opts.Consumer,
+	}).Result()
+	if err != nil || len(pending) > 0 {
+		return
+	}
+	if err := q.client.XGroupDelConsumer(ctx, q.stream, q.opts.Group, q.opts.Consumer).Err(); err != nil {
+		log.Printf("queue %s remove consumer %s error: %v", q.stream, q.opts.Consumer, err)
+	}
+}
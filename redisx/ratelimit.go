@@ -0,0 +1,62 @@
+package redisx
+
+import (
+	"context"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/redisx/internal"
+	"github.com/LouYuanbo1/go-webservice/redisx/options"
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimitAlgorithm 是限流算法
+type RateLimitAlgorithm = internal.Algorithm
+
+const (
+	// SlidingWindowLog 记录窗口内每个请求的时间, 最精确, 内存与请求数成正比
+	SlidingWindowLog = internal.SlidingWindowLog
+	// SlidingWindowCounter 按上一个窗口的计数加权估算, 内存固定, 误差很小
+	SlidingWindowCounter = internal.SlidingWindowCounter
+	// TokenBucket 以 limit/period 的速度补充令牌, 允许 burst 个请求的突发
+	TokenBucket = internal.TokenBucket
+	// GCRA 与令牌桶等价, 只保存一个时间戳
+	GCRA = internal.GCRA
+)
+
+// RateLimitResult 是一次限流判断的结果
+type RateLimitResult = internal.RateLimitResult
+
+/*
+RateLimiter 是基于 Redis 的限流器, 每种算法都是一个原子执行的 Lua 脚本,
+使用 Redis 服务器时间, 因此多个实例共享同一份限额.
+
+RateLimiter is a Redis-backed rate limiter shared by all replicas.
+*/
+type RateLimiter interface {
+	// Allow 判断 key 的一个请求是否通过
+	Allow(ctx context.Context, key string) (RateLimitResult, error)
+	// AllowN 判断 key 的 n 个请求是否同时通过, n 超过容量时返回错误
+	AllowN(ctx context.Context, key string, n int) (RateLimitResult, error)
+}
+
+/*
+NewRateLimiter 创建每个 key 在 period 内最多允许 limit 个请求的限流器.
+
+Example:
+
+	limiter, err := redisx.NewRateLimiter(client, redisx.GCRA, 100, time.Minute,
+		options.WithBurst(20),
+		options.WithRateLimitKeyPrefix("ratelimit:api:"),
+	)
+	res, err := limiter.Allow(ctx, "user:1001")
+	if err == nil && !res.Allowed {
+		return fmt.Errorf("retry after %s", res.RetryAfter)
+	}
+*/
+func NewRateLimiter(client redis.UniversalClient, algorithm RateLimitAlgorithm, limit int, period time.Duration, opts ...options.RateLimitOption) (RateLimiter, error) {
+	rl, err := internal.NewRateLimiter(client, algorithm, limit, period, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return rl, nil
+}
@@ -0,0 +1,158 @@
+package redisx_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/redisx"
+	"github.com/LouYuanbo1/go-webservice/redisx/options"
+	"github.com/alicebob/miniredis/v2"
+)
+
+// rateLimitStep 在 advance 之后发起 n 个请求, 检查最后一个请求的结果
+type rateLimitStep struct {
+	advance   time.Duration
+	n         int
+	allowed   bool
+	remaining int
+	retry     time.Duration
+}
+
+// rateLimitClock 固定 miniredis 的 TIME, 脚本按服务器时间计算, 结果可以精确断言
+type rateLimitClock struct {
+	m   *miniredis.Miniredis
+	now time.Time
+}
+
+func newRateLimitClock(m *miniredis.Miniredis) *rateLimitClock {
+	// 10s 窗口的整数倍, 滑动窗口计数从窗口起点开始
+	c := &rateLimitClock{m: m, now: time.Unix(1_700_000_000, 0)}
+	m.SetTime(c.now)
+	return c
+}
+
+func (c *rateLimitClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+	c.m.SetTime(c.now)
+	c.m.FastForward(d)
+}
+
+func TestRateLimitAlgorithms(t *testing.T) {
+	// 每个 key 10s 内最多 3 个请求
+	cases := []struct {
+		algorithm redisx.RateLimitAlgorithm
+		steps     []rateLimitStep
+	}{
+		{redisx.SlidingWindowLog, []rateLimitStep{
+			{n: 1, allowed: true, remaining: 2},
+			{n: 2, allowed: true, remaining: 0},
+			// 最早的请求在 10s 后移出窗口
+			{n: 1, allowed: false, remaining: 0, retry: 10 * time.Second},
+			{advance: 4 * time.Second, n: 1, allowed: false, remaining: 0, retry: 6 * time.Second},
+			{advance: 6 * time.Second, n: 1, allowed: true, remaining: 2},
+		}},
+		{redisx.SlidingWindowCounter, []rateLimitStep{
+			{n: 3, allowed: true, remaining: 0},
+			// 下一个窗口中上一窗口的权重降到 2/3 时才能通过: 10s + 10s/3
+			{n: 1, allowed: false, remaining: 0, retry: 13334 * time.Millisecond},
+			{advance: 10 * time.Second, n: 1, allowed: false, remaining: 0, retry: 3334 * time.Millisecond},
+			{advance: 3334 * time.Millisecond, n: 1, allowed: true, remaining: 0},
+		}},
+		{redisx.TokenBucket, []rateLimitStep{
+			{n: 1, allowed: true, remaining: 2},
+			{n: 2, allowed: true, remaining: 0},
+			// 每 10s/3 补充一个令牌
+			{n: 1, allowed: false, remaining: 0, retry: 3334 * time.Millisecond},
+			{advance: 3334 * time.Millisecond, n: 1, allowed: true, remaining: 0},
+			{advance: 10 * time.Second, n: 1, allowed: true, remaining: 2},
+		}},
+		{redisx.GCRA, []rateLimitStep{
+			{n: 1, allowed: true, remaining: 2},
+			{n: 2, allowed: true, remaining: 0},
+			{n: 1, allowed: false, remaining: 0, retry: 3334 * time.Millisecond},
+			{advance: 3334 * time.Millisecond, n: 1, allowed: true, remaining: 0},
+			{advance: 10 * time.Second, n: 1, allowed: true, remaining: 2},
+		}},
+	}
+	for _, c := range cases {
+		t.Run(string(c.algorithm), func(t *testing.T) {
+			m, client := newMiniredisClient(t)
+			clock := newRateLimitClock(m)
+			rl, err := redisx.NewRateLimiter(client, c.algorithm, 3, 10*time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			for i, step := range c.steps {
+				clock.advance(step.advance)
+				res, err := rl.AllowN(ctx, "user:1", step.n)
+				if err != nil {
+					t.Fatalf("step %d: %v", i, err)
+				}
+				if res.Allowed != step.allowed || res.Remaining != step.remaining || res.RetryAfter != step.retry || res.Limit != 3 {
+					t.Errorf("step %d: got %+v, want allowed=%v remaining=%d retry=%v", i, res, step.allowed, step.remaining, step.retry)
+				}
+				if res.ResetAfter <= 0 || res.ResetAfter > 20*time.Second {
+					t.Errorf("step %d: reset after = %v", i, res.ResetAfter)
+				}
+			}
+			// 状态保存在带前缀的 key 中并设置了过期时间
+			if ttl := m.TTL("ratelimit:user:1"); ttl <= 0 {
+				t.Errorf("ttl of the rate limit key = %v", ttl)
+			}
+			// 其他 key 有独立的限额
+			if res, err := rl.Allow(ctx, "user:2"); err != nil || !res.Allowed || res.Remaining != 2 {
+				t.Errorf("other key = %+v, %v", res, err)
+			}
+			if _, err := rl.AllowN(ctx, "user:1", 4); err == nil {
+				t.Error("AllowN() above the capacity should fail")
+			}
+		})
+	}
+}
+
+func TestRateLimitBurst(t *testing.T) {
+	for _, algorithm := range []redisx.RateLimitAlgorithm{redisx.TokenBucket, redisx.GCRA} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			m, client := newMiniredisClient(t)
+			newRateLimitClock(m)
+			rl, err := redisx.NewRateLimiter(client, algorithm, 3, 10*time.Second,
+				options.WithBurst(5), options.WithRateLimitKeyPrefix("rl:api:"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			res, err := rl.AllowN(ctx, "user:1", 5)
+			if err != nil || !res.Allowed || res.Limit != 5 || res.Remaining != 0 {
+				t.Fatalf("AllowN(5) with burst 5 = %+v, %v", res, err)
+			}
+			if res, _ := rl.Allow(ctx, "user:1"); res.Allowed || res.RetryAfter != 3334*time.Millisecond {
+				t.Errorf("Allow() after the burst = %+v", res)
+			}
+			if !m.Exists("rl:api:user:1") {
+				t.Error("key prefix was not applied")
+			}
+		})
+	}
+}
+
+func TestNewRateLimiterValidation(t *testing.T) {
+	_, client := newMiniredisClient(t)
+	cases := map[string]func() (redisx.RateLimiter, error){
+		"NilClient": func() (redisx.RateLimiter, error) { return redisx.NewRateLimiter(nil, redisx.GCRA, 1, time.Second) },
+		"Algorithm": func() (redisx.RateLimiter, error) { return redisx.NewRateLimiter(client, "leaky", 1, time.Second) },
+		"Limit":     func() (redisx.RateLimiter, error) { return redisx.NewRateLimiter(client, redisx.GCRA, 0, time.Second) },
+		"Period": func() (redisx.RateLimiter, error) {
+			return redisx.NewRateLimiter(client, redisx.GCRA, 1, time.Microsecond)
+		},
+		"NegativeBurst": func() (redisx.RateLimiter, error) {
+			return redisx.NewRateLimiter(client, redisx.GCRA, 1, time.Second, options.WithBurst(-1))
+		},
+	}
+	for name, fn := range cases {
+		if _, err := fn(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
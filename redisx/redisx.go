@@ -9,6 +9,26 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+/*
+KeyErrors 记录批量操作中每个失败 key 的错误, 可以通过 errors.As 取出:
+
+	values, missing, err := rx.MGet(ctx, keys)
+	var keyErrs redisx.KeyErrors
+	if errors.As(err, &keyErrs) {
+		for key, err := range keyErrs {
+			log.Printf("decode %s: %v", key, err)
+		}
+	}
+*/
+type KeyErrors = internal.KeyErrors
+
+// ErrNotFound 由 GetOrLoad 的 loader 返回表示数据不存在, 命中缓存的空结果时 GetOrLoad 也返回该错误
+var ErrNotFound = internal.ErrNotFound
+
+/*
+RedisX 是类型化的 Redis 缓存, 批量操作(MGet, MSetWithTTL, MDel)在 Cluster 上按哈希槽分组,
+通过一次 pipeline 发送, 不要求 key 位于同一个槽.
+*/
 type RedisX[T any] interface {
 	SetWithTTL(ctx context.Context, key string, value T, opts ...options.TTLOption) error
 	HSetWithTTL(ctx context.Context, key string, value T, opts ...options.TTLOption) error
@@ -19,10 +39,42 @@ type RedisX[T any] interface {
 	HGetAll(ctx context.Context, key string) (T, error)
 	HGetAllPointer(ctx context.Context, key string) (*T, error)
 	Del(ctx context.Context, key string) error
+	// MGet 批量读取, 返回 key -> 值 与不存在的 key; 解码失败的 key 不在两者之中, 通过 KeyErrors 返回
+	MGet(ctx context.Context, keys []string) (map[string]T, []string, error)
+	// MSetWithTTL 通过 pipeline 批量写入并为每个 key 设置过期时间(见 options.WithKeyTTL), 编码失败时不写入任何 key
+	MSetWithTTL(ctx context.Context, values map[string]T, opts ...options.TTLOption) error
+	// MDel 批量删除, 返回删除的 key 数量
+	MDel(ctx context.Context, keys []string) (int64, error)
+	/*
+		GetOrLoad 读取缓存, 未命中时调用 loader 加载并写入缓存:
+		  - 同一进程内并发加载同一个 key 时只执行一次 loader
+		  - options.WithLoadLock 在多个实例之间加锁, 避免热点 key 过期时所有实例同时访问数据库
+		  - loader 返回 ErrNotFound 时, options.WithNegativeTTL 缓存空结果, 命中空结果同样返回 ErrNotFound
+		  - options.WithEarlyRefresh 在过期前按概率在后台刷新热点 key
+		loader 使用不会被调用方取消的 ctx 执行, 需要自行控制超时.
+	*/
+	GetOrLoad(ctx context.Context, key string, loader func(ctx context.Context) (T, error), opts ...options.LoadOption) (T, error)
+	// Publish 按 RedisX 的编码发布消息, 返回收到消息的订阅者数量
+	Publish(ctx context.Context, channel string, value T) (int64, error)
+	// Subscribe 订阅频道, 等待订阅确认后返回, 消息按 RedisX 的编码解码(见 Subscription)
+	Subscribe(ctx context.Context, channels ...string) (Subscription[T], error)
+	// PSubscribe 按模式订阅频道, 例如 "notify:*"
+	PSubscribe(ctx context.Context, patterns ...string) (Subscription[T], error)
 	Acquire(ctx context.Context, key string, expire time.Duration) (string, bool, error)
 	Release(ctx context.Context, key, lockID string) error
 }
 
-func NewRedisX[T any](client *redis.Client, defaultTTLKey time.Duration) RedisX[T] {
-	return internal.NewRedisX[T](client, defaultTTLKey)
+/*
+NewRedisX 创建 RedisX, 默认使用 JSON 编码且不压缩, 可以通过 options 选择编码与压缩方式.
+读取时按头部自动识别压缩数据, 因此开启压缩前写入的数据仍然可以读取; 更换 Codec 则需要清空或迁移已有的数据.
+
+Example:
+
+	rx := redisx.NewRedisX[Product](client, time.Hour,
+		options.WithCodec(codec.Msgpack),
+		options.WithCompression(codec.Zstd, 1024),
+	)
+*/
+func NewRedisX[T any](client redis.UniversalClient, defaultTTLKey time.Duration, opts ...options.EncodingOption) RedisX[T] {
+	return internal.NewRedisX[T](client, defaultTTLKey, opts...)
 }
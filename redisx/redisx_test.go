@@ -0,0 +1,141 @@
+package redisx_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/redisx"
+	"github.com/LouYuanbo1/go-webservice/redisx/codec"
+	"github.com/LouYuanbo1/go-webservice/redisx/options"
+)
+
+type cachedProduct struct {
+	ID          uint64 `json:"id"`
+	Description string `json:"description"`
+}
+
+func TestCompressionReadsUncompressedValues(t *testing.T) {
+	m, client := newMiniredisClient(t)
+	ctx := context.Background()
+	value := cachedProduct{ID: 1, Description: strings.Repeat("compressible ", 100)}
+
+	plain := redisx.NewRedisX[cachedProduct](client, time.Minute)
+	if err := plain.SetWithTTL(ctx, "product:1", value); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []codec.Compressor{codec.Gzip, codec.Zstd} {
+		t.Run(c.Name(), func(t *testing.T) {
+			compressed := redisx.NewRedisX[cachedProduct](client, time.Minute, options.WithCompression(c, 64))
+			got, err := compressed.Get(ctx, "product:1")
+			if err != nil {
+				t.Fatalf("read value written before compression: %v", err)
+			}
+			if got != value {
+				t.Errorf("got %+v", got)
+			}
+
+			key := "product:" + c.Name()
+			if err := compressed.SetWithTTL(ctx, key, value); err != nil {
+				t.Fatal(err)
+			}
+			raw, err := m.Get(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(raw, "\x00RXC") || len(raw) >= len(value.Description) {
+				t.Errorf("value was not compressed: %d bytes", len(raw))
+			}
+			// 关闭压缩后仍能按头部识别并解压
+			if got, err := plain.Get(ctx, key); err != nil || got != value {
+				t.Errorf("read compressed value without compression = %+v, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestMGetMissingAndKeyErrors(t *testing.T) {
+	m, client := newMiniredisClient(t)
+	ctx := context.Background()
+	rx := redisx.NewRedisX[cachedProduct](client, time.Minute)
+
+	if err := rx.MSetWithTTL(ctx, map[string]cachedProduct{
+		"p:1": {ID: 1, Description: "one"},
+		"p:2": {ID: 2, Description: "two"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	m.Set("p:bad", "not json")
+
+	values, missing, err := rx.MGet(ctx, []string{"p:1", "p:missing", "p:2", "p:bad", "p:1"})
+	var keyErrs redisx.KeyErrors
+	if !errors.As(err, &keyErrs) || len(keyErrs) != 1 || keyErrs["p:bad"] == nil {
+		t.Fatalf("expected KeyErrors for p:bad, got %v", err)
+	}
+	if len(values) != 2 || values["p:1"].Description != "one" || values["p:2"].Description != "two" {
+		t.Errorf("values = %+v", values)
+	}
+	if !slices.Equal(missing, []string{"p:missing"}) {
+		t.Errorf("missing = %v", missing)
+	}
+
+	values, missing, err = rx.MGet(ctx, []string{"p:1", "p:none"})
+	if err != nil || len(values) != 1 || !slices.Equal(missing, []string{"p:none"}) {
+		t.Errorf("MGet() = %v, %v, %v", values, missing, err)
+	}
+}
+
+func TestMSetWithKeyTTL(t *testing.T) {
+	m, client := newMiniredisClient(t)
+	ctx := context.Background()
+	rx := redisx.NewRedisX[cachedProduct](client, time.Minute)
+
+	values := map[string]cachedProduct{"p:1": {ID: 1}, "p:2": {ID: 2}, "p:3": {ID: 3}}
+	if err := rx.MSetWithTTL(ctx, values); err != nil {
+		t.Fatal(err)
+	}
+	for key := range values {
+		if ttl := m.TTL(key); ttl != time.Minute {
+			t.Errorf("%s: default ttl = %v, want %v", key, ttl, time.Minute)
+		}
+	}
+
+	ttls := map[string]time.Duration{"p:1": time.Second, "p:2": 2 * time.Second, "p:3": 3 * time.Second}
+	if err := rx.MSetWithTTL(ctx, values, options.WithKeyTTL(func(key string) time.Duration { return ttls[key] })); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range ttls {
+		if ttl := m.TTL(key); ttl != want {
+			t.Errorf("%s: ttl = %v, want %v", key, ttl, want)
+		}
+	}
+	m.FastForward(1500 * time.Millisecond)
+	if _, missing, _ := rx.MGet(ctx, []string{"p:1", "p:2", "p:3"}); !slices.Equal(missing, []string{"p:1"}) {
+		t.Errorf("missing after 1.5s = %v, want [p:1]", missing)
+	}
+}
+
+func TestMDelCounts(t *testing.T) {
+	_, client := newMiniredisClient(t)
+	ctx := context.Background()
+	rx := redisx.NewRedisX[cachedProduct](client, time.Minute)
+
+	if err := rx.MSetWithTTL(ctx, map[string]cachedProduct{"p:1": {ID: 1}, "p:2": {ID: 2}, "p:3": {ID: 3}}); err != nil {
+		t.Fatal(err)
+	}
+	// 重复的 key 只删除一次, 不存在的 key 不计数
+	deleted, err := rx.MDel(ctx, []string{"p:1", "p:2", "p:1", "p:missing"})
+	if err != nil || deleted != 2 {
+		t.Errorf("MDel() = %d, %v, want 2", deleted, err)
+	}
+	if deleted, err := rx.MDel(ctx, nil); err != nil || deleted != 0 {
+		t.Errorf("MDel(nil) = %d, %v", deleted, err)
+	}
+	if _, missing, _ := rx.MGet(ctx, []string{"p:1", "p:2", "p:3"}); !slices.Equal(missing, []string{"p:1", "p:2"}) {
+		t.Errorf("missing = %v", missing)
+	}
+}
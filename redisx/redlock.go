@@ -0,0 +1,41 @@
+package redisx
+
+import (
+	"github.com/LouYuanbo1/go-webservice/redisx/internal"
+	"github.com/LouYuanbo1/go-webservice/redisx/options"
+	"github.com/redis/go-redis/v9"
+)
+
+/*
+NewRedlock 在多个相互独立的 Redis 节点(通常为 3 或 5 个 InitRedis 创建的单机客户端)上实现 Redlock 算法,
+返回的 Lock 与 NewLock 的 API 相同, 可以直接替换:
+  - 并发在所有节点上加锁, 多数节点(N/2+1)成功且扣除加锁耗时与时钟漂移(TTL 的 1% + 2ms)后仍有效才算成功
+  - 加锁失败时在已成功的节点上撤销, 解锁时在所有节点上释放
+  - 看门狗在多数节点续期成功时延长锁, 多数节点上的锁丢失时关闭 Lost()
+  - 每个节点的请求超时为 max(TTL/100, 50ms), 单个节点不可用不会阻塞加锁
+
+防护令牌取多数节点返回值中的最大值, 并写回这些节点的计数器, 写回未在多数节点上成功时加锁失败.
+任意两个多数派至少有一个公共节点, 因此节点轮流不可用时令牌仍然严格递增;
+节点数据丢失(例如未开启持久化的节点重启)时不能保证.
+
+NewRedlock returns a Lock backed by the Redlock algorithm over independent Redis nodes.
+
+Example:
+
+	var clients []redis.UniversalClient
+	for _, cfg := range cfgs {
+		client, err := redisx.InitRedis(ctx, &cfg)
+		if err != nil {
+			return err
+		}
+		clients = append(clients, client)
+	}
+	lock, err := redisx.NewRedlock(clients, "payment:1001", options.WithLockTTL(10*time.Second))
+*/
+func NewRedlock(clients []redis.UniversalClient, key string, opts ...options.LockOption) (Lock, error) {
+	lock, err := internal.NewRedlock(clients, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
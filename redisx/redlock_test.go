@@ -0,0 +1,193 @@
+package redisx_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LouYuanbo1/go-webservice/redisx"
+	"github.com/LouYuanbo1/go-webservice/redisx/options"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newRedlockNodes(t *testing.T, n int) ([]*miniredis.Miniredis, []redis.UniversalClient) {
+	t.Helper()
+	servers := make([]*miniredis.Miniredis, n)
+	clients := make([]redis.UniversalClient, n)
+	for i := range n {
+		servers[i], clients[i] = newMiniredisClient(t)
+	}
+	return servers, clients
+}
+
+func newRedlock(t *testing.T, clients []redis.UniversalClient, opts ...options.LockOption) redisx.Lock {
+	t.Helper()
+	l, err := redisx.NewRedlock(clients, "job", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+// lockedOn 返回持有锁的节点数
+func lockedOn(servers []*miniredis.Miniredis) int {
+	var n int
+	for _, s := range servers {
+		if s.Exists("{job}") {
+			n++
+		}
+	}
+	return n
+}
+
+func TestNewRedlockValidation(t *testing.T) {
+	if _, err := redisx.NewRedlock(nil, "job"); err == nil {
+		t.Error("NewRedlock() with no clients should fail")
+	}
+	_, clients := newRedlockNodes(t, 2)
+	if _, err := redisx.NewRedlock(append(clients, nil), "job"); err == nil {
+		t.Error("NewRedlock() with a nil client should fail")
+	}
+}
+
+func TestRedlockQuorumWithNodesDown(t *testing.T) {
+	ctx := context.Background()
+	servers, clients := newRedlockNodes(t, 5)
+	servers[3].Close()
+	servers[4].Close()
+
+	l := newRedlock(t, clients)
+	if _, err := l.Lock(ctx); err != nil {
+		t.Fatalf("Lock() with 2 of 5 nodes down: %v", err)
+	}
+	if n := lockedOn(servers[:3]); n != 3 {
+		t.Fatalf("locked on %d of the 3 available nodes", n)
+	}
+	if held, err := l.Unlock(ctx); err != nil || !held {
+		t.Fatalf("Unlock() = %v, %v", held, err)
+	}
+	if n := lockedOn(servers[:3]); n != 0 {
+		t.Fatalf("lock still held on %d nodes after Unlock()", n)
+	}
+
+	servers[2].Close()
+	if _, _, err := newRedlock(t, clients).TryLock(ctx); err == nil {
+		t.Fatal("TryLock() with 3 of 5 nodes down should fail")
+	}
+	if n := lockedOn(servers[:2]); n != 0 {
+		t.Fatalf("failed TryLock() left the lock on %d nodes", n)
+	}
+}
+
+func TestRedlockMinorityHeldByAnotherOwner(t *testing.T) {
+	ctx := context.Background()
+	servers, clients := newRedlockNodes(t, 3)
+
+	other := redisx.NewLock(clients[0], "job", options.WithWatchdog(false))
+	if _, ok, err := other.TryLock(ctx); err != nil || !ok {
+		t.Fatalf("other TryLock() = %v, %v", ok, err)
+	}
+
+	l := newRedlock(t, clients)
+	token, ok, err := l.TryLock(ctx)
+	if err != nil || !ok || token <= 0 {
+		t.Fatalf("TryLock() with a minority taken = %d, %v, %v", token, ok, err)
+	}
+	if held, err := l.Unlock(ctx); err != nil || !held {
+		t.Fatalf("Unlock() = %v, %v", held, err)
+	}
+	// Unlock 在所有节点上执行, 但不会释放其他持有者的锁
+	if !servers[0].Exists("{job}") || lockedOn(servers) != 1 {
+		t.Fatal("Unlock() should release every node except the one held by another owner")
+	}
+}
+
+func TestRedlockMajorityHeldByAnotherOwnerRollsBack(t *testing.T) {
+	ctx := context.Background()
+	servers, clients := newRedlockNodes(t, 3)
+	for _, c := range clients[:2] {
+		other := redisx.NewLock(c, "job", options.WithWatchdog(false))
+		if _, ok, err := other.TryLock(ctx); err != nil || !ok {
+			t.Fatalf("other TryLock() = %v, %v", ok, err)
+		}
+	}
+
+	l := newRedlock(t, clients)
+	if _, ok, err := l.TryLock(ctx); err != nil || ok {
+		t.Fatalf("TryLock() with a majority taken = %v, %v, want false, nil", ok, err)
+	}
+	if servers[2].Exists("{job}") {
+		t.Fatal("failed TryLock() did not roll back the node it acquired")
+	}
+}
+
+func TestRedlockRejectsClockDrift(t *testing.T) {
+	ctx := context.Background()
+	servers, clients := newRedlockNodes(t, 3)
+
+	// TTL 小于时钟漂移补偿(TTL/100 + 2ms), 即使所有节点加锁成功也没有剩余的有效时间
+	l := newRedlock(t, clients, options.WithLockTTL(2*time.Millisecond), options.WithWatchdog(false))
+	if _, ok, err := l.TryLock(ctx); err != nil || ok {
+		t.Fatalf("TryLock() without validity left = %v, %v, want false, nil", ok, err)
+	}
+	if n := lockedOn(servers); n != 0 {
+		t.Fatalf("rejected TryLock() left the lock on %d nodes", n)
+	}
+}
+
+func TestRedlockWatchdogLosesQuorum(t *testing.T) {
+	ctx := context.Background()
+	servers, clients := newRedlockNodes(t, 3)
+	l := newRedlock(t, clients, options.WithLockTTL(300*time.Millisecond))
+	if _, err := l.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// 一个节点丢失时仍然满足多数, 继续续期
+	servers[0].Del("{job}")
+	time.Sleep(250 * time.Millisecond)
+	select {
+	case <-l.Lost():
+		t.Fatal("Lost() closed while a majority still held the lock")
+	default:
+	}
+
+	// 多数节点丢失后看门狗标记丢失
+	servers[1].Del("{job}")
+	select {
+	case <-l.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("Lost() not closed after the majority lost the lock")
+	}
+	if held, err := l.Unlock(ctx); err != nil || held {
+		t.Fatalf("Unlock() after loss = %v, %v, want false, nil", held, err)
+	}
+}
+
+func TestRedlockTokensIncreaseWithRotatingOutages(t *testing.T) {
+	ctx := context.Background()
+	servers, clients := newRedlockNodes(t, 3)
+	l := newRedlock(t, clients, options.WithWatchdog(false))
+
+	// 每次一个节点不可用, 各节点的计数器不同, 新的多数派可能不包含计数器最大的节点
+	var last int64
+	for i := range 6 {
+		down := servers[i%len(servers)]
+		down.Close()
+		token, ok, err := l.TryLock(ctx)
+		if err != nil || !ok {
+			t.Fatalf("round %d: TryLock() = %v, %v", i, ok, err)
+		}
+		if token <= last {
+			t.Fatalf("round %d: token %d after %d, want strictly increasing", i, token, last)
+		}
+		last = token
+		if held, err := l.Unlock(ctx); err != nil || !held {
+			t.Fatalf("round %d: Unlock() = %v, %v", i, held, err)
+		}
+		if err := down.Restart(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}